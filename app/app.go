@@ -0,0 +1,198 @@
+// Package app wires the API's handlers and middleware into a router. It is
+// shared by the server binary and by tests that need the full API.
+package app
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/pradist/todoapi/audit"
+	"github.com/pradist/todoapi/auth"
+	"github.com/pradist/todoapi/buildinfo"
+	"github.com/pradist/todoapi/middleware"
+	"github.com/pradist/todoapi/migrations"
+	"github.com/pradist/todoapi/settings"
+	"github.com/pradist/todoapi/todo"
+	"gorm.io/gorm"
+)
+
+// Config is everything NewRouter needs.
+type Config struct {
+	Sign          string
+	TokenTTL      time.Duration
+	TokenScope    auth.TokenScope
+	Limiter       *middleware.IPLimiter
+	CORS          middleware.CORSConfig
+	DebugSQL      bool
+	SecureHeaders bool
+	// StrictParams rejects unknown query parameters on every request, not
+	// just those sent with ?strict_params=true.
+	StrictParams bool
+	// PrettyJSON indents every JSON response, not just those requested
+	// with ?pretty=true.
+	PrettyJSON bool
+	// DebugBodies logs request and response bodies, with credentials
+	// redacted. It is meant for staging, never production.
+	DebugBodies bool
+	// Ready gates every route but /healthz until startup completes. Nil
+	// means always ready.
+	Ready *middleware.Readiness
+	// Concurrency bounds the requests handled at once. /healthz is never
+	// limited.
+	Concurrency middleware.ConcurrencyConfig
+	// LogSkipPaths are request paths left out of the access log, matched
+	// exactly.
+	LogSkipPaths []string
+	// DisabledEndpoints names Endpoints whose routes answer 404.
+	DisabledEndpoints []string
+	Todo              todo.Config
+}
+
+// Endpoints groups the optional routes under the names DisabledEndpoints
+// accepts. The core todo routes, authentication and health checks are
+// always on.
+var Endpoints = map[string][]string{
+	"apikeys":   {"POST /apikeys", "GET /apikeys", "DELETE /apikeys/:id"},
+	"audit":     {"GET /audit"},
+	"bulk":      {"POST /todos/bulk-update", "POST /todos/complete-by"},
+	"freshness": {"POST /todos/status"},
+	"graphql":   {"POST /graphql"},
+	"grouped":   {"GET /todos/grouped"},
+	"history":   {"GET /todos/:id/history"},
+	"ics":       {"GET /todos/:id/ics"},
+	"recent":    {"GET /todos/recent-completed"},
+	"settings":  {"GET /me/settings", "PUT /me/settings"},
+	"stats":     {"GET /admin/users/stats"},
+	"status":    {"POST /todos/:id/status"},
+	"sync":      {"POST /todos/sync"},
+	"templates": {"POST /templates", "GET /templates", "GET /templates/:id", "DELETE /templates/:id", "POST /todos/from-template/:id"},
+	"timer":     {"POST /todos/:id/timer/start", "POST /todos/:id/timer/stop"},
+	"today":     {"GET /todos/today"},
+	"transfer":  {"POST /todos/:id/transfer"},
+	"trash":     {"GET /todos/trash", "DELETE /todos/trash"},
+	"trends":    {"GET /todos/trends"},
+	"version":   {"GET /version"},
+}
+
+// Migrate applies the pending schema migrations for every model the API
+// serves.
+func Migrate(db *gorm.DB) error {
+	return migrations.Run(db)
+}
+
+// SignToken signs JWTs issued by POST /tokenz.
+func SignToken(token *jwt.Token, key any) (string, error) {
+	return token.SignedString(key)
+}
+
+// Protect returns the middleware that verifies tokens on protected routes.
+func (cfg Config) Protect() gin.HandlerFunc {
+	return auth.Protect([]byte(cfg.Sign), cfg.TokenScope)
+}
+
+// CheckJWT verifies that tokens signed by SignToken pass Protect.
+func (cfg Config) CheckJWT() error {
+	return auth.SelfCheck(cfg.Sign, cfg.TokenScope, SignToken, cfg.Protect())
+}
+
+// NewRouter builds the complete API on db.
+func NewRouter(db *gorm.DB, cfg Config) *gin.Engine {
+	r := gin.New()
+	var inFlight middleware.InFlight
+	r.Use(gin.LoggerWithConfig(gin.LoggerConfig{SkipPaths: cfg.LogSkipPaths}), gin.Recovery(), inFlight.Track())
+	if len(cfg.DisabledEndpoints) > 0 {
+		var routes []string
+		for _, name := range cfg.DisabledEndpoints {
+			routes = append(routes, Endpoints[name]...)
+		}
+		r.Use(middleware.DisableRoutes(routes...))
+	}
+	r.Use(middleware.PrettyJSON(cfg.PrettyJSON))
+	if cfg.SecureHeaders {
+		r.Use(middleware.SecureHeaders())
+	}
+	if cfg.DebugSQL {
+		r.Use(middleware.QueryCounter())
+	}
+	if cfg.DebugBodies {
+		r.Use(middleware.DebugBodies(gin.DefaultWriter, middleware.DefaultBodyLogLimit, "/tokenz", "/apikeys"))
+	}
+	if len(cfg.CORS.AllowOrigins) > 0 {
+		r.Use(middleware.CORSMiddleware(cfg.CORS))
+	}
+	if cfg.Ready != nil {
+		r.Use(cfg.Ready.Gate("/healthz"))
+	}
+	if cfg.Concurrency.Max > 0 {
+		r.Use(middleware.ConcurrencyLimit(cfg.Concurrency, "/healthz"))
+	}
+	strict := middleware.StrictParams(cfg.StrictParams)
+	r.GET("/healthz", strict(), func(c *gin.Context) {
+		if !cfg.Ready.Ready() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "starting"})
+			return
+		}
+		c.JSON(200, gin.H{"status": "ok"})
+	})
+	r.GET("/healthz/details", strict(), healthDetails(describeDatabase(db), &inFlight))
+	r.GET("/version", strict(), buildinfo.Handler)
+	r.POST("/tokenz", strict(), middleware.RateLimitMiddleware(cfg.Limiter), auth.AccessToken(db, cfg.Sign, cfg.TokenTTL, cfg.TokenScope, SignToken))
+	protected := r.Group("", auth.AcceptAPIKeys(db, cfg.Protect()))
+	shape := cfg.Todo.Response()
+	protected.GET("/me", strict(), auth.Me(shape))
+	settingsHandler := settings.NewHandler(db, shape)
+	protected.GET("/me/settings", strict(), settingsHandler.Get)
+	protected.PUT("/me/settings", strict(), settingsHandler.Put)
+	apiKeys := auth.NewAPIKeyHandler(db, shape)
+	protected.POST("/apikeys", strict(), apiKeys.Create)
+	protected.GET("/apikeys", strict(), apiKeys.List)
+	protected.DELETE("/apikeys/:id", strict(), apiKeys.Revoke)
+	handler := todo.NewTodoHandler(db, cfg.Todo)
+	protected.POST("/todos", strict("tz"), handler.NewTask)
+	protected.GET("/todos", strict(listParams...), handler.ListTasks)
+	protected.POST("/todos/bulk-update", strict("all"), handler.BulkUpdate)
+	protected.POST("/todos/complete-by", strict("priority", "has_due_date", "overdue", "match", "due_before", "all"), handler.CompleteBy)
+	protected.GET("/todos/trash", strict(viewParams...), handler.ListTrash)
+	protected.DELETE("/todos/trash", strict("before"), auth.RequireRole(auth.RoleAdmin), handler.PurgeTrash)
+	protected.GET("/todos/today", strict(viewParams...), handler.ListToday)
+	protected.GET("/todos/recent-completed", strict(append([]string{"days"}, viewParams...)...), handler.ListRecentCompleted)
+	protected.POST("/todos/sync", strict("preserve_created_at"), handler.Sync)
+	protected.POST("/todos/status", strict(), handler.Freshness)
+	protected.POST("/todos/from-template/:id", strict("tz"), handler.NewTaskFromTemplate)
+	protected.POST("/templates", strict(), handler.CreateTemplate)
+	protected.GET("/templates", strict("page", "limit"), handler.ListTemplates)
+	protected.GET("/templates/:id", strict(), handler.GetTemplate)
+	protected.DELETE("/templates/:id", strict(), handler.DeleteTemplate)
+	protected.GET("/todos/grouped", strict(groupedParams...), handler.ListGrouped)
+	protected.GET("/todos/trends", strict("period", "from", "to", "tz"), handler.Trends)
+	protected.POST("/graphql", strict(), handler.GraphQL)
+
+	auditHandler := audit.NewHandler(db, cfg.Todo.PageLimits, shape)
+	protected.GET("/audit", strict("page", "limit", "todo_id", "user_id", "action"), auth.RequireRole(auth.RoleAdmin), auditHandler.List)
+	protected.GET("/admin/users/stats", strict("page", "limit"), auth.RequireRole(auth.RoleAdmin), handler.UserStats)
+	protected.GET("/todos/:id", strict("fields", "include_deleted", "tz", "truncate"), handler.GetTask)
+	protected.GET("/todos/:id/ics", strict(), handler.ExportICS)
+	protected.GET("/todos/:id/history", strict("page", "limit"), handler.History)
+	protected.PUT("/todos/:id", strict("tz"), handler.PutTask)
+	protected.DELETE("/todos/:id", strict(), handler.DeleteTask)
+	protected.POST("/todos/:id/transfer", strict(), handler.Transfer)
+	protected.POST("/todos/:id/status", strict(), handler.AdvanceStatus)
+	protected.POST("/todos/:id/timer/start", strict(), handler.StartTimer)
+	protected.POST("/todos/:id/timer/stop", strict(), handler.StopTimer)
+	return r
+}
+
+// filterParams are the list filters shared by GET /todos and
+// GET /todos/grouped.
+var filterParams = []string{"completed", "priority", "has_due_date", "overdue", "match"}
+
+// viewParams shape the todos returned by the list-style endpoints.
+var viewParams = []string{"page", "limit", "fields", "tz", "truncate"}
+
+var (
+	listParams    = slices.Concat(viewParams, []string{"include_deleted", "modified_since", "sort"}, filterParams)
+	groupedParams = append([]string{"by"}, filterParams...)
+)
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/buildinfo"
+	"github.com/pradist/todoapi/middleware"
+	"gorm.io/gorm"
+)
+
+// started approximates when the process started.
+var started = time.Now()
+
+// databaseInfo describes the database driver, read once when the router
+// is built.
+type databaseInfo struct {
+	Driver  string `json:"driver"`
+	Version string `json:"version"`
+}
+
+// describeDatabase reads the driver name and server version of db. The
+// version is "unknown" if it can't be queried.
+func describeDatabase(db *gorm.DB) databaseInfo {
+	info := databaseInfo{Driver: db.Dialector.Name(), Version: "unknown"}
+	query := "SELECT version()"
+	if info.Driver == "sqlite" {
+		query = "SELECT sqlite_version()"
+	}
+	var version string
+	if err := db.Raw(query).Scan(&version).Error; err == nil && version != "" {
+		info.Version = version
+	}
+	return info
+}
+
+// healthDetails serves GET /healthz/details: uptime, build and runtime
+// versions, the database and the requests in flight, for debugging a
+// running server. It does no database work per request.
+func healthDetails(db databaseInfo, inFlight *middleware.InFlight) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uptime := time.Since(started)
+		c.JSON(http.StatusOK, gin.H{
+			"status":         "ok",
+			"uptime":         uptime.Round(time.Second).String(),
+			"uptime_seconds": int64(uptime.Seconds()),
+			"version":        buildinfo.Version,
+			"go_version":     runtime.Version(),
+			"database":       db,
+			"in_flight":      inFlight.Count(),
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Package apperr is the catalog of errors the API returns. Each kind of
+// error carries an HTTP status and a stable code that clients can match
+// on, and Write renders any error as
+//
+//	{"error": "<message>", "code": "<code>"}
+//
+// with the kind's status. Handlers return or pass these errors instead of
+// building error responses themselves.
+package apperr
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error is an error with the status and code it is reported with. Errors
+// made from a kind with With or Wrap match that kind under errors.Is.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	// Details are extra fields written alongside "error" and "code".
+	Details map[string]any
+
+	kind  *Error
+	cause error
+}
+
+// The kinds of error. Their messages are used only when nothing more
+// specific is given.
+var (
+	ErrBadRequest           = newKind(http.StatusBadRequest, "bad_request", "bad request")
+	ErrUnauthorized         = newKind(http.StatusUnauthorized, "unauthorized", "unauthorized")
+	ErrForbidden            = newKind(http.StatusForbidden, "forbidden", "forbidden")
+	ErrQuotaExceeded        = newKind(http.StatusForbidden, "quota_exceeded", "todo quota exceeded")
+	ErrNotFound             = newKind(http.StatusNotFound, "not_found", "not found")
+	ErrConflict             = newKind(http.StatusConflict, "conflict", "conflict")
+	ErrPreconditionFailed   = newKind(http.StatusPreconditionFailed, "precondition_failed", "precondition failed")
+	ErrValidation           = newKind(http.StatusUnprocessableEntity, "validation_failed", "validation failed")
+	ErrPreconditionRequired = newKind(http.StatusPreconditionRequired, "precondition_required", "precondition required")
+	ErrTooManyRequests      = newKind(http.StatusTooManyRequests, "too_many_requests", "too many requests")
+	ErrInternal             = newKind(http.StatusInternalServerError, "internal", "internal error")
+	ErrUnavailable          = newKind(http.StatusServiceUnavailable, "unavailable", "service unavailable")
+)
+
+// Kinds lists every kind of error, for documentation and tests.
+var Kinds = []*Error{
+	ErrBadRequest, ErrUnauthorized, ErrForbidden, ErrQuotaExceeded, ErrNotFound,
+	ErrConflict, ErrPreconditionFailed, ErrValidation, ErrPreconditionRequired,
+	ErrTooManyRequests, ErrInternal, ErrUnavailable,
+}
+
+func newKind(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Unwrap returns the error passed to Wrap, if any.
+func (e *Error) Unwrap() error { return e.cause }
+
+// Is reports whether target is the kind e was made from.
+func (e *Error) Is(target error) bool {
+	return e.kind != nil && e.kind == target
+}
+
+// With returns an error of the same kind as e with message.
+func (e *Error) With(message string) *Error {
+	kind := e.kind
+	if kind == nil {
+		kind = e
+	}
+	return &Error{Status: e.Status, Code: e.Code, Message: message, Details: e.Details, kind: kind}
+}
+
+// Wrap returns an error of the same kind as e that reports err's message
+// and unwraps to err.
+func (e *Error) Wrap(err error) *Error {
+	wrapped := e.With(err.Error())
+	wrapped.cause = err
+	return wrapped
+}
+
+// WithDetail returns a copy of e that also writes key: value.
+func (e *Error) WithDetail(key string, value any) *Error {
+	copied := *e
+	copied.Details = maps.Clone(e.Details)
+	if copied.Details == nil {
+		copied.Details = make(map[string]any)
+	}
+	copied.Details[key] = value
+	if copied.kind == nil {
+		copied.kind = e
+	}
+	return &copied
+}
+
+// From returns err as an *Error. An *Error wrapped with more context keeps
+// its kind and reports the full message; errors outside the catalog are
+// internal errors reporting their own message.
+func From(err error) *Error {
+	var e *Error
+	if !errors.As(err, &e) {
+		return ErrInternal.Wrap(err)
+	}
+	if error(e) != err {
+		return e.Wrap(err)
+	}
+	return e
+}
+
+// Body is the JSON body err is written as.
+func Body(err error) gin.H {
+	e := From(err)
+	body := gin.H{"error": e.Message, "code": e.Code}
+	for k, v := range e.Details {
+		body[k] = v
+	}
+	return body
+}
+
+// Write writes err as a JSON error response with its kind's status.
+func Write(c *gin.Context, err error) {
+	c.JSON(From(err).Status, Body(err))
+}
+
+// Abort writes err like Write and stops the handler chain.
+func Abort(c *gin.Context, err error) {
+	c.AbortWithStatusJSON(From(err).Status, Body(err))
+}
@@ -0,0 +1,138 @@
+package apperr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestKinds_StatusAndCode: every kind has the status and code clients rely on
+func TestKinds_StatusAndCode(t *testing.T) {
+	want := map[*Error]struct {
+		status int
+		code   string
+	}{
+		ErrBadRequest:           {http.StatusBadRequest, "bad_request"},
+		ErrUnauthorized:         {http.StatusUnauthorized, "unauthorized"},
+		ErrForbidden:            {http.StatusForbidden, "forbidden"},
+		ErrQuotaExceeded:        {http.StatusForbidden, "quota_exceeded"},
+		ErrNotFound:             {http.StatusNotFound, "not_found"},
+		ErrConflict:             {http.StatusConflict, "conflict"},
+		ErrPreconditionFailed:   {http.StatusPreconditionFailed, "precondition_failed"},
+		ErrValidation:           {http.StatusUnprocessableEntity, "validation_failed"},
+		ErrPreconditionRequired: {http.StatusPreconditionRequired, "precondition_required"},
+		ErrTooManyRequests:      {http.StatusTooManyRequests, "too_many_requests"},
+		ErrInternal:             {http.StatusInternalServerError, "internal"},
+		ErrUnavailable:          {http.StatusServiceUnavailable, "unavailable"},
+	}
+	if len(Kinds) != len(want) {
+		t.Fatalf("expected %d kinds, got %d", len(want), len(Kinds))
+	}
+	codes := make(map[string]bool)
+	for _, kind := range Kinds {
+		w, ok := want[kind]
+		if !ok {
+			t.Errorf("unexpected kind %q", kind.Code)
+			continue
+		}
+		if kind.Status != w.status || kind.Code != w.code {
+			t.Errorf("%s: expected %d %q, got %d %q", w.code, w.status, w.code, kind.Status, kind.Code)
+		}
+		if codes[kind.Code] {
+			t.Errorf("code %q is used twice", kind.Code)
+		}
+		codes[kind.Code] = true
+	}
+}
+
+// TestWith_MatchesKind: errors made from a kind match it, not other kinds or each other
+func TestWith_MatchesKind(t *testing.T) {
+	missing := ErrNotFound.With("todo not found")
+	gone := ErrNotFound.With("template not found")
+
+	if !errors.Is(missing, ErrNotFound) || errors.Is(missing, ErrConflict) {
+		t.Error("expected the error to match only its own kind")
+	}
+	if errors.Is(missing, gone) {
+		t.Error("expected errors of the same kind to stay distinct")
+	}
+	if missing.Status != http.StatusNotFound || missing.Code != "not_found" || missing.Error() != "todo not found" {
+		t.Errorf("expected a 404 not_found with the given message, got %d %q %q", missing.Status, missing.Code, missing.Error())
+	}
+	if !errors.Is(missing.WithDetail("id", 1), ErrNotFound) {
+		t.Error("expected details to keep the kind")
+	}
+}
+
+// TestWrap_Unwraps: wrapped errors report and unwrap to their cause
+func TestWrap_Unwraps(t *testing.T) {
+	cause := errors.New("due_date must not be in the past")
+	err := ErrValidation.Wrap(cause)
+
+	if !errors.Is(err, cause) || !errors.Is(err, ErrValidation) || err.Error() != cause.Error() {
+		t.Errorf("expected the cause's message and both matches, got %q", err.Error())
+	}
+}
+
+// TestFrom: catalog errors keep their kind through fmt wrapping; others are internal
+func TestFrom(t *testing.T) {
+	stale := ErrPreconditionFailed.With("todo has changed")
+
+	if got := From(stale); got != stale {
+		t.Errorf("expected the error itself, got %+v", got)
+	}
+	got := From(fmt.Errorf("todo 3: %w", stale))
+	if got.Status != http.StatusPreconditionFailed || got.Error() != "todo 3: todo has changed" {
+		t.Errorf("expected a 412 with the full message, got %d %q", got.Status, got.Error())
+	}
+	got = From(errors.New("disk I/O error"))
+	if got.Status != http.StatusInternalServerError || got.Code != "internal" || got.Error() != "disk I/O error" {
+		t.Errorf("expected an internal error with the message, got %d %q %q", got.Status, got.Code, got.Error())
+	}
+}
+
+// TestWrite_Body: errors are written as {"error", "code"} with their details and status
+func TestWrite_Body(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		Write(c, ErrConflict.With("invalid status transition").WithDetail("allowed", []string{"open"}))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if body["error"] != "invalid status transition" || body["code"] != "conflict" {
+		t.Errorf("expected the message and code, got %v", body)
+	}
+	if allowed, _ := body["allowed"].([]any); len(allowed) != 1 || allowed[0] != "open" {
+		t.Errorf("expected the allowed detail, got %v", body["allowed"])
+	}
+}
+
+// TestAbort_StopsChain: Abort writes the error and skips later handlers
+func TestAbort_StopsChain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	reached := false
+	r.GET("/", func(c *gin.Context) { Abort(c, ErrForbidden) }, func(c *gin.Context) { reached = true })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusForbidden || reached {
+		t.Errorf("expected a 403 that stops the chain, got %d (reached: %v)", w.Code, reached)
+	}
+}
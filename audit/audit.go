@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionDelete   = "delete"
+	ActionTransfer = "transfer"
+	ActionPurge    = "purge"
+)
+
+// Log is one recorded mutation of a todo. Changes maps each changed field
+// to its old and new value.
+type Log struct {
+	ID        uint            `json:"id" gorm:"primarykey"`
+	Action    string          `json:"action" gorm:"not null"`
+	TodoID    uint            `json:"todo_id" gorm:"index"`
+	UserID    uint            `json:"user_id" gorm:"index"`
+	Changes   json.RawMessage `json:"changes" gorm:"type:text"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// TableName is "audit_logs" under GORM's default naming, with the naming
+// strategy's prefix and pluralisation applied.
+func (Log) TableName(namer schema.Namer) string {
+	return namer.TableName("AuditLog")
+}
+
+// Change is the old and new value of one field.
+type Change struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// ignoredFields change on every write and would only add noise to a diff.
+var ignoredFields = map[string]bool{"UpdatedAt": true}
+
+// Diff compares the JSON forms of before and after and returns the fields
+// whose values differ. A nil before (create) or after (delete) is treated as
+// an object with no fields.
+func Diff(before, after any) (map[string]Change, error) {
+	old, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]Change{}
+	for k, v := range cur {
+		if !ignoredFields[k] && !reflect.DeepEqual(old[k], v) {
+			changes[k] = Change{Old: old[k], New: v}
+		}
+	}
+	for k, v := range old {
+		if _, ok := cur[k]; !ok && !ignoredFields[k] {
+			changes[k] = Change{Old: v}
+		}
+	}
+	return changes, nil
+}
+
+func toFields(v any) (map[string]any, error) {
+	fields := map[string]any{}
+	if v == nil {
+		return fields, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return fields, json.Unmarshal(raw, &fields)
+}
+
+// Record writes an audit entry for a mutation of todoID by userID. It must be
+// called with the transaction that performs the mutation so that the entry
+// and the change are committed or rolled back together. If the transaction
+// carries a Buffer, the entry is added to it instead, to be written once the
+// transaction commits. Updates that change nothing are not recorded.
+func Record(tx *gorm.DB, action string, todoID, userID uint, before, after any) error {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return err
+	}
+	if action == ActionUpdate && len(changes) == 0 {
+		return nil
+	}
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	entry := Log{Action: action, TodoID: todoID, UserID: userID, Changes: raw}
+	if buf := bufferFrom(tx); buf != nil {
+		entry.CreatedAt = time.Now()
+		buf.entries = append(buf.entries, entry)
+		return nil
+	}
+	return tx.Create(&entry).Error
+}
@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	if err := db.AutoMigrate(&Log{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+type item struct {
+	Title     string `json:"text"`
+	Done      bool   `json:"done"`
+	UpdatedAt string
+}
+
+func TestDiff_Create(t *testing.T) {
+	changes, err := Diff(nil, item{Title: "new", Done: false})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changed fields, got %v", changes)
+	}
+	if changes["text"].Old != nil || changes["text"].New != "new" {
+		t.Errorf("expected text nil -> new, got %+v", changes["text"])
+	}
+}
+
+// TestDiff_Update: only fields whose values differ are reported, UpdatedAt is ignored
+func TestDiff_Update(t *testing.T) {
+	changes, err := Diff(item{Title: "same", Done: false, UpdatedAt: "t1"}, item{Title: "same", Done: true, UpdatedAt: "t2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("expected only done to change, got %v", changes)
+	}
+	if changes["done"].Old != false || changes["done"].New != true {
+		t.Errorf("expected done false -> true, got %+v", changes["done"])
+	}
+}
+
+func TestDiff_Delete(t *testing.T) {
+	changes, err := Diff(item{Title: "gone"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if changes["text"].Old != "gone" || changes["text"].New != nil {
+		t.Errorf("expected text gone -> nil, got %+v", changes["text"])
+	}
+}
+
+func TestRecord_StoresEntry(t *testing.T) {
+	db := setupTestDB(t)
+
+	if err := Record(db, ActionUpdate, 7, 3, item{Title: "a"}, item{Title: "b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var entry Log
+	if err := db.First(&entry).Error; err != nil {
+		t.Fatalf("expected an audit entry: %v", err)
+	}
+	if entry.Action != ActionUpdate || entry.TodoID != 7 || entry.UserID != 3 {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	var changes map[string]Change
+	if err := json.Unmarshal(entry.Changes, &changes); err != nil {
+		t.Fatalf("changes are not valid JSON: %v", err)
+	}
+	if changes["text"].Old != "a" || changes["text"].New != "b" {
+		t.Errorf("expected text a -> b, got %+v", changes["text"])
+	}
+}
+
+// TestRecord_SkipsNoopUpdate: an update that changes nothing is not recorded
+func TestRecord_SkipsNoopUpdate(t *testing.T) {
+	db := setupTestDB(t)
+
+	if err := Record(db, ActionUpdate, 1, 1, item{Title: "a"}, item{Title: "a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var count int64
+	db.Model(&Log{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected no audit entries, got %d", count)
+	}
+}
+
+// TestRecord_RolledBackWithTransaction: an entry written in a failed transaction is discarded
+func TestRecord_RolledBackWithTransaction(t *testing.T) {
+	db := setupTestDB(t)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := Record(tx, ActionCreate, 1, 1, nil, item{Title: "a"}); err != nil {
+			return err
+		}
+		return errors.New("mutation failed")
+	})
+	if err == nil {
+		t.Fatal("expected the transaction to fail")
+	}
+
+	var count int64
+	db.Model(&Log{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected no orphan audit entries, got %d", count)
+	}
+}
@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Buffer holds the entries recorded in one transaction until it commits.
+// Record fills it instead of writing when the transaction's context
+// carries one.
+type Buffer struct {
+	entries []Log
+}
+
+type bufferKey struct{}
+
+// WithBuffer returns ctx carrying buf, so transactions started with it
+// record their entries into buf.
+func WithBuffer(ctx context.Context, buf *Buffer) context.Context {
+	return context.WithValue(ctx, bufferKey{}, buf)
+}
+
+func bufferFrom(tx *gorm.DB) *Buffer {
+	if tx.Statement == nil || tx.Statement.Context == nil {
+		return nil
+	}
+	buf, _ := tx.Statement.Context.Value(bufferKey{}).(*Buffer)
+	return buf
+}
+
+// Batcher writes audit entries in batches from a background goroutine.
+// Entries reach the database up to the flush interval after their change
+// commits and are lost if the process dies in between; in exchange a
+// mutation no longer pays for its own audit insert.
+type Batcher struct {
+	db       *gorm.DB
+	size     int
+	interval time.Duration
+	entries  chan Log
+	done     chan struct{}
+	mu       sync.RWMutex
+	closed   bool
+}
+
+// NewBatcher starts a Batcher that writes to db once size entries are
+// waiting or interval has passed since the first of them, whichever comes
+// first. Close it to write what is left.
+func NewBatcher(db *gorm.DB, size int, interval time.Duration) *Batcher {
+	b := &Batcher{
+		db:       db,
+		size:     size,
+		interval: interval,
+		entries:  make(chan Log, size),
+		done:     make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Add queues the entries held by buf, blocking while the queue is full.
+// After Close they are written straight away instead.
+func (b *Batcher) Add(buf *Buffer) {
+	if len(buf.entries) == 0 {
+		return
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		if err := b.db.Create(&buf.entries).Error; err != nil {
+			fmt.Printf("audit write failed, %d entries lost: %s\n", len(buf.entries), err)
+		}
+		return
+	}
+	for _, e := range buf.entries {
+		b.entries <- e
+	}
+}
+
+// Close stops queueing entries and waits until the queued ones are written
+// or ctx is done.
+func (b *Batcher) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.entries)
+	}
+	b.mu.Unlock()
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Batcher) run() {
+	defer close(b.done)
+	timer := time.NewTimer(b.interval)
+	timer.Stop()
+	batch := make([]Log, 0, b.size)
+	flush := func() {
+		timer.Stop()
+		if len(batch) == 0 {
+			return
+		}
+		if err := b.db.CreateInBatches(batch, b.size).Error; err != nil {
+			fmt.Printf("audit flush failed, %d entries lost: %s\n", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case e, ok := <-b.entries:
+			if !ok {
+				flush()
+				return
+			}
+			if len(batch) == 0 {
+				timer.Reset(b.interval)
+			}
+			batch = append(batch, e)
+			if len(batch) >= b.size {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
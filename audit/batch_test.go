@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func setupBatchDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := setupTestDB(t)
+	// Every connection to :memory: is a new database, so the background
+	// writer must share the one the test migrated.
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get the connection pool: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
+
+// buffered records n updates into a new Buffer the way a transaction would.
+func buffered(t *testing.T, db *gorm.DB, n int) *Buffer {
+	t.Helper()
+	buf := new(Buffer)
+	tx := db.WithContext(WithBuffer(context.Background(), buf))
+	for i := range n {
+		if err := Record(tx, ActionUpdate, uint(i+1), 1, item{Title: "old"}, item{Title: "new"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return buf
+}
+
+func countLogs(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(&Log{}).Count(&n).Error; err != nil {
+		t.Fatalf("failed to count entries: %v", err)
+	}
+	return n
+}
+
+// TestRecord_Buffered: with a buffer in the context, entries wait in it instead of being written
+func TestRecord_Buffered(t *testing.T) {
+	db := setupBatchDB(t)
+
+	buf := buffered(t, db, 2)
+
+	if n := countLogs(t, db); n != 0 {
+		t.Errorf("expected nothing written yet, got %d entries", n)
+	}
+	if len(buf.entries) != 2 || buf.entries[0].CreatedAt.IsZero() {
+		t.Errorf("expected 2 timestamped entries in the buffer, got %+v", buf.entries)
+	}
+}
+
+// TestBatcher_FlushesOnInterval: queued entries are written once the interval passes
+func TestBatcher_FlushesOnInterval(t *testing.T) {
+	db := setupBatchDB(t)
+	b := NewBatcher(db, 100, 10*time.Millisecond)
+	defer b.Close(context.Background())
+
+	b.Add(buffered(t, db, 3))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for countLogs(t, db) != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 3 entries to be written, got %d", countLogs(t, db))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestBatcher_FlushesFullBatch: a full batch is written without waiting for the interval
+func TestBatcher_FlushesFullBatch(t *testing.T) {
+	db := setupBatchDB(t)
+	b := NewBatcher(db, 2, time.Hour)
+	defer b.Close(context.Background())
+
+	b.Add(buffered(t, db, 2))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for countLogs(t, db) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the full batch to be written, got %d entries", countLogs(t, db))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestBatcher_CloseFlushes: Close writes what is queued, and later entries are written directly
+func TestBatcher_CloseFlushes(t *testing.T) {
+	db := setupBatchDB(t)
+	b := NewBatcher(db, 100, time.Hour)
+
+	b.Add(buffered(t, db, 3))
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := countLogs(t, db); n != 3 {
+		t.Errorf("expected 3 entries after Close, got %d", n)
+	}
+
+	b.Add(buffered(t, db, 1))
+	if n := countLogs(t, db); n != 4 {
+		t.Errorf("expected an entry added after Close to be written, got %d entries", n)
+	}
+	if err := b.Close(context.Background()); err != nil {
+		t.Errorf("expected a second Close to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/pagination"
+	"github.com/pradist/todoapi/response"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	db     *gorm.DB
+	limits pagination.Limits
+	shape  response.Options
+}
+
+func NewHandler(db *gorm.DB, limits pagination.Limits, shape response.Options) *Handler {
+	return &Handler{db: db, limits: limits, shape: shape}
+}
+
+// List returns audit entries newest first. It can be narrowed with
+// ?todo_id=, ?user_id= and ?action=, and is paginated with ?page= and ?limit=.
+func (h *Handler) List(c *gin.Context) {
+	p, err := h.limits.FromQuery(c)
+	if err != nil {
+		apperr.Write(c, apperr.ErrBadRequest.Wrap(err))
+		return
+	}
+	response.SetPage(c, p)
+
+	q := h.db.WithContext(c.Request.Context()).Model(&Log{})
+	for _, column := range []string{"todo_id", "user_id"} {
+		v, ok := c.GetQuery(column)
+		if !ok {
+			continue
+		}
+		id, err := strconv.ParseUint(v, 10, strconv.IntSize)
+		if err != nil {
+			apperr.Write(c, apperr.ErrBadRequest.With(column+" must be a positive integer"))
+			return
+		}
+		q = q.Where(column+" = ?", id)
+	}
+	if action, ok := c.GetQuery("action"); ok {
+		q = q.Where("action = ?", action)
+	}
+
+	entries := []Log{}
+	if err := q.Order("id DESC").Limit(p.Limit).Offset(p.Offset()).Find(&entries).Error; err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	h.shape.JSON(c, http.StatusOK, entries)
+}
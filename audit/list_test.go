@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/pagination"
+	"github.com/pradist/todoapi/response"
+)
+
+func setupListRouter(t *testing.T) (*gin.Engine, *Handler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := NewHandler(setupTestDB(t), pagination.Limits{}, response.Options{})
+	r := gin.New()
+	r.GET("/audit", h.List)
+	return r, h
+}
+
+func doAuditList(r *gin.Engine, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/audit"+query, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decodeEntries(t *testing.T, w *httptest.ResponseRecorder) []Log {
+	t.Helper()
+	var entries []Log
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return entries
+}
+
+func TestList_NewestFirstAndPaginated(t *testing.T) {
+	r, h := setupListRouter(t)
+	for i := uint(1); i <= 3; i++ {
+		Record(h.db, ActionCreate, i, 1, nil, item{Title: "t"})
+	}
+
+	entries := decodeEntries(t, doAuditList(r, "?limit=2"))
+	if len(entries) != 2 || entries[0].TodoID != 3 || entries[1].TodoID != 2 {
+		t.Fatalf("expected todos 3 and 2 on the first page, got %+v", entries)
+	}
+
+	entries = decodeEntries(t, doAuditList(r, "?limit=2&page=2"))
+	if len(entries) != 1 || entries[0].TodoID != 1 {
+		t.Errorf("expected todo 1 on the second page, got %+v", entries)
+	}
+}
+
+func TestList_Filters(t *testing.T) {
+	r, h := setupListRouter(t)
+	Record(h.db, ActionCreate, 1, 1, nil, item{Title: "a"})
+	Record(h.db, ActionUpdate, 1, 1, item{Title: "a"}, item{Title: "b"})
+	Record(h.db, ActionCreate, 2, 2, nil, item{Title: "c"})
+
+	if entries := decodeEntries(t, doAuditList(r, "?todo_id=1")); len(entries) != 2 {
+		t.Errorf("expected 2 entries for todo 1, got %d", len(entries))
+	}
+	if entries := decodeEntries(t, doAuditList(r, "?user_id=2")); len(entries) != 1 {
+		t.Errorf("expected 1 entry for user 2, got %d", len(entries))
+	}
+	if entries := decodeEntries(t, doAuditList(r, "?action=update")); len(entries) != 1 {
+		t.Errorf("expected 1 update entry, got %d", len(entries))
+	}
+}
+
+func TestList_InvalidQuery(t *testing.T) {
+	r, _ := setupListRouter(t)
+
+	for _, query := range []string{"?todo_id=abc", "?user_id=-1", "?page=0"} {
+		if w := doAuditList(r, query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+// TestList_Shaped: with the envelope and string ids, entries come in data with their page in meta
+func TestList_Shaped(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewHandler(setupTestDB(t), pagination.Limits{}, response.Options{Envelope: true, IDsAsStrings: true})
+	r := gin.New()
+	r.GET("/audit", h.List)
+	Record(h.db, ActionCreate, 1, 1, nil, item{Title: "a"})
+
+	var body struct {
+		Data []map[string]any `json:"data"`
+		Meta map[string]any   `json:"meta"`
+	}
+	if err := json.Unmarshal(doAuditList(r, "?limit=5").Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(body.Data) != 1 || body.Data[0]["id"] != "1" {
+		t.Errorf("expected one entry with id \"1\", got %+v", body.Data)
+	}
+	if body.Meta["limit"] != 5.0 || body.Meta["count"] != 1.0 {
+		t.Errorf("expected limit 5 and count 1 in meta, got %v", body.Meta)
+	}
+}
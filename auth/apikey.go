@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/response"
+	"gorm.io/gorm"
+)
+
+// APIKeyHeader carries an API key in place of a bearer token.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyPrefix starts every key, so leaked keys are easy to recognise.
+const apiKeyPrefix = "tdk_"
+
+// APIKey lets scripts act as a user without a JWT. Only a SHA-256 hash of
+// the key is stored; the key itself is shown once, when it is created.
+// Revoking a key soft-deletes it.
+type APIKey struct {
+	gorm.Model
+	UserID uint   `gorm:"index;not null"`
+	Name   string `gorm:"not null"`
+	// Prefix is the start of the key, to tell keys apart in listings.
+	Prefix string `gorm:"not null"`
+	Hash   string `gorm:"uniqueIndex;not null"`
+}
+
+// newAPIKey returns a random key with 256 bits of entropy.
+func newAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashAPIKey is a fast hash, which is enough for random keys of this size
+// and lets keys be looked up by hash.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// AcceptAPIKeys authenticates requests that send X-API-Key and hands all
+// others to protect. A key must exist, not be revoked and belong to an
+// existing user; the request then carries that user's id and role as if it
+// had presented their token. Invalid keys are 401 and never fall back to a
+// token.
+func AcceptAPIKeys(db *gorm.DB, protect gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(APIKeyHeader)
+		if key == "" {
+			protect(c)
+			return
+		}
+
+		conn := db.WithContext(c.Request.Context())
+		var apiKey APIKey
+		if err := conn.Where("hash = ?", hashAPIKey(key)).First(&apiKey).Error; err != nil {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		var user User
+		if err := conn.First(&user, apiKey.UserID).Error; err != nil {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		SetClaims(c, &Claims{
+			Roles:          []string{user.Role},
+			StandardClaims: jwt.StandardClaims{Subject: strconv.FormatUint(uint64(user.ID), 10)},
+		})
+		c.Next()
+	}
+}
+
+type createAPIKeyRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type apiKeyResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Prefix    string    `json:"prefix"`
+	CreatedAt time.Time `json:"created_at"`
+	// Key is only returned when the key is created.
+	Key string `json:"key,omitempty"`
+}
+
+func newAPIKeyResponse(k APIKey) apiKeyResponse {
+	return apiKeyResponse{ID: k.ID, Name: k.Name, Prefix: k.Prefix, CreatedAt: k.CreatedAt}
+}
+
+// APIKeyHandler serves the caller's API keys. It must run behind Protect
+// or AcceptAPIKeys.
+type APIKeyHandler struct {
+	db    *gorm.DB
+	shape response.Options
+}
+
+func NewAPIKeyHandler(db *gorm.DB, shape response.Options) *APIKeyHandler {
+	return &APIKeyHandler{db: db, shape: shape}
+}
+
+// Create issues a new key for the caller and returns it once.
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	userID, ok := UserID(c)
+	if !ok {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Write(c, apperr.ErrBadRequest.With("name is required (up to 100 characters)"))
+		return
+	}
+
+	key, err := newAPIKey()
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	apiKey := APIKey{UserID: userID, Name: req.Name, Prefix: key[:len(apiKeyPrefix)+6], Hash: hashAPIKey(key)}
+	if err := h.db.WithContext(c.Request.Context()).Create(&apiKey).Error; err != nil {
+		apperr.Write(c, err)
+		return
+	}
+
+	resp := newAPIKeyResponse(apiKey)
+	resp.Key = key
+	h.shape.JSON(c, http.StatusCreated, resp)
+}
+
+// List returns the caller's active keys, newest first, without the keys
+// themselves.
+func (h *APIKeyHandler) List(c *gin.Context) {
+	userID, ok := UserID(c)
+	if !ok {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	var keys []APIKey
+	err := h.db.WithContext(c.Request.Context()).Where("user_id = ?", userID).
+		Order("id DESC").Find(&keys).Error
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	resp := make([]apiKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		resp = append(resp, newAPIKeyResponse(k))
+	}
+	h.shape.JSON(c, http.StatusOK, resp)
+}
+
+// Revoke deletes one of the caller's keys; admins may revoke anyone's.
+// Requests using the key fail from then on.
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	userID, ok := UserID(c)
+	if !ok {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, strconv.IntSize)
+	if err != nil || id == 0 {
+		apperr.Write(c, apperr.ErrBadRequest.With("invalid id"))
+		return
+	}
+
+	q := h.db.WithContext(c.Request.Context())
+	if !HasRole(c, RoleAdmin) {
+		q = q.Where("user_id = ?", userID)
+	}
+	var apiKey APIKey
+	err = q.First(&apiKey, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apperr.Write(c, apperr.ErrNotFound.With("api key not found"))
+		return
+	}
+	if err == nil {
+		err = h.db.WithContext(c.Request.Context()).Delete(&apiKey).Error
+	}
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
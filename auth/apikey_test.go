@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/response"
+	"gorm.io/gorm"
+)
+
+// setupAPIKeyRouter serves the key endpoints and /me behind AcceptAPIKeys.
+func setupAPIKeyRouter(t *testing.T) (*gorm.DB, *gin.Engine) {
+	t.Helper()
+	return setupShapedAPIKeyRouter(t, response.Options{})
+}
+
+// setupShapedAPIKeyRouter is setupAPIKeyRouter with responses shaped by
+// shape.
+func setupShapedAPIKeyRouter(t *testing.T, shape response.Options) (*gorm.DB, *gin.Engine) {
+	t.Helper()
+	db := setupAuthTestDB(t)
+	if err := db.AutoMigrate(&APIKey{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+	h := NewAPIKeyHandler(db, shape)
+	r := gin.New()
+	protected := r.Group("", AcceptAPIKeys(db, Protect(testSecret, TokenScope{})))
+	protected.GET("/me", Me(shape))
+	protected.POST("/apikeys", h.Create)
+	protected.GET("/apikeys", h.List)
+	protected.DELETE("/apikeys/:id", h.Revoke)
+	return db, r
+}
+
+// bearer returns an Authorization header value for a token of user.
+func bearer(t *testing.T, user User) string {
+	t.Helper()
+	token, err := createToken(user, string(testSecret), 5*time.Minute, TokenScope{}, defaultSignFn)
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	return "Bearer " + token
+}
+
+func doAPIKeyRequest(r *gin.Engine, method, path, header, value, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// createKey issues a key for user through POST /apikeys.
+func createKey(t *testing.T, r *gin.Engine, user User) apiKeyResponse {
+	t.Helper()
+	w := doAPIKeyRequest(r, http.MethodPost, "/apikeys", "Authorization", bearer(t, user), `{"name": "ci"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp apiKeyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	return resp
+}
+
+func seedRole(t *testing.T, db *gorm.DB, username, role string) User {
+	t.Helper()
+	user := User{Username: username, Password: "x", Role: role}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to seed user: %v", err)
+	}
+	return user
+}
+
+// TestAPIKey_Authenticates: a valid key acts as its owner, with their role
+func TestAPIKey_Authenticates(t *testing.T) {
+	db, r := setupAPIKeyRouter(t)
+	admin := seedRole(t, db, "root", RoleAdmin)
+	key := createKey(t, r, admin)
+
+	if !strings.HasPrefix(key.Key, apiKeyPrefix) || !strings.HasPrefix(key.Key, key.Prefix) {
+		t.Fatalf("expected a %s key starting with its prefix, got %+v", apiKeyPrefix, key)
+	}
+	var stored APIKey
+	db.First(&stored, key.ID)
+	if stored.Hash == key.Key || stored.Hash != hashAPIKey(key.Key) {
+		t.Error("expected only the hash of the key to be stored")
+	}
+
+	w := doAPIKeyRequest(r, http.MethodGet, "/me", APIKeyHeader, key.Key, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var me struct {
+		Subject string   `json:"subject"`
+		Roles   []string `json:"roles"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &me)
+	if me.Subject != fmt.Sprint(admin.ID) || len(me.Roles) != 1 || me.Roles[0] != RoleAdmin {
+		t.Errorf("expected the admin's identity, got %+v", me)
+	}
+}
+
+// TestAPIKey_Rejected: unknown and revoked keys are 401, without falling back to the token
+func TestAPIKey_Rejected(t *testing.T) {
+	db, r := setupAPIKeyRouter(t)
+	user := seedRole(t, db, "alice", RoleUser)
+	key := createKey(t, r, user)
+
+	if w := doAPIKeyRequest(r, http.MethodDelete, fmt.Sprintf("/apikeys/%d", key.ID), APIKeyHeader, key.Key, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 on revoke, got %d", w.Code)
+	}
+
+	for name, value := range map[string]string{"revoked": key.Key, "unknown": apiKeyPrefix + "nope"} {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set(APIKeyHeader, value)
+		req.Header.Set("Authorization", bearer(t, user))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s key: expected 401, got %d", name, w.Code)
+		}
+	}
+}
+
+// TestAPIKey_DeletedUser: a key stops working when its owner is removed
+func TestAPIKey_DeletedUser(t *testing.T) {
+	db, r := setupAPIKeyRouter(t)
+	user := seedRole(t, db, "alice", RoleUser)
+	key := createKey(t, r, user)
+	db.Delete(&user)
+
+	if w := doAPIKeyRequest(r, http.MethodGet, "/me", APIKeyHeader, key.Key, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+// TestAPIKey_ListOwnKeys: listings show only the caller's active keys, never the key itself
+func TestAPIKey_ListOwnKeys(t *testing.T) {
+	db, r := setupAPIKeyRouter(t)
+	alice := seedRole(t, db, "alice", RoleUser)
+	bob := seedRole(t, db, "bob", RoleUser)
+	createKey(t, r, alice)
+	revoked := createKey(t, r, alice)
+	createKey(t, r, bob)
+	doAPIKeyRequest(r, http.MethodDelete, fmt.Sprintf("/apikeys/%d", revoked.ID), "Authorization", bearer(t, alice), "")
+
+	w := doAPIKeyRequest(r, http.MethodGet, "/apikeys", "Authorization", bearer(t, alice), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), `"key"`) {
+		t.Errorf("expected keys to be hidden, got %s", w.Body.String())
+	}
+	var keys []apiKeyResponse
+	json.Unmarshal(w.Body.Bytes(), &keys)
+	if len(keys) != 1 || keys[0].ID != 1 {
+		t.Errorf("expected only alice's active key, got %+v", keys)
+	}
+}
+
+// TestAPIKey_Revoke: users revoke only their own keys; admins revoke any
+func TestAPIKey_Revoke(t *testing.T) {
+	db, r := setupAPIKeyRouter(t)
+	alice := seedRole(t, db, "alice", RoleUser)
+	bob := seedRole(t, db, "bob", RoleUser)
+	admin := seedRole(t, db, "root", RoleAdmin)
+	key := createKey(t, r, alice)
+	path := fmt.Sprintf("/apikeys/%d", key.ID)
+
+	tests := []struct {
+		name string
+		user User
+		path string
+		want int
+	}{
+		{"another user", bob, path, http.StatusNotFound},
+		{"invalid id", alice, "/apikeys/abc", http.StatusBadRequest},
+		{"admin", admin, path, http.StatusNoContent},
+		{"already revoked", alice, path, http.StatusNotFound},
+	}
+	for _, tc := range tests {
+		if w := doAPIKeyRequest(r, http.MethodDelete, tc.path, "Authorization", bearer(t, tc.user), ""); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, w.Code)
+		}
+	}
+}
+
+func TestAPIKey_CreateRequiresName(t *testing.T) {
+	db, r := setupAPIKeyRouter(t)
+	user := seedRole(t, db, "alice", RoleUser)
+
+	for _, body := range []string{`{}`, `{"name": "` + strings.Repeat("x", 101) + `"}`} {
+		if w := doAPIKeyRequest(r, http.MethodPost, "/apikeys", "Authorization", bearer(t, user), body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
+
+// TestAPIKey_Shaped: with the envelope and string ids, keys and /me come in data and key ids are strings
+func TestAPIKey_Shaped(t *testing.T) {
+	db, r := setupShapedAPIKeyRouter(t, response.Options{Envelope: true, IDsAsStrings: true})
+	auth := bearer(t, seedRole(t, db, "alice", RoleUser))
+	doAPIKeyRequest(r, http.MethodPost, "/apikeys", "Authorization", auth, `{"name": "ci"}`)
+
+	var keys struct {
+		Data []map[string]any `json:"data"`
+	}
+	w := doAPIKeyRequest(r, http.MethodGet, "/apikeys", "Authorization", auth, "")
+	if err := json.Unmarshal(w.Body.Bytes(), &keys); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if len(keys.Data) != 1 || keys.Data[0]["id"] != "1" {
+		t.Errorf("expected one key with id \"1\" in data, got %s", w.Body.String())
+	}
+
+	var me struct {
+		Data map[string]any `json:"data"`
+	}
+	w = doAPIKeyRequest(r, http.MethodGet, "/me", "Authorization", auth, "")
+	if err := json.Unmarshal(w.Body.Bytes(), &me); err != nil || me.Data["subject"] == nil {
+		t.Errorf("expected the identity in data, got %s", w.Body.String())
+	}
+}
@@ -1,12 +1,14 @@
 package auth
 
 import (
+	"cmp"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
+	"github.com/pradist/todoapi/apperr"
 	"gorm.io/gorm"
 )
 
@@ -15,39 +17,67 @@ type loginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-func createToken(userID uint, signature string, signFn func(*jwt.Token, any) (string, error)) (string, error) {
-	claims := &jwt.StandardClaims{
-		ExpiresAt: time.Now().Add(5 * time.Minute).Unix(),
-		IssuedAt:  time.Now().Unix(),
-		Issuer:    "todoapi",
-		Subject:   strconv.FormatUint(uint64(userID), 10),
+// Claims are the JWT claims issued by AccessToken and read back by Protect.
+type Claims struct {
+	Roles []string `json:"roles,omitempty"`
+	jwt.StandardClaims
+}
+
+// DefaultIssuer is the iss claim of tokens minted without a configured
+// issuer. DefaultAudience is the audience the server uses when none is
+// configured.
+const (
+	DefaultIssuer   = "todoapi"
+	DefaultAudience = "todoapi"
+)
+
+// TokenScope ties tokens to this API. AccessToken writes Issuer and
+// Audience into the iss and aud claims, and Protect rejects tokens whose
+// claims don't match. An empty field is not checked.
+type TokenScope struct {
+	Issuer   string
+	Audience string
+}
+
+func createToken(user User, signature string, ttl time.Duration, scope TokenScope, signFn func(*jwt.Token, any) (string, error)) (string, error) {
+	claims := &Claims{
+		Roles: []string{user.Role},
+		StandardClaims: jwt.StandardClaims{
+			Audience:  scope.Audience,
+			ExpiresAt: time.Now().Add(ttl).Unix(),
+			IssuedAt:  time.Now().Unix(),
+			Issuer:    cmp.Or(scope.Issuer, DefaultIssuer),
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return signFn(token, []byte(signature))
 }
 
-func AccessToken(db *gorm.DB, signature string, signFn func(*jwt.Token, any) (string, error)) gin.HandlerFunc {
+// AccessToken exchanges valid credentials for a JWT that expires after ttl
+// and is scoped to scope.
+func AccessToken(db *gorm.DB, signature string, ttl time.Duration, scope TokenScope, signFn func(*jwt.Token, any) (string, error)) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req loginRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "username and password are required"})
+			apperr.Write(c, apperr.ErrBadRequest.With("username and password are required"))
 			return
 		}
 
 		var user User
-		if err := db.Where("username = ?", req.Username).First(&user).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		if err := db.WithContext(c.Request.Context()).Where("username = ?", req.Username).First(&user).Error; err != nil {
+			apperr.Write(c, apperr.ErrUnauthorized.With("invalid credentials"))
 			return
 		}
 
 		if !CheckPassword(req.Password, user.Password) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+			apperr.Write(c, apperr.ErrUnauthorized.With("invalid credentials"))
 			return
 		}
 
-		token, err := createToken(user.ID, signature, signFn)
+		token, err := createToken(user, signature, ttl, scope, signFn)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			apperr.Write(c, err)
 			return
 		}
 
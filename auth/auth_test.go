@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
@@ -44,7 +45,7 @@ func defaultSignFn(token *jwt.Token, key any) (string, error) {
 func setupAuthRouter(db *gorm.DB) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
-	r.POST("/tokenz", AccessToken(db, "test_secret", defaultSignFn))
+	r.POST("/tokenz", AccessToken(db, "test_secret", 5*time.Minute, TokenScope{}, defaultSignFn))
 	return r
 }
 
@@ -77,6 +78,14 @@ func TestAccessToken_Success(t *testing.T) {
 	if resp["token"] == "" {
 		t.Error("expected non-empty token in response")
 	}
+
+	claims := &Claims{}
+	if _, err := jwt.ParseWithClaims(resp["token"], claims, hmacKeyFunc([]byte("test_secret"))); err != nil {
+		t.Fatalf("issued token does not parse: %v", err)
+	}
+	if len(claims.Roles) != 1 || claims.Roles[0] != RoleUser {
+		t.Errorf("expected roles [user], got %v", claims.Roles)
+	}
 }
 
 // TestAccessToken_WrongPassword: wrong password returns 401
@@ -133,7 +142,7 @@ func TestAccessToken_InvalidJSON(t *testing.T) {
 	db := setupAuthTestDB(t)
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
-	r.POST("/tokenz", AccessToken(db, "test_secret", defaultSignFn))
+	r.POST("/tokenz", AccessToken(db, "test_secret", 5*time.Minute, TokenScope{}, defaultSignFn))
 
 	req := httptest.NewRequest(http.MethodPost, "/tokenz", bytes.NewBufferString(`{invalid}`))
 	req.Header.Set("Content-Type", "application/json")
@@ -174,10 +183,52 @@ func TestAccessToken_SigningError(t *testing.T) {
 	}
 	r := gin.New()
 	gin.SetMode(gin.TestMode)
-	r.POST("/tokenz", AccessToken(db, "test_secret", failingSignFn))
+	r.POST("/tokenz", AccessToken(db, "test_secret", 5*time.Minute, TokenScope{}, failingSignFn))
 	w := doTokenRequest(t, r, map[string]string{"username": "alice", "password": "secret123"})
 
 	if w.Code != http.StatusInternalServerError {
 		t.Fatalf("expected 500, got %d", w.Code)
 	}
 }
+
+// TestAccessToken_UsesTTL: the token expiry follows the configured TTL
+func TestAccessToken_UsesTTL(t *testing.T) {
+	db := setupAuthTestDB(t)
+	seedUser(t, db, "alice", "secret123")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/tokenz", AccessToken(db, "test_secret", time.Hour, TokenScope{}, defaultSignFn))
+
+	w := doTokenRequest(t, r, map[string]string{"username": "alice", "password": "secret123"})
+
+	var resp map[string]string
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	claims := &Claims{}
+	if _, err := jwt.ParseWithClaims(resp["token"], claims, hmacKeyFunc([]byte("test_secret"))); err != nil {
+		t.Fatalf("issued token does not parse: %v", err)
+	}
+	if ttl := claims.ExpiresAt - claims.IssuedAt; ttl != int64(time.Hour.Seconds()) {
+		t.Errorf("expected a 1h lifetime, got %ds", ttl)
+	}
+}
+
+// TestAccessToken_SetsScope: issued tokens carry the configured iss and aud claims
+func TestAccessToken_SetsScope(t *testing.T) {
+	db := setupAuthTestDB(t)
+	seedUser(t, db, "alice", "secret123")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/tokenz", AccessToken(db, "test_secret", time.Minute, TokenScope{Issuer: "todo-auth", Audience: "todo-clients"}, defaultSignFn))
+
+	w := doTokenRequest(t, r, map[string]string{"username": "alice", "password": "secret123"})
+
+	var resp map[string]string
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	claims := &Claims{}
+	if _, err := jwt.ParseWithClaims(resp["token"], claims, hmacKeyFunc([]byte("test_secret"))); err != nil {
+		t.Fatalf("issued token does not parse: %v", err)
+	}
+	if claims.Issuer != "todo-auth" || claims.Audience != "todo-clients" {
+		t.Errorf("expected iss todo-auth and aud todo-clients, got %q and %q", claims.Issuer, claims.Audience)
+	}
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/response"
+)
+
+// Me returns the handler that answers with the identity carried by the
+// caller's validated token, shaped as shape says. It must run behind
+// Protect.
+func Me(shape response.Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := ClaimsFromContext(c)
+		if !ok {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		roles := claims.Roles
+		if roles == nil {
+			roles = []string{}
+		}
+		// Requests authenticated by an API key carry no expiry.
+		var expiresAt *time.Time
+		if claims.ExpiresAt != 0 {
+			t := time.Unix(claims.ExpiresAt, 0).UTC()
+			expiresAt = &t
+		}
+		shape.JSON(c, http.StatusOK, gin.H{
+			"subject":    claims.Subject,
+			"roles":      roles,
+			"issuer":     claims.Issuer,
+			"expires_at": expiresAt,
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/response"
+)
+
+func setupMeRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/me", Protect(testSecret, TokenScope{}), Me(response.Options{}))
+	return r
+}
+
+// TestMe_ReturnsTokenIdentity: /me echoes the subject, roles and expiry of the token
+func TestMe_ReturnsTokenIdentity(t *testing.T) {
+	r := setupMeRouter()
+	expiresAt := time.Now().Add(5 * time.Minute).Truncate(time.Second)
+	user := User{Role: RoleAdmin}
+	user.ID = 42
+	token, err := createToken(user, string(testSecret), 5*time.Minute, TokenScope{}, defaultSignFn)
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	var resp struct {
+		Subject   string    `json:"subject"`
+		Roles     []string  `json:"roles"`
+		Issuer    string    `json:"issuer"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if resp.Subject != "42" {
+		t.Errorf("expected subject 42, got %q", resp.Subject)
+	}
+	if len(resp.Roles) != 1 || resp.Roles[0] != RoleAdmin {
+		t.Errorf("expected roles [admin], got %v", resp.Roles)
+	}
+	if resp.Issuer != "todoapi" {
+		t.Errorf("expected issuer todoapi, got %q", resp.Issuer)
+	}
+	if resp.ExpiresAt.Sub(expiresAt).Abs() > 2*time.Second {
+		t.Errorf("expected expiry near %v, got %v", expiresAt, resp.ExpiresAt)
+	}
+}
+
+// TestMe_TokenWithoutRoles: tokens without a roles claim report an empty list
+func TestMe_TokenWithoutRoles(t *testing.T) {
+	r := setupMeRouter()
+
+	w := doMeRequest(r, "Bearer "+makeValidToken(t))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp map[string]any
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if roles, ok := resp["roles"].([]any); !ok || len(roles) != 0 {
+		t.Errorf("expected empty roles list, got %v", resp["roles"])
+	}
+}
+
+// TestMe_Unauthenticated: /me without a token returns 401
+func TestMe_Unauthenticated(t *testing.T) {
+	r := setupMeRouter()
+
+	w := doMeRequest(r, "")
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+}
+
+// TestMe_WithoutProtect: /me mounted without Protect has no claims and returns 401
+func TestMe_WithoutProtect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/me", Me(response.Options{}))
+
+	w := doMeRequest(r, "")
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+}
+
+func doMeRequest(r *gin.Engine, authHeader string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -26,7 +27,55 @@ func extractBearerToken(c *gin.Context) (string, bool) {
 	return strings.TrimPrefix(header, "Bearer "), true
 }
 
-func Protect(signature []byte) gin.HandlerFunc {
+// inScope reports whether claims were issued for scope. Configured values
+// must be present in the token and match exactly.
+func inScope(claims *Claims, scope TokenScope) bool {
+	if scope.Issuer != "" && !claims.VerifyIssuer(scope.Issuer, true) {
+		return false
+	}
+	if scope.Audience != "" && !claims.VerifyAudience(scope.Audience, true) {
+		return false
+	}
+	return true
+}
+
+// claimsKey is the gin context key under which Protect stores the *Claims
+// of the validated token.
+const claimsKey = "claims"
+
+// ClaimsFromContext returns the claims Protect stored for the current
+// request. ok is false when the request did not pass through Protect.
+func ClaimsFromContext(c *gin.Context) (claims *Claims, ok bool) {
+	v, exists := c.Get(claimsKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok = v.(*Claims)
+	return claims, ok
+}
+
+// UserID returns the authenticated user's id from the token subject.
+func UserID(c *gin.Context) (uint, bool) {
+	claims, ok := ClaimsFromContext(c)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(claims.Subject, 10, strconv.IntSize)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// SetClaims stores claims on the context the same way Protect does. It lets
+// other authentication paths and tests hand identity to downstream handlers.
+func SetClaims(c *gin.Context, claims *Claims) {
+	c.Set(claimsKey, claims)
+}
+
+// Protect rejects requests without a valid bearer token with 401. Tokens
+// must be signed with signature and match scope's issuer and audience.
+func Protect(signature []byte, scope TokenScope) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString, ok := extractBearerToken(c)
 		if !ok {
@@ -34,11 +83,17 @@ func Protect(signature []byte) gin.HandlerFunc {
 			return
 		}
 
-		if _, err := jwt.Parse(tokenString, hmacKeyFunc(signature)); err != nil {
+		claims := &Claims{}
+		if _, err := jwt.ParseWithClaims(tokenString, claims, hmacKeyFunc(signature)); err != nil {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		if !inScope(claims, scope) {
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
 
+		SetClaims(c, claims)
 		c.Next()
 	}
 }
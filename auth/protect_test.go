@@ -35,7 +35,7 @@ func TestMain(m *testing.M) {
 func setupProtectRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
-	r.GET("/protected", Protect(testSecret), func(c *gin.Context) {
+	r.GET("/protected", Protect(testSecret, TokenScope{}), func(c *gin.Context) {
 		c.Status(http.StatusOK)
 	})
 	return r
@@ -142,3 +142,99 @@ func TestProtect_WrongSigningMethod(t *testing.T) {
 		t.Fatalf("expected 401, got %d", w.Code)
 	}
 }
+
+// TestClaimsFromContext_DownstreamHandler: a handler behind Protect can read the token subject
+func TestClaimsFromContext_DownstreamHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	var subject string
+	var userID uint
+	r.GET("/protected", Protect(testSecret, TokenScope{}), func(c *gin.Context) {
+		claims, ok := ClaimsFromContext(c)
+		if !ok {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		subject = claims.Subject
+		userID, _ = UserID(c)
+		c.Status(http.StatusOK)
+	})
+
+	w := doProtectRequest(r, "Bearer "+makeValidToken(t))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if subject != "1" {
+		t.Errorf("expected subject 1, got %q", subject)
+	}
+	if userID != 1 {
+		t.Errorf("expected user id 1, got %d", userID)
+	}
+}
+
+// TestClaimsFromContext_NoClaims: without Protect there are no claims
+func TestClaimsFromContext_NoClaims(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	if _, ok := ClaimsFromContext(c); ok {
+		t.Error("expected no claims on a fresh context")
+	}
+	if _, ok := UserID(c); ok {
+		t.Error("expected no user id on a fresh context")
+	}
+}
+
+// TestUserID_NonNumericSubject: a subject that is not a user id is rejected
+func TestUserID_NonNumericSubject(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	SetClaims(c, &Claims{StandardClaims: jwt.StandardClaims{Subject: "service-account"}})
+
+	if _, ok := UserID(c); ok {
+		t.Error("expected non-numeric subject to yield no user id")
+	}
+}
+
+func makeScopedToken(t *testing.T, issuer, audience string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.StandardClaims{
+		Audience:  audience,
+		ExpiresAt: time.Now().Add(5 * time.Minute).Unix(),
+		Issuer:    issuer,
+		Subject:   "1",
+	})
+	ss, err := token.SignedString(testSecret)
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	return ss
+}
+
+// TestProtect_Scope: tokens for another issuer or audience are rejected with 401
+func TestProtect_Scope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/protected", Protect(testSecret, TokenScope{Issuer: "todoapi", Audience: "todo-clients"}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name, issuer, audience string
+		want                   int
+	}{
+		{"matching", "todoapi", "todo-clients", http.StatusOK},
+		{"wrong issuer", "billing", "todo-clients", http.StatusUnauthorized},
+		{"wrong audience", "todoapi", "billing-clients", http.StatusUnauthorized},
+		{"missing audience", "todoapi", "", http.StatusUnauthorized},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doProtectRequest(r, "Bearer "+makeScopedToken(t, tc.issuer, tc.audience))
+			if w.Code != tc.want {
+				t.Errorf("expected %d, got %d", tc.want, w.Code)
+			}
+		})
+	}
+}
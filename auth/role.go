@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+)
+
+// HasRole reports whether the validated token grants role.
+func HasRole(c *gin.Context, role string) bool {
+	claims, ok := ClaimsFromContext(c)
+	return ok && slices.Contains(claims.Roles, role)
+}
+
+// RequireRole rejects requests whose token does not grant role with 403. It
+// must run after Protect.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasRole(c, role) {
+			apperr.Abort(c, apperr.ErrForbidden)
+			return
+		}
+		c.Next()
+	}
+}
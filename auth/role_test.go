@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupRoleRouter(claims *Claims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin", func(c *gin.Context) {
+		if claims != nil {
+			SetClaims(c, claims)
+		}
+	}, RequireRole(RoleAdmin), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func doRoleRequest(r *gin.Engine) int {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	return w.Code
+}
+
+// TestRequireRole_Granted: a token with the role passes through
+func TestRequireRole_Granted(t *testing.T) {
+	r := setupRoleRouter(&Claims{Roles: []string{RoleUser, RoleAdmin}})
+
+	if code := doRoleRequest(r); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+}
+
+// TestRequireRole_Missing: a token without the role gets 403
+func TestRequireRole_Missing(t *testing.T) {
+	r := setupRoleRouter(&Claims{Roles: []string{RoleUser}})
+
+	if code := doRoleRequest(r); code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", code)
+	}
+}
+
+// TestRequireRole_NoClaims: a request that skipped Protect gets 403
+func TestRequireRole_NoClaims(t *testing.T) {
+	r := setupRoleRouter(nil)
+
+	if code := doRoleRequest(r); code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", code)
+	}
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+// SelfCheck mints a token the same way AccessToken does and passes it
+// through protect. It fails if protect rejects the token, which means
+// tokens issued by /tokenz would be refused by every protected route.
+// Run it at startup so a key mismatch stops the server before traffic
+// arrives.
+func SelfCheck(signature string, scope TokenScope, signFn func(*jwt.Token, any) (string, error), protect gin.HandlerFunc) error {
+	if signature == "" {
+		return errors.New("signing key is empty")
+	}
+
+	probe := User{Role: RoleUser}
+	probe.ID = 1
+	token, err := createToken(probe, signature, time.Minute, scope, signFn)
+	if err != nil {
+		return fmt.Errorf("minting test token: %w", err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("Authorization", "Bearer "+token)
+	protect(c)
+
+	if c.IsAborted() {
+		return fmt.Errorf("a freshly minted token was rejected with status %d; the signing and verification keys, issuer or audience do not match", w.Code)
+	}
+	if id, ok := UserID(c); !ok || id != probe.ID {
+		return errors.New("a freshly minted token was accepted but its subject was not available to handlers")
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func signWithKey(token *jwt.Token, key any) (string, error) {
+	return token.SignedString(key)
+}
+
+func TestSelfCheck_MatchingKeys(t *testing.T) {
+	if err := SelfCheck("secret", TokenScope{}, signWithKey, Protect([]byte("secret"), TokenScope{})); err != nil {
+		t.Fatalf("expected self-check to pass, got %v", err)
+	}
+}
+
+// TestSelfCheck_MismatchedKeys: verifying with a different key than signing fails
+func TestSelfCheck_MismatchedKeys(t *testing.T) {
+	if err := SelfCheck("secret", TokenScope{}, signWithKey, Protect([]byte("==signature=="), TokenScope{})); err == nil {
+		t.Fatal("expected self-check to fail")
+	}
+}
+
+func TestSelfCheck_EmptyKey(t *testing.T) {
+	if err := SelfCheck("", TokenScope{}, signWithKey, Protect([]byte(""), TokenScope{})); err == nil {
+		t.Fatal("expected self-check to fail for an empty key")
+	}
+}
+
+func TestSelfCheck_SigningError(t *testing.T) {
+	failing := func(*jwt.Token, any) (string, error) { return "", errors.New("boom") }
+
+	if err := SelfCheck("secret", TokenScope{}, failing, Protect([]byte("secret"), TokenScope{})); err == nil {
+		t.Fatal("expected self-check to fail when signing fails")
+	}
+}
@@ -5,10 +5,16 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	gorm.Model
 	Username string `gorm:"uniqueIndex;not null"`
 	Password string `gorm:"not null"`
+	Role     string `gorm:"not null;default:user"`
 }
 
 func HashPassword(plain string) (string, error) {
@@ -0,0 +1,28 @@
+// Package buildinfo holds version details injected at build time:
+//
+//	go build -ldflags "-X github.com/pradist/todoapi/buildinfo.Version=v1.2.0 \
+//	  -X github.com/pradist/todoapi/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/pradist/todoapi/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package buildinfo
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Set with -ldflags -X. Builds without them report "dev".
+var (
+	Version   = "dev"
+	Commit    = "dev"
+	BuildTime = "dev"
+)
+
+// Handler serves GET /version.
+func Handler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"version":    Version,
+		"commit":     Commit,
+		"build_time": BuildTime,
+	})
+}
@@ -0,0 +1,518 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/app"
+	"github.com/pradist/todoapi/auth"
+	"github.com/pradist/todoapi/middleware"
+	"github.com/pradist/todoapi/todo"
+	"gorm.io/gorm/schema"
+)
+
+// config is the server configuration, loaded once at startup: the router
+// settings plus what main itself needs.
+type config struct {
+	app.Config
+	shutdownTimeout time.Duration
+	shutdownSignals []os.Signal
+	// trashRetention is how long deleted todos are kept before the trash
+	// purger removes them. Zero keeps them until purged by hand.
+	trashRetention time.Duration
+	// sqliteVacuumInterval is how often the SQLite database is vacuumed.
+	// Zero never vacuums.
+	sqliteVacuumInterval time.Duration
+	// auditBatchSize is how many audit entries are written together. Zero
+	// writes each one in the transaction of its change.
+	auditBatchSize int
+	// auditFlushInterval is the longest an audit entry waits for a batch
+	// to fill.
+	auditFlushInterval time.Duration
+	// dbNaming names the database tables.
+	dbNaming schema.NamingStrategy
+	// dbConnectRetries is how many more times opening the database is
+	// tried after the first failure, dbConnectBackoff apart at first.
+	dbConnectRetries int
+	dbConnectBackoff time.Duration
+	// dbStatementTimeout bounds how long a statement waits on a locked
+	// database. Zero keeps the driver's default.
+	dbStatementTimeout time.Duration
+	// dbFallbackMemory serves from an empty in-memory database when the
+	// configured one can't be opened. It is for local development only.
+	dbFallbackMemory bool
+}
+
+// defaultShutdownSignals start a graceful shutdown unless SHUTDOWN_SIGNALS
+// says otherwise.
+var defaultShutdownSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+
+// signalsByName are the signals SHUTDOWN_SIGNALS may list.
+var signalsByName = map[string]os.Signal{
+	"SIGINT":  syscall.SIGINT,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGQUIT": syscall.SIGQUIT,
+}
+
+// configFromEnv reads the server configuration from environment variables
+// and rejects invalid values so misconfiguration fails at boot.
+//
+//	TOKEN_TTL               - lifetime of issued JWTs (default: 5m)
+//	SHUTDOWN_TIMEOUT        - how long shutdown waits for in-flight requests (default: 5s)
+//	SHUTDOWN_SIGNALS        - signals that start a graceful shutdown (default: SIGINT,SIGTERM)
+//	DEBUG_SQL               - report per-request query counts in X-DB-Queries (default: false)
+//	DEBUG_BODIES            - log request and response bodies, credentials redacted (default: false)
+//	SECURE_HEADERS          - add nosniff, frame and HSTS security headers (default: false)
+//	JWT_ISSUER              - iss claim issued and required on tokens (default: todoapi)
+//	JWT_AUDIENCE            - aud claim issued and required on tokens (default: todoapi)
+//	TRASH_RETENTION         - purge todos deleted longer ago than this (default: never)
+//	SQLITE_VACUUM_INTERVAL  - how often to VACUUM the SQLite database when idle (default: never)
+//	AUDIT_BATCH_SIZE        - write audit entries in batches of this size after commit; 0 writes them in the transaction (default: 0)
+//	AUDIT_FLUSH_INTERVAL    - longest an audit entry waits for its batch to fill (default: 1s)
+//	DB_TABLE_PREFIX         - prefix for every table name, e.g. "todoapi_" (default: none)
+//	DB_SINGULAR_TABLES      - name tables in the singular, e.g. "todo" (default: false)
+//	DB_CONNECT_RETRIES      - retries when the database can't be opened at startup (default: 0)
+//	DB_CONNECT_BACKOFF      - wait before the first retry, doubling after each (default: 1s)
+//	DB_STATEMENT_TIMEOUT    - how long a statement waits on a locked database (default: 5s, the driver's)
+//	DB_FALLBACK_MEMORY      - use an in-memory database if it can't be opened; refused with GIN_MODE=release (default: false)
+//	MAX_CONCURRENT_REQUESTS - requests handled at once; 0 means unlimited (default: 0)
+//	CONCURRENCY_WAIT        - how long excess requests queue before a 503 (default: 0)
+//	DISABLED_ENDPOINTS      - comma-separated app.Endpoints that answer 404 (default: none)
+//
+// Durations accept Go syntax ("90s", "1h30m") or ISO 8601 ("PT90S", "PT1H30M").
+func configFromEnv() (config, error) {
+	tokenTTL, err := durationFromEnv("TOKEN_TTL", 5*time.Minute)
+	if err != nil {
+		return config{}, err
+	}
+	shutdownTimeout, err := durationFromEnv("SHUTDOWN_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return config{}, err
+	}
+	shutdownSignals, err := signalsFromEnv("SHUTDOWN_SIGNALS")
+	if err != nil {
+		return config{}, err
+	}
+	trashRetention, err := durationFromEnv("TRASH_RETENTION", 0)
+	if err != nil {
+		return config{}, err
+	}
+	sqliteVacuumInterval, err := durationFromEnv("SQLITE_VACUUM_INTERVAL", 0)
+	if err != nil {
+		return config{}, err
+	}
+	auditBatchSize := 0
+	if v := os.Getenv("AUDIT_BATCH_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return config{}, fmt.Errorf("AUDIT_BATCH_SIZE must be a non-negative integer, got %q", v)
+		}
+		auditBatchSize = n
+	}
+	auditFlushInterval, err := durationFromEnv("AUDIT_FLUSH_INTERVAL", time.Second)
+	if err != nil {
+		return config{}, err
+	}
+	if auditFlushInterval <= 0 {
+		return config{}, fmt.Errorf("AUDIT_FLUSH_INTERVAL must be positive, got %s", auditFlushInterval)
+	}
+	singularTables, err := boolFromEnv("DB_SINGULAR_TABLES")
+	if err != nil {
+		return config{}, err
+	}
+	dbConnectRetries := 0
+	if v := os.Getenv("DB_CONNECT_RETRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return config{}, fmt.Errorf("DB_CONNECT_RETRIES must be a non-negative integer, got %q", v)
+		}
+		dbConnectRetries = n
+	}
+	dbConnectBackoff, err := durationFromEnv("DB_CONNECT_BACKOFF", time.Second)
+	if err != nil {
+		return config{}, err
+	}
+	dbStatementTimeout, err := durationFromEnv("DB_STATEMENT_TIMEOUT", 0)
+	if err != nil {
+		return config{}, err
+	}
+	if dbStatementTimeout > 0 && dbStatementTimeout < time.Millisecond {
+		return config{}, fmt.Errorf("DB_STATEMENT_TIMEOUT must be at least 1ms, got %s", dbStatementTimeout)
+	}
+	dbFallbackMemory, err := boolFromEnv("DB_FALLBACK_MEMORY")
+	if err != nil {
+		return config{}, err
+	}
+	if dbFallbackMemory && os.Getenv(gin.EnvGinMode) == gin.ReleaseMode {
+		return config{}, fmt.Errorf("DB_FALLBACK_MEMORY is for development and can't be used with %s=%s", gin.EnvGinMode, gin.ReleaseMode)
+	}
+	concurrency, err := concurrencyConfigFromEnv()
+	if err != nil {
+		return config{}, err
+	}
+	todoCfg, err := todoConfigFromEnv()
+	if err != nil {
+		return config{}, err
+	}
+	corsCfg, err := corsConfigFromEnv()
+	if err != nil {
+		return config{}, err
+	}
+	debugSQL, err := boolFromEnv("DEBUG_SQL")
+	if err != nil {
+		return config{}, err
+	}
+	debugBodies, err := boolFromEnv("DEBUG_BODIES")
+	if err != nil {
+		return config{}, err
+	}
+	secureHeaders, err := boolFromEnv("SECURE_HEADERS")
+	if err != nil {
+		return config{}, err
+	}
+	prettyJSON, err := boolFromEnv("PRETTY_JSON")
+	if err != nil {
+		return config{}, err
+	}
+	strictParams, err := boolFromEnv("STRICT_PARAMS")
+	if err != nil {
+		return config{}, err
+	}
+	disabled, err := disabledEndpointsFromEnv()
+	if err != nil {
+		return config{}, err
+	}
+	return config{
+		Config: app.Config{
+			Sign:     os.Getenv("SIGN"),
+			TokenTTL: tokenTTL,
+			TokenScope: auth.TokenScope{
+				Issuer:   cmp.Or(os.Getenv("JWT_ISSUER"), auth.DefaultIssuer),
+				Audience: cmp.Or(os.Getenv("JWT_AUDIENCE"), auth.DefaultAudience),
+			},
+			Limiter:           ipLimiterFromEnv(),
+			CORS:              corsCfg,
+			DebugSQL:          debugSQL,
+			DebugBodies:       debugBodies,
+			SecureHeaders:     secureHeaders,
+			PrettyJSON:        prettyJSON,
+			StrictParams:      strictParams,
+			Concurrency:       concurrency,
+			LogSkipPaths:      listFromEnv("LOG_SKIP_PATHS"),
+			DisabledEndpoints: disabled,
+			Todo:              todoCfg,
+		},
+		shutdownTimeout:      shutdownTimeout,
+		shutdownSignals:      shutdownSignals,
+		trashRetention:       trashRetention,
+		sqliteVacuumInterval: sqliteVacuumInterval,
+		auditBatchSize:       auditBatchSize,
+		auditFlushInterval:   auditFlushInterval,
+		dbNaming: schema.NamingStrategy{
+			TablePrefix:   os.Getenv("DB_TABLE_PREFIX"),
+			SingularTable: singularTables,
+		},
+		dbConnectRetries:   dbConnectRetries,
+		dbConnectBackoff:   dbConnectBackoff,
+		dbStatementTimeout: dbStatementTimeout,
+		dbFallbackMemory:   dbFallbackMemory,
+	}, nil
+}
+
+// concurrencyConfigFromEnv reads MAX_CONCURRENT_REQUESTS and
+// CONCURRENCY_WAIT.
+func concurrencyConfigFromEnv() (middleware.ConcurrencyConfig, error) {
+	var cfg middleware.ConcurrencyConfig
+	if v := os.Getenv("MAX_CONCURRENT_REQUESTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return middleware.ConcurrencyConfig{}, fmt.Errorf("MAX_CONCURRENT_REQUESTS must be a non-negative integer, got %q", v)
+		}
+		cfg.Max = n
+	}
+	wait, err := durationFromEnv("CONCURRENCY_WAIT", 0)
+	if err != nil {
+		return middleware.ConcurrencyConfig{}, err
+	}
+	cfg.Wait = wait
+	return cfg, nil
+}
+
+// corsConfigFromEnv builds the CORS settings from environment variables.
+//
+//	CORS_ALLOW_ORIGINS     - comma-separated origins, or "*" (default: CORS disabled)
+//	CORS_MAX_AGE           - seconds browsers may cache a preflight (default: 0)
+//	CORS_ALLOW_CREDENTIALS - allow cookies and Authorization headers (default: false)
+func corsConfigFromEnv() (middleware.CORSConfig, error) {
+	cfg := middleware.CORSConfig{AllowOrigins: listFromEnv("CORS_ALLOW_ORIGINS")}
+
+	if v := os.Getenv("CORS_MAX_AGE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return middleware.CORSConfig{}, fmt.Errorf("CORS_MAX_AGE must be a non-negative number of seconds, got %q", v)
+		}
+		cfg.MaxAge = time.Duration(n) * time.Second
+	}
+
+	if v := os.Getenv("CORS_ALLOW_CREDENTIALS"); v != "" {
+		allow, err := strconv.ParseBool(v)
+		if err != nil {
+			return middleware.CORSConfig{}, fmt.Errorf("CORS_ALLOW_CREDENTIALS must be true or false, got %q", v)
+		}
+		cfg.AllowCredentials = allow
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return middleware.CORSConfig{}, err
+	}
+	return cfg, nil
+}
+
+// signalsFromEnv parses a comma-separated list of signal names such as
+// "SIGTERM,SIGHUP". The SIG prefix is optional. Unset means
+// defaultShutdownSignals.
+func signalsFromEnv(name string) ([]os.Signal, error) {
+	names := listFromEnv(name)
+	if len(names) == 0 {
+		return defaultShutdownSignals, nil
+	}
+	signals := make([]os.Signal, 0, len(names))
+	for _, n := range names {
+		sig, ok := signalsByName["SIG"+strings.TrimPrefix(strings.ToUpper(n), "SIG")]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown signal %q; use SIGINT, SIGTERM, SIGHUP or SIGQUIT", name, n)
+		}
+		signals = append(signals, sig)
+	}
+	return signals, nil
+}
+
+// listFromEnv splits a comma-separated variable, dropping blank entries.
+func listFromEnv(name string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// disabledEndpointsFromEnv reads DISABLED_ENDPOINTS, rejecting names that
+// are not in app.Endpoints.
+func disabledEndpointsFromEnv() ([]string, error) {
+	names := listFromEnv("DISABLED_ENDPOINTS")
+	for _, name := range names {
+		if _, ok := app.Endpoints[name]; !ok {
+			known := slices.Sorted(maps.Keys(app.Endpoints))
+			return nil, fmt.Errorf("DISABLED_ENDPOINTS: unknown endpoint %q; known: %s", name, strings.Join(known, ", "))
+		}
+	}
+	return names, nil
+}
+
+// boolFromEnv parses the named variable with strconv.ParseBool, returning
+// false when it is unset.
+func boolFromEnv(name string) (bool, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false, got %q", name, v)
+	}
+	return b, nil
+}
+
+// durationFromEnv parses the named variable with parseDuration, returning
+// def when it is unset. Only positive durations are accepted.
+func durationFromEnv(name string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	d, err := parseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be positive, got %q", name, v)
+	}
+	return d, nil
+}
+
+var iso8601Duration = regexp.MustCompile(`^P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// parseDuration accepts either a Go duration string ("1h30m") or an
+// ISO 8601 duration ("PT1H30M", "P1DT12H", "P2W"). ISO years and months are
+// rejected because their length depends on the calendar.
+func parseDuration(s string) (time.Duration, error) {
+	if !strings.HasPrefix(s, "P") {
+		return time.ParseDuration(s)
+	}
+
+	m := iso8601Duration.FindStringSubmatch(s)
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q (years and months are not supported)", s)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var total time.Duration
+	for i, unit := range units {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(m[i+1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q: %w", s, err)
+		}
+		total += time.Duration(n * float64(unit))
+	}
+	return total, nil
+}
+
+// todoConfigFromEnv builds a todo.Config from environment variables.
+//
+//	JSON_CASE            - response key style: "snake", "camel", or empty for the model defaults
+//	NORMALIZE_WHITESPACE - trim and collapse whitespace in titles (default: true)
+//	MAX_TODOS_PER_USER   - active todos a user may hold; 0 means unlimited (default: 0)
+//	DEFAULT_PAGE_SIZE    - list page size when ?limit= is omitted (default: 20)
+//	MAX_PAGE_SIZE        - largest ?limit= honoured; larger values are clamped (default: 100)
+//	MAX_PAGE_OFFSET      - deepest offset a page may start at (default: 10000)
+//	REQUIRE_IF_MATCH     - reject PUTs that replace a todo without If-Match (default: false)
+//	REJECT_PAST_DUE      - reject due dates in the past with 422 (default: false)
+//	IDS_AS_STRINGS       - write ids as JSON strings (default: false)
+//	TIME_FORMAT          - response timestamps: "rfc3339" or "unix_millis" (default: rfc3339)
+//	STRICT_JSON          - reject request bodies with unknown fields (default: false)
+//	DELETE_NOT_FOUND     - 404 on deleting an id the caller never had (default: false)
+//	RESPONSE_ENVELOPE    - wrap JSON responses as {"data": ..., "meta": ...} (default: false)
+func todoConfigFromEnv() (todo.Config, error) {
+	var cfg todo.Config
+
+	switch v := os.Getenv("JSON_CASE"); v {
+	case "", todo.SnakeCase, todo.CamelCase:
+		cfg.JSONCase = v
+	default:
+		return todo.Config{}, fmt.Errorf("JSON_CASE must be %q or %q, got %q", todo.SnakeCase, todo.CamelCase, v)
+	}
+
+	switch v := os.Getenv("TIME_FORMAT"); v {
+	case "", todo.TimeRFC3339, todo.TimeUnixMillis:
+		cfg.TimeFormat = v
+	default:
+		return todo.Config{}, fmt.Errorf("TIME_FORMAT must be %q or %q, got %q", todo.TimeRFC3339, todo.TimeUnixMillis, v)
+	}
+
+	switch v := os.Getenv("DEFAULT_PRIORITY"); v {
+	case "", todo.PriorityLow, todo.PriorityMedium, todo.PriorityHigh:
+		cfg.DefaultPriority = v
+	default:
+		return todo.Config{}, fmt.Errorf("DEFAULT_PRIORITY must be %q, %q or %q, got %q", todo.PriorityLow, todo.PriorityMedium, todo.PriorityHigh, v)
+	}
+
+	if v := os.Getenv("NORMALIZE_WHITESPACE"); v != "" {
+		normalize, err := strconv.ParseBool(v)
+		if err != nil {
+			return todo.Config{}, fmt.Errorf("NORMALIZE_WHITESPACE must be true or false, got %q", v)
+		}
+		cfg.PreserveWhitespace = !normalize
+	}
+
+	requireIfMatch, err := boolFromEnv("REQUIRE_IF_MATCH")
+	if err != nil {
+		return todo.Config{}, err
+	}
+	cfg.RequireIfMatch = requireIfMatch
+
+	rejectPastDue, err := boolFromEnv("REJECT_PAST_DUE")
+	if err != nil {
+		return todo.Config{}, err
+	}
+	cfg.RejectPastDue = rejectPastDue
+
+	idsAsStrings, err := boolFromEnv("IDS_AS_STRINGS")
+	if err != nil {
+		return todo.Config{}, err
+	}
+	cfg.IDsAsStrings = idsAsStrings
+
+	strictJSON, err := boolFromEnv("STRICT_JSON")
+	if err != nil {
+		return todo.Config{}, err
+	}
+	cfg.StrictJSON = strictJSON
+
+	deleteNotFound, err := boolFromEnv("DELETE_NOT_FOUND")
+	if err != nil {
+		return todo.Config{}, err
+	}
+	cfg.DeleteNotFound = deleteNotFound
+
+	responseEnvelope, err := boolFromEnv("RESPONSE_ENVELOPE")
+	if err != nil {
+		return todo.Config{}, err
+	}
+	cfg.ResponseEnvelope = responseEnvelope
+
+	if v := os.Getenv("MAX_TITLE_LEN"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return todo.Config{}, fmt.Errorf("MAX_TITLE_LEN must be a positive integer, got %q", v)
+		}
+		cfg.MaxTitleLen = n
+	}
+
+	if v := os.Getenv("MAX_BULK_ITEMS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return todo.Config{}, fmt.Errorf("MAX_BULK_ITEMS must be a positive integer, got %q", v)
+		}
+		cfg.MaxBulkItems = n
+	}
+
+	if v := os.Getenv("MAX_TODOS_PER_USER"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return todo.Config{}, fmt.Errorf("MAX_TODOS_PER_USER must be a non-negative integer, got %q", v)
+		}
+		cfg.MaxTodosPerUser = n
+	}
+
+	for name, size := range map[string]*int{
+		"DEFAULT_PAGE_SIZE": &cfg.PageLimits.Default,
+		"MAX_PAGE_SIZE":     &cfg.PageLimits.Max,
+		"MAX_PAGE_OFFSET":   &cfg.PageLimits.MaxOffset,
+	} {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return todo.Config{}, fmt.Errorf("%s must be a positive integer, got %q", name, v)
+			}
+			*size = n
+		}
+	}
+	if err := cfg.PageLimits.Validate(); err != nil {
+		return todo.Config{}, fmt.Errorf("DEFAULT_PAGE_SIZE/MAX_PAGE_SIZE/MAX_PAGE_OFFSET: %w", err)
+	}
+
+	if v := os.Getenv("TZ"); v != "" {
+		loc, err := time.LoadLocation(v)
+		if err != nil {
+			return todo.Config{}, fmt.Errorf("TZ must be an IANA time zone such as Asia/Bangkok, got %q", v)
+		}
+		cfg.Location = loc
+	}
+
+	return cfg, nil
+}
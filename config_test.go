@@ -0,0 +1,640 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pradist/todoapi/auth"
+)
+
+func TestTodoConfigFromEnv_JSONCase(t *testing.T) {
+	for _, v := range []string{"", "snake", "camel"} {
+		t.Setenv("JSON_CASE", v)
+		cfg, err := todoConfigFromEnv()
+		if err != nil {
+			t.Fatalf("JSON_CASE=%q: unexpected error: %v", v, err)
+		}
+		if cfg.JSONCase != v {
+			t.Errorf("expected JSONCase %q, got %q", v, cfg.JSONCase)
+		}
+	}
+}
+
+func TestTodoConfigFromEnv_InvalidJSONCase(t *testing.T) {
+	t.Setenv("JSON_CASE", "kebab")
+
+	if _, err := todoConfigFromEnv(); err == nil {
+		t.Fatal("expected error for unsupported JSON_CASE")
+	}
+}
+
+func TestTodoConfigFromEnv_DefaultPriority(t *testing.T) {
+	t.Setenv("DEFAULT_PRIORITY", "high")
+
+	cfg, err := todoConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.DefaultPriority != "high" {
+		t.Errorf("expected DefaultPriority high, got %q", cfg.DefaultPriority)
+	}
+
+	t.Setenv("DEFAULT_PRIORITY", "urgent")
+	if _, err := todoConfigFromEnv(); err == nil {
+		t.Error("expected error for unknown DEFAULT_PRIORITY")
+	}
+}
+
+func TestTodoConfigFromEnv_NormalizeWhitespace(t *testing.T) {
+	tests := []struct {
+		value    string
+		preserve bool
+	}{
+		{"", false},
+		{"true", false},
+		{"false", true},
+	}
+	for _, tc := range tests {
+		t.Setenv("NORMALIZE_WHITESPACE", tc.value)
+		cfg, err := todoConfigFromEnv()
+		if err != nil {
+			t.Fatalf("NORMALIZE_WHITESPACE=%q: unexpected error: %v", tc.value, err)
+		}
+		if cfg.PreserveWhitespace != tc.preserve {
+			t.Errorf("NORMALIZE_WHITESPACE=%q: expected PreserveWhitespace=%v", tc.value, tc.preserve)
+		}
+	}
+}
+
+func TestTodoConfigFromEnv_InvalidNormalizeWhitespace(t *testing.T) {
+	t.Setenv("NORMALIZE_WHITESPACE", "sometimes")
+
+	if _, err := todoConfigFromEnv(); err == nil {
+		t.Fatal("expected error for invalid NORMALIZE_WHITESPACE")
+	}
+}
+
+func TestConfig_CheckJWT(t *testing.T) {
+	if err := testConfig().CheckJWT(); err != nil {
+		t.Fatalf("expected JWT self-check to pass, got %v", err)
+	}
+
+	cfg := testConfig()
+	cfg.Sign = ""
+	if err := cfg.CheckJWT(); err == nil {
+		t.Fatal("expected JWT self-check to fail without a signing key")
+	}
+}
+
+func TestCORSConfigFromEnv(t *testing.T) {
+	t.Setenv("CORS_ALLOW_ORIGINS", "https://a.example.com, https://b.example.com")
+	t.Setenv("CORS_MAX_AGE", "600")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+
+	cfg, err := corsConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.AllowOrigins) != 2 || cfg.AllowOrigins[1] != "https://b.example.com" {
+		t.Errorf("unexpected origins %v", cfg.AllowOrigins)
+	}
+	if cfg.MaxAge != 10*time.Minute || !cfg.AllowCredentials {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+}
+
+// TestCORSConfigFromEnv_CredentialsWithWildcard: browsers forbid this, so startup fails
+func TestCORSConfigFromEnv_CredentialsWithWildcard(t *testing.T) {
+	t.Setenv("CORS_ALLOW_ORIGINS", "*")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+
+	if _, err := corsConfigFromEnv(); err == nil {
+		t.Fatal("expected error for credentials with a wildcard origin")
+	}
+}
+
+func TestCORSConfigFromEnv_InvalidMaxAge(t *testing.T) {
+	t.Setenv("CORS_MAX_AGE", "-5")
+
+	if _, err := corsConfigFromEnv(); err == nil {
+		t.Fatal("expected error for negative CORS_MAX_AGE")
+	}
+}
+
+func TestConfigFromEnv_SecureHeaders(t *testing.T) {
+	t.Setenv("SECURE_HEADERS", "true")
+
+	cfg, err := configFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.SecureHeaders {
+		t.Error("expected secure headers to be enabled")
+	}
+
+	t.Setenv("SECURE_HEADERS", "yes please")
+	if _, err := configFromEnv(); err == nil {
+		t.Error("expected error for invalid SECURE_HEADERS")
+	}
+}
+
+func TestTodoConfigFromEnv_TZ(t *testing.T) {
+	t.Setenv("TZ", "UTC")
+
+	cfg, err := todoConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Location != time.UTC {
+		t.Errorf("expected UTC, got %v", cfg.Location)
+	}
+
+	t.Setenv("TZ", "Mars/Olympus_Mons")
+	if _, err := todoConfigFromEnv(); err == nil {
+		t.Error("expected error for unknown TZ")
+	}
+}
+
+func TestSignalsFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []os.Signal
+	}{
+		{"", defaultShutdownSignals},
+		{"SIGTERM", []os.Signal{syscall.SIGTERM}},
+		{"term, hup", []os.Signal{syscall.SIGTERM, syscall.SIGHUP}},
+		{"SIGINT,SIGQUIT", []os.Signal{syscall.SIGINT, syscall.SIGQUIT}},
+	}
+	for _, tc := range tests {
+		t.Setenv("SHUTDOWN_SIGNALS", tc.value)
+		got, err := signalsFromEnv("SHUTDOWN_SIGNALS")
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.value, err)
+			continue
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%q: expected %v, got %v", tc.value, tc.want, got)
+		}
+	}
+
+	t.Setenv("SHUTDOWN_SIGNALS", "SIGKILL")
+	if _, err := signalsFromEnv("SHUTDOWN_SIGNALS"); err == nil {
+		t.Error("expected error for a signal that can't be trapped")
+	}
+}
+
+func TestConfigFromEnv_DisabledEndpoints(t *testing.T) {
+	t.Setenv("DISABLED_ENDPOINTS", "bulk, trends")
+
+	cfg, err := configFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(cfg.DisabledEndpoints, []string{"bulk", "trends"}) {
+		t.Errorf("expected [bulk trends], got %v", cfg.DisabledEndpoints)
+	}
+
+	t.Setenv("DISABLED_ENDPOINTS", "bulk,export")
+	if _, err := configFromEnv(); err == nil || !strings.Contains(err.Error(), `"export"`) {
+		t.Errorf("expected an error naming the unknown endpoint, got %v", err)
+	}
+}
+
+func TestConfigFromEnv_LogSkipPaths(t *testing.T) {
+	t.Setenv("LOG_SKIP_PATHS", "/healthz, /metrics,,")
+
+	cfg, err := configFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(cfg.LogSkipPaths, []string{"/healthz", "/metrics"}) {
+		t.Errorf("expected [/healthz /metrics], got %v", cfg.LogSkipPaths)
+	}
+}
+
+func TestConfigFromEnv_DBNaming(t *testing.T) {
+	t.Setenv("DB_TABLE_PREFIX", "todoapi_")
+	t.Setenv("DB_SINGULAR_TABLES", "true")
+
+	cfg, err := configFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.dbNaming.TableName("Todo"); got != "todoapi_todo" {
+		t.Errorf("expected table todoapi_todo, got %q", got)
+	}
+
+	t.Setenv("DB_SINGULAR_TABLES", "maybe")
+	if _, err := configFromEnv(); err == nil {
+		t.Error("expected error for a non-boolean DB_SINGULAR_TABLES")
+	}
+}
+
+func TestConfigFromEnv_DBConnect(t *testing.T) {
+	cfg, err := configFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.dbConnectRetries != 0 || cfg.dbConnectBackoff != time.Second {
+		t.Errorf("expected 0 retries 1s apart by default, got %d and %v", cfg.dbConnectRetries, cfg.dbConnectBackoff)
+	}
+
+	t.Setenv("DB_CONNECT_RETRIES", "10")
+	t.Setenv("DB_CONNECT_BACKOFF", "PT2S")
+	cfg, err = configFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.dbConnectRetries != 10 || cfg.dbConnectBackoff != 2*time.Second {
+		t.Errorf("expected 10 retries 2s apart, got %d and %v", cfg.dbConnectRetries, cfg.dbConnectBackoff)
+	}
+
+	t.Setenv("DB_CONNECT_RETRIES", "-1")
+	if _, err := configFromEnv(); err == nil {
+		t.Error("expected error for a negative DB_CONNECT_RETRIES")
+	}
+}
+
+func TestConfigFromEnv_DBStatementTimeout(t *testing.T) {
+	t.Setenv("DB_STATEMENT_TIMEOUT", "PT30S")
+
+	cfg, err := configFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.dbStatementTimeout != 30*time.Second {
+		t.Errorf("expected 30s, got %v", cfg.dbStatementTimeout)
+	}
+
+	for _, v := range []string{"500us", "-1s"} {
+		t.Setenv("DB_STATEMENT_TIMEOUT", v)
+		if _, err := configFromEnv(); err == nil {
+			t.Errorf("DB_STATEMENT_TIMEOUT=%q: expected error", v)
+		}
+	}
+}
+
+func TestConfigFromEnv_DBFallbackMemory(t *testing.T) {
+	t.Setenv("DB_FALLBACK_MEMORY", "true")
+
+	cfg, err := configFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.dbFallbackMemory {
+		t.Error("expected the in-memory fallback to be enabled")
+	}
+
+	t.Setenv("GIN_MODE", "release")
+	if _, err := configFromEnv(); err == nil {
+		t.Error("expected DB_FALLBACK_MEMORY to be refused in release mode")
+	}
+}
+
+func TestConfigFromEnv_SQLiteVacuumInterval(t *testing.T) {
+	t.Setenv("SQLITE_VACUUM_INTERVAL", "P1D")
+
+	cfg, err := configFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.sqliteVacuumInterval != 24*time.Hour {
+		t.Errorf("expected a daily vacuum, got %v", cfg.sqliteVacuumInterval)
+	}
+
+	t.Setenv("SQLITE_VACUUM_INTERVAL", "weekly")
+	if _, err := configFromEnv(); err == nil {
+		t.Error("expected error for an invalid SQLITE_VACUUM_INTERVAL")
+	}
+}
+
+func TestConfigFromEnv_AuditBatch(t *testing.T) {
+	cfg, err := configFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.auditBatchSize != 0 || cfg.auditFlushInterval != time.Second {
+		t.Errorf("expected unbatched audit writes with a 1s interval by default, got %d and %v", cfg.auditBatchSize, cfg.auditFlushInterval)
+	}
+
+	t.Setenv("AUDIT_BATCH_SIZE", "100")
+	t.Setenv("AUDIT_FLUSH_INTERVAL", "250ms")
+	cfg, err = configFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.auditBatchSize != 100 || cfg.auditFlushInterval != 250*time.Millisecond {
+		t.Errorf("expected batches of 100 every 250ms, got %d and %v", cfg.auditBatchSize, cfg.auditFlushInterval)
+	}
+
+	for name, v := range map[string]string{"AUDIT_BATCH_SIZE": "-1", "AUDIT_FLUSH_INTERVAL": "0s"} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, v)
+			if _, err := configFromEnv(); err == nil {
+				t.Errorf("expected error for %s=%q", name, v)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv_Concurrency(t *testing.T) {
+	t.Setenv("MAX_CONCURRENT_REQUESTS", "50")
+	t.Setenv("CONCURRENCY_WAIT", "250ms")
+
+	cfg, err := configFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Concurrency.Max != 50 || cfg.Concurrency.Wait != 250*time.Millisecond {
+		t.Errorf("unexpected concurrency config %+v", cfg.Concurrency)
+	}
+
+	t.Setenv("MAX_CONCURRENT_REQUESTS", "-1")
+	if _, err := configFromEnv(); err == nil {
+		t.Error("expected error for a negative MAX_CONCURRENT_REQUESTS")
+	}
+}
+
+func TestConfigFromEnv_TokenScope(t *testing.T) {
+	cfg, err := configFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.TokenScope.Issuer != auth.DefaultIssuer || cfg.TokenScope.Audience != auth.DefaultAudience {
+		t.Errorf("expected default scope, got %+v", cfg.TokenScope)
+	}
+
+	t.Setenv("SIGN", "secret")
+	t.Setenv("JWT_ISSUER", "https://auth.example.com")
+	t.Setenv("JWT_AUDIENCE", "todo-clients")
+	if cfg, _ = configFromEnv(); cfg.TokenScope.Issuer != "https://auth.example.com" || cfg.TokenScope.Audience != "todo-clients" {
+		t.Errorf("expected configured scope, got %+v", cfg.TokenScope)
+	}
+	if err := cfg.CheckJWT(); err != nil {
+		t.Errorf("expected minted tokens to pass the scoped Protect, got %v", err)
+	}
+}
+
+func TestTodoConfigFromEnv_MaxTodosPerUser(t *testing.T) {
+	t.Setenv("MAX_TODOS_PER_USER", "50")
+
+	cfg, err := todoConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MaxTodosPerUser != 50 {
+		t.Errorf("expected MaxTodosPerUser=50, got %d", cfg.MaxTodosPerUser)
+	}
+}
+
+func TestTodoConfigFromEnv_InvalidMaxTodosPerUser(t *testing.T) {
+	for _, v := range []string{"-1", "many"} {
+		t.Setenv("MAX_TODOS_PER_USER", v)
+		if _, err := todoConfigFromEnv(); err == nil {
+			t.Errorf("MAX_TODOS_PER_USER=%q: expected error", v)
+		}
+	}
+}
+
+func TestTodoConfigFromEnv_MaxBulkItems(t *testing.T) {
+	t.Setenv("MAX_BULK_ITEMS", "500")
+
+	cfg, err := todoConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MaxBulkItems != 500 {
+		t.Errorf("expected MaxBulkItems=500, got %d", cfg.MaxBulkItems)
+	}
+
+	for _, v := range []string{"0", "lots"} {
+		t.Setenv("MAX_BULK_ITEMS", v)
+		if _, err := todoConfigFromEnv(); err == nil {
+			t.Errorf("MAX_BULK_ITEMS=%q: expected error", v)
+		}
+	}
+}
+
+func TestTodoConfigFromEnv_MaxTitleLen(t *testing.T) {
+	t.Setenv("MAX_TITLE_LEN", "200")
+
+	cfg, err := todoConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MaxTitleLen != 200 {
+		t.Errorf("expected MaxTitleLen=200, got %d", cfg.MaxTitleLen)
+	}
+
+	for _, v := range []string{"0", "long"} {
+		t.Setenv("MAX_TITLE_LEN", v)
+		if _, err := todoConfigFromEnv(); err == nil {
+			t.Errorf("MAX_TITLE_LEN=%q: expected error", v)
+		}
+	}
+}
+
+func TestTodoConfigFromEnv_RequireIfMatch(t *testing.T) {
+	t.Setenv("REQUIRE_IF_MATCH", "true")
+
+	cfg, err := todoConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.RequireIfMatch {
+		t.Error("expected RequireIfMatch to be set")
+	}
+
+	t.Setenv("REQUIRE_IF_MATCH", "sometimes")
+	if _, err := todoConfigFromEnv(); err == nil {
+		t.Error("expected error for a non-boolean value")
+	}
+}
+
+func TestTodoConfigFromEnv_RejectPastDue(t *testing.T) {
+	t.Setenv("REJECT_PAST_DUE", "true")
+
+	cfg, err := todoConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.RejectPastDue {
+		t.Error("expected RejectPastDue to be set")
+	}
+}
+
+func TestTodoConfigFromEnv_IDsAsStrings(t *testing.T) {
+	t.Setenv("IDS_AS_STRINGS", "true")
+
+	cfg, err := todoConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.IDsAsStrings {
+		t.Error("expected IDsAsStrings to be set")
+	}
+}
+
+func TestTodoConfigFromEnv_StrictJSON(t *testing.T) {
+	t.Setenv("STRICT_JSON", "true")
+
+	cfg, err := todoConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.StrictJSON {
+		t.Error("expected StrictJSON to be set")
+	}
+}
+
+func TestTodoConfigFromEnv_DeleteNotFound(t *testing.T) {
+	t.Setenv("DELETE_NOT_FOUND", "true")
+
+	cfg, err := todoConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.DeleteNotFound {
+		t.Error("expected DeleteNotFound to be set")
+	}
+}
+
+func TestTodoConfigFromEnv_ResponseEnvelope(t *testing.T) {
+	t.Setenv("RESPONSE_ENVELOPE", "true")
+
+	cfg, err := todoConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.ResponseEnvelope {
+		t.Error("expected ResponseEnvelope to be set")
+	}
+}
+
+func TestTodoConfigFromEnv_PageSizes(t *testing.T) {
+	t.Setenv("DEFAULT_PAGE_SIZE", "10")
+	t.Setenv("MAX_PAGE_SIZE", "50")
+	t.Setenv("MAX_PAGE_OFFSET", "1000")
+
+	cfg, err := todoConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.PageLimits.Default != 10 || cfg.PageLimits.Max != 50 || cfg.PageLimits.MaxOffset != 1000 {
+		t.Errorf("unexpected page limits %+v", cfg.PageLimits)
+	}
+}
+
+func TestTodoConfigFromEnv_InvalidPageSizes(t *testing.T) {
+	tests := []struct{ def, max string }{
+		{"100", "50"},
+		{"0", ""},
+		{"", "lots"},
+	}
+	for _, tc := range tests {
+		t.Setenv("DEFAULT_PAGE_SIZE", tc.def)
+		t.Setenv("MAX_PAGE_SIZE", tc.max)
+		if _, err := todoConfigFromEnv(); err == nil {
+			t.Errorf("DEFAULT_PAGE_SIZE=%q MAX_PAGE_SIZE=%q: expected error", tc.def, tc.max)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"90s":       90 * time.Second,
+		"1h30m":     90 * time.Minute,
+		"PT1H":      time.Hour,
+		"PT90S":     90 * time.Second,
+		"PT1H30M":   90 * time.Minute,
+		"PT0.5S":    500 * time.Millisecond,
+		"P1D":       24 * time.Hour,
+		"P1DT12H":   36 * time.Hour,
+		"P2W":       14 * 24 * time.Hour,
+		"PT5M":      5 * time.Minute,
+		"PT1H0M30S": time.Hour + 30*time.Second,
+	}
+	for in, want := range tests {
+		got, err := parseDuration(in)
+		if err != nil {
+			t.Errorf("parseDuration(%q): unexpected error: %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("parseDuration(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestParseDuration_Invalid(t *testing.T) {
+	for _, in := range []string{"", "P", "PT", "P1Y", "P1M", "PT1X", "P1DT", "1 hour", "PT-1H", "abc"} {
+		if _, err := parseDuration(in); err == nil {
+			t.Errorf("parseDuration(%q): expected error", in)
+		}
+	}
+}
+
+func TestDurationFromEnv(t *testing.T) {
+	t.Setenv("TEST_DURATION", "")
+	if d, err := durationFromEnv("TEST_DURATION", time.Minute); err != nil || d != time.Minute {
+		t.Errorf("expected default 1m, got %v (err %v)", d, err)
+	}
+
+	t.Setenv("TEST_DURATION", "PT10M")
+	if d, err := durationFromEnv("TEST_DURATION", time.Minute); err != nil || d != 10*time.Minute {
+		t.Errorf("expected 10m, got %v (err %v)", d, err)
+	}
+
+	for _, v := range []string{"0s", "-5m", "PT0S", "soon"} {
+		t.Setenv("TEST_DURATION", v)
+		if _, err := durationFromEnv("TEST_DURATION", time.Minute); err == nil {
+			t.Errorf("TEST_DURATION=%q: expected error", v)
+		}
+	}
+}
+
+func TestConfigFromEnv_Durations(t *testing.T) {
+	t.Setenv("TOKEN_TTL", "PT1H")
+	t.Setenv("SHUTDOWN_TIMEOUT", "30s")
+
+	cfg, err := configFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.TokenTTL != time.Hour {
+		t.Errorf("expected token TTL 1h, got %v", cfg.TokenTTL)
+	}
+	if cfg.shutdownTimeout != 30*time.Second {
+		t.Errorf("expected shutdown timeout 30s, got %v", cfg.shutdownTimeout)
+	}
+}
+
+func TestConfigFromEnv_InvalidTokenTTL(t *testing.T) {
+	t.Setenv("TOKEN_TTL", "P1M")
+
+	if _, err := configFromEnv(); err == nil {
+		t.Fatal("expected error for calendar-based TOKEN_TTL")
+	}
+}
+
+// TestTodoConfigFromEnv_TimeFormat: TIME_FORMAT accepts rfc3339 and unix_millis only
+func TestTodoConfigFromEnv_TimeFormat(t *testing.T) {
+	t.Setenv("TIME_FORMAT", "unix_millis")
+
+	cfg, err := todoConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.TimeFormat != "unix_millis" {
+		t.Errorf("expected TimeFormat unix_millis, got %q", cfg.TimeFormat)
+	}
+
+	t.Setenv("TIME_FORMAT", "unix")
+	if _, err := todoConfigFromEnv(); err == nil {
+		t.Error("expected error for unknown TIME_FORMAT")
+	}
+}
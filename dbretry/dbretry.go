@@ -0,0 +1,87 @@
+// Package dbretry retries database work that failed for a transient reason,
+// such as a lock timeout, a deadlock or a dropped connection.
+package dbretry
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"syscall"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Policy bounds the retries. The zero value uses DefaultPolicy.
+type Policy struct {
+	// MaxAttempts is the total number of tries, including the first.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry; it doubles each time
+	// up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxElapsed stops retrying once this much time has passed since the
+	// first attempt.
+	MaxElapsed time.Duration
+}
+
+var DefaultPolicy = Policy{
+	MaxAttempts: 4,
+	BaseDelay:   10 * time.Millisecond,
+	MaxDelay:    200 * time.Millisecond,
+	MaxElapsed:  time.Second,
+}
+
+// Do runs fn until it succeeds, returns an error that is not transient, or
+// the policy is exhausted. fn must be safe to repeat: a read, or a whole
+// transaction that was rolled back.
+func Do(ctx context.Context, p Policy, fn func() error) error {
+	if p.MaxAttempts == 0 {
+		p = DefaultPolicy
+	}
+	start := time.Now()
+	delay := p.BaseDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !IsTransient(err) || attempt >= p.MaxAttempts || time.Since(start)+delay > p.MaxElapsed {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay = min(delay*2, p.MaxDelay)
+	}
+}
+
+// sqlStater is implemented by Postgres driver errors (pgconn.PgError and
+// lib/pq.Error) without importing either driver.
+type sqlStater interface {
+	SQLState() string
+}
+
+// IsTransient reports whether err is worth retrying: SQLite busy or locked
+// errors, Postgres serialization failures and deadlocks, and broken
+// connections.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	var pgErr sqlStater
+	if errors.As(err, &pgErr) {
+		switch pgErr.SQLState() {
+		case "40001", "40P01": // serialization_failure, deadlock_detected
+			return true
+		}
+		return false
+	}
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, syscall.ECONNRESET)
+}
@@ -0,0 +1,114 @@
+package dbretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	errBusy  = sqlite3.Error{Code: sqlite3.ErrBusy}
+	fastTest = Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, MaxElapsed: time.Second}
+)
+
+type pgError string
+
+func (e pgError) Error() string    { return "pg error " + string(e) }
+func (e pgError) SQLState() string { return string(e) }
+
+func TestIsTransient(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want bool
+	}{
+		"sqlite busy":         {errBusy, true},
+		"wrapped sqlite busy": {fmt.Errorf("create: %w", errBusy), true},
+		"sqlite constraint":   {sqlite3.Error{Code: sqlite3.ErrConstraint}, false},
+		"postgres deadlock":   {pgError("40P01"), true},
+		"postgres serial":     {pgError("40001"), true},
+		"postgres unique":     {pgError("23505"), false},
+		"plain error":         {errors.New("boom"), false},
+		"context canceled":    {context.Canceled, false},
+	}
+	for name, tc := range tests {
+		if got := IsTransient(tc.err); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", name, tc.want, got)
+		}
+	}
+}
+
+// TestDo_RetriesTransient: a transient failure is retried until fn succeeds
+func TestDo_RetriesTransient(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), fastTest, func() error {
+		calls++
+		if calls < 3 {
+			return errBusy
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestDo_NonTransientPassesThrough(t *testing.T) {
+	calls := 0
+	boom := errors.New("boom")
+	err := Do(context.Background(), fastTest, func() error {
+		calls++
+		return boom
+	})
+
+	if !errors.Is(err, boom) || calls != 1 {
+		t.Errorf("expected one call returning boom, got %d calls and %v", calls, err)
+	}
+}
+
+func TestDo_CapsAttempts(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), fastTest, func() error {
+		calls++
+		return errBusy
+	})
+
+	if !IsTransient(err) || calls != fastTest.MaxAttempts {
+		t.Errorf("expected %d calls ending in the transient error, got %d and %v", fastTest.MaxAttempts, calls, err)
+	}
+}
+
+// TestDo_CapsElapsed: no retry is started that would run past MaxElapsed
+func TestDo_CapsElapsed(t *testing.T) {
+	p := Policy{MaxAttempts: 100, BaseDelay: 50 * time.Millisecond, MaxDelay: 50 * time.Millisecond, MaxElapsed: 120 * time.Millisecond}
+	calls := 0
+	Do(context.Background(), p, func() error {
+		calls++
+		return errBusy
+	})
+
+	if calls != 3 {
+		t.Errorf("expected 3 calls within the time budget, got %d", calls)
+	}
+}
+
+func TestDo_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	Do(ctx, Policy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour, MaxElapsed: 2 * time.Hour}, func() error {
+		calls++
+		return errBusy
+	})
+
+	if calls != 1 {
+		t.Errorf("expected no retries after cancellation, got %d calls", calls)
+	}
+}
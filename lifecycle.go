@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+type shutdownHook struct {
+	name string
+	fn   func(context.Context) error
+}
+
+// lifecycle collects shutdown hooks and runs them in reverse order of
+// registration, so a resource is released only after everything registered
+// later, which may depend on it, has stopped. Register the database first,
+// then workers; startServer registers the HTTP server last so it stops
+// taking requests before anything else goes away.
+type lifecycle struct {
+	hooks []shutdownHook
+}
+
+func (l *lifecycle) onShutdown(name string, fn func(context.Context) error) {
+	l.hooks = append(l.hooks, shutdownHook{name: name, fn: fn})
+}
+
+// shutdown runs every hook within ctx, newest first. A failing hook does
+// not stop the rest; all errors are returned together.
+func (l *lifecycle) shutdown(ctx context.Context) error {
+	var errs []error
+	for i := len(l.hooks) - 1; i >= 0; i-- {
+		h := l.hooks[i]
+		if err := h.fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// job registers a shutdown hook for the background job name and returns
+// the function that starts it, running run in its own goroutine. The hook
+// cancels the context passed to run and, if the job was started, waits for
+// run to return.
+func (l *lifecycle) job(name string, run func(context.Context)) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	var started atomic.Bool
+	done := make(chan struct{})
+	l.onShutdown(name, func(shutdownCtx context.Context) error {
+		cancel()
+		if !started.Load() {
+			return nil
+		}
+		select {
+		case <-done:
+			return nil
+		case <-shutdownCtx.Done():
+			return shutdownCtx.Err()
+		}
+	})
+	return func() {
+		started.Store(true)
+		go func() {
+			defer close(done)
+			run(ctx)
+		}()
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestLifecycle_ReverseOrder: hooks run newest first
+func TestLifecycle_ReverseOrder(t *testing.T) {
+	var lc lifecycle
+	var order []string
+	for _, name := range []string{"database", "workers", "http server"} {
+		lc.onShutdown(name, func(context.Context) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+
+	if err := lc.shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"http server", "workers", "database"}
+	if !slices.Equal(order, want) {
+		t.Errorf("expected order %v, got %v", want, order)
+	}
+}
+
+// TestLifecycle_ErrorsDontStopLaterHooks: every hook runs and failures are reported together
+func TestLifecycle_ErrorsDontStopLaterHooks(t *testing.T) {
+	var lc lifecycle
+	closed := false
+	lc.onShutdown("database", func(context.Context) error {
+		closed = true
+		return nil
+	})
+	lc.onShutdown("workers", func(context.Context) error {
+		return errors.New("worker stuck")
+	})
+
+	err := lc.shutdown(context.Background())
+
+	if err == nil || err.Error() != "workers: worker stuck" {
+		t.Errorf("expected the worker error, got %v", err)
+	}
+	if !closed {
+		t.Error("expected the database hook to run after a failed hook")
+	}
+}
+
+func TestLifecycle_PassesDrainContext(t *testing.T) {
+	var lc lifecycle
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	lc.onShutdown("worker", func(ctx context.Context) error {
+		return ctx.Err()
+	})
+
+	if err := lc.shutdown(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected hooks to receive the drain context, got %v", err)
+	}
+}
+
+// TestLifecycle_Job: shutdown cancels a started job and waits for it, and skips one never started
+func TestLifecycle_Job(t *testing.T) {
+	var lc lifecycle
+	stopped := false
+	start := lc.job("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		stopped = true
+	})
+	lc.job("idle", func(context.Context) {
+		t.Error("expected the job that was never started not to run")
+	})
+	start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := lc.shutdown(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !stopped {
+		t.Error("expected shutdown to wait for the job to return")
+	}
+}
@@ -5,30 +5,107 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"syscall"
 
 	"github.com/joho/godotenv"
+	"github.com/pradist/todoapi/audit"
+	"github.com/pradist/todoapi/middleware"
+	"gorm.io/gorm"
 )
 
 func main() {
+	environ := environNames()
 	err := godotenv.Load(".env")
 	if err != nil {
 		fmt.Printf("please consider environment variables: %s", err)
 	}
 
-	db, err := setupDB("todo.db")
+	cfg, err := configFromEnv()
 	if err != nil {
-		panic("failed to connect database")
+		fmt.Printf("invalid configuration: %s\n", err)
+		os.Exit(1)
+	}
+	if err := cfg.CheckJWT(); err != nil {
+		fmt.Printf("JWT self-check failed (check SIGN): %s\n", err)
+		os.Exit(1)
 	}
 
-	r := setupRouter(db, os.Getenv("SIGN"), ipLimiterFromEnv())
+	db, err := connectDB(func() (*gorm.DB, error) {
+		return openDB(busyTimeoutDSN("todo.db", cfg.dbStatementTimeout), cfg.dbNaming)
+	}, cfg.dbConnectRetries, cfg.dbConnectBackoff)
+	db, err = fallbackDB(db, err, cfg.dbFallbackMemory, cfg.dbNaming)
+	if err != nil {
+		fmt.Printf("failed to connect database: %s\n", err)
+		os.Exit(1)
+	}
+	if cfg.DebugSQL {
+		if err := middleware.RegisterQueryCounter(db); err != nil {
+			fmt.Printf("failed to enable DEBUG_SQL: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var lc lifecycle
+	lc.onShutdown("database", func(context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+
+	if cfg.auditBatchSize > 0 {
+		batcher := audit.NewBatcher(db, cfg.auditBatchSize, cfg.auditFlushInterval)
+		lc.onShutdown("audit batcher", batcher.Close)
+		cfg.Todo.Audit = batcher
+	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	startReloader(".env", environ, cfg.Limiter, cfg.shutdownSignals, &lc)
+	startPurger := startTrashPurger(db, cfg.trashRetention, &lc)
+	startVacuumJob := startVacuum(db, cfg.sqliteVacuumInterval, &lc)
+
+	// Listen straight away and answer 503 until the database is ready.
+	// Shutdown waits for initialisation first, so the jobs it starts are
+	// stopped and the database isn't closed under the migration.
+	var initErr error
+	initDone := make(chan struct{})
+	lc.onShutdown("database init", func(ctx context.Context) error {
+		select {
+		case <-initDone:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	cfg.Ready = new(middleware.Readiness)
+	r := setupRouter(db, cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), cfg.shutdownSignals...)
 	defer stop()
 
-	if err := startServer(ctx, r, ":"+os.Getenv("PORT")); err != nil {
+	go func() {
+		err := initDB(db)
+		if err == nil {
+			cfg.Ready.SetReady()
+			startPurger()
+			startVacuumJob()
+		}
+		initErr = err
+		close(initDone)
+		if err != nil {
+			stop()
+		}
+	}()
+
+	if err := startServer(ctx, r, ":"+os.Getenv("PORT"), cfg.shutdownTimeout, &lc); err != nil {
 		fmt.Printf("Server forced to shutdown: %s\n", err)
 	}
 
+	<-initDone
+	if initErr != nil {
+		fmt.Printf("database initialisation failed: %s\n", initErr)
+		os.Exit(1)
+	}
+
 	fmt.Println("Server exiting")
 }
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+)
+
+// ConcurrencyConfig bounds how many requests are handled at once.
+type ConcurrencyConfig struct {
+	// Max is the number of requests in flight at once. Zero means
+	// unlimited.
+	Max int
+	// Wait is how long a request beyond Max queues for a free slot before
+	// it is shed. Zero sheds it immediately.
+	Wait time.Duration
+}
+
+// ConcurrencyLimit holds requests to cfg.Max in flight, so a burst can't
+// start more database work than the server can handle. Excess requests
+// wait up to cfg.Wait, then get 503 Service Unavailable with Retry-After.
+// Requests for the skip paths are never limited.
+func ConcurrencyLimit(cfg ConcurrencyConfig, skip ...string) gin.HandlerFunc {
+	slots := make(chan struct{}, cfg.Max)
+	return func(c *gin.Context) {
+		if slices.Contains(skip, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		if !acquire(c, slots, cfg.Wait) {
+			c.Header("Retry-After", "1")
+			apperr.Abort(c, apperr.ErrUnavailable.With("server is busy"))
+			return
+		}
+		defer func() { <-slots }()
+		c.Next()
+	}
+}
+
+// acquire takes a slot, waiting up to wait for one to free up. It gives up
+// early if the client goes away.
+func acquire(c *gin.Context, slots chan struct{}, wait time.Duration) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+	if wait <= 0 {
+		return false
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-c.Request.Context().Done():
+		return false
+	}
+}
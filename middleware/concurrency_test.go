@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// blockingRouter serves /work, which holds its slot until release is
+// closed, and /healthz, which returns at once.
+func blockingRouter(cfg ConcurrencyConfig, started chan<- struct{}, release <-chan struct{}) *gin.Engine {
+	r := gin.New()
+	r.Use(ConcurrencyLimit(cfg, "/healthz"))
+	r.GET("/work", func(c *gin.Context) {
+		started <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	r.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func doConcurrent(r *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+// TestConcurrencyLimit_Sheds: requests beyond the limit get 503 while the slots are busy
+func TestConcurrencyLimit_Sheds(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	r := blockingRouter(ConcurrencyConfig{Max: 2}, started, release)
+
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = doConcurrent(r, "/work").Code
+		}()
+		<-started
+	}
+
+	w := doConcurrent(r, "/work")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 beyond the limit, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("expected Retry-After: 1, got %q", got)
+	}
+	if w := doConcurrent(r, "/healthz"); w.Code != http.StatusOK {
+		t.Errorf("expected skipped paths to pass, got %d", w.Code)
+	}
+
+	close(release)
+	wg.Wait()
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("request %d: expected 200, got %d", i, code)
+		}
+	}
+	go func() { <-started }()
+	if w := doConcurrent(r, "/work"); w.Code != http.StatusOK {
+		t.Errorf("expected 200 once slots are free, got %d", w.Code)
+	}
+}
+
+// TestConcurrencyLimit_Waits: a queued request gets the slot freed within Wait
+func TestConcurrencyLimit_Waits(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	r := blockingRouter(ConcurrencyConfig{Max: 1, Wait: 5 * time.Second}, started, release)
+
+	done := make(chan int)
+	go func() { done <- doConcurrent(r, "/work").Code }()
+	<-started
+
+	queued := make(chan int)
+	go func() { queued <- doConcurrent(r, "/work").Code }()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	<-started
+
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("expected the first request to succeed, got %d", code)
+	}
+	if code := <-queued; code != http.StatusOK {
+		t.Errorf("expected the queued request to succeed, got %d", code)
+	}
+}
+
+// TestConcurrencyLimit_WaitTimesOut: a queued request is shed once Wait passes
+func TestConcurrencyLimit_WaitTimesOut(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	r := blockingRouter(ConcurrencyConfig{Max: 1, Wait: 10 * time.Millisecond}, started, release)
+
+	go doConcurrent(r, "/work")
+	<-started
+
+	if w := doConcurrent(r, "/work"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 after waiting, got %d", w.Code)
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig controls which browser origins may call the API.
+type CORSConfig struct {
+	// AllowOrigins lists the permitted origins. "*" allows any origin.
+	// An empty list disables CORS.
+	AllowOrigins []string
+	// MaxAge is how long browsers may cache a preflight response. Zero
+	// leaves it to the browser default.
+	MaxAge time.Duration
+	// AllowCredentials lets browsers send cookies and Authorization
+	// headers on cross-origin requests.
+	AllowCredentials bool
+}
+
+const corsAllowMethods = "GET, POST, PUT, DELETE, OPTIONS"
+const corsAllowHeaders = "Authorization, Content-Type, X-API-Key, If-Match, If-None-Match, Prefer"
+
+// corsExposeHeaders lets scripts read ETags for conditional requests and
+// find todos created with Prefer: return=minimal.
+const corsExposeHeaders = "ETag, Location, Preference-Applied"
+
+// Validate rejects combinations browsers refuse to honour.
+func (cfg CORSConfig) Validate() error {
+	if cfg.AllowCredentials && slices.Contains(cfg.AllowOrigins, "*") {
+		return errors.New("CORS credentials cannot be allowed with a wildcard origin; list the origins explicitly")
+	}
+	if cfg.MaxAge < 0 {
+		return errors.New("CORS max age must not be negative")
+	}
+	return nil
+}
+
+// CORSMiddleware adds CORS headers for allowed origins and answers
+// preflight requests with 204. Requests from other origins pass through
+// without CORS headers, so the browser blocks them.
+func CORSMiddleware(cfg CORSConfig) gin.HandlerFunc {
+	wildcard := slices.Contains(cfg.AllowOrigins, "*")
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || !(wildcard || slices.Contains(cfg.AllowOrigins, origin)) {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		if wildcard {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Add("Vary", "Origin")
+		}
+		if cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", corsAllowMethods)
+			h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			if cfg.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge/time.Second)))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		h.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+		c.Next()
+	}
+}
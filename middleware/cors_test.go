@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newCORSRouter(cfg CORSConfig) *gin.Engine {
+	r := gin.New()
+	r.Use(CORSMiddleware(cfg))
+	r.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func doCORS(r *gin.Engine, method, origin string, preflight bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if preflight {
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSMiddleware_AllowedOrigin(t *testing.T) {
+	r := newCORSRouter(CORSConfig{AllowOrigins: []string{"https://app.example.com"}, AllowCredentials: true})
+
+	w := doCORS(r, http.MethodGet, "https://app.example.com", false)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("expected origin to be echoed, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("expected credentials to be allowed, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != "ETag, Location, Preference-Applied" {
+		t.Errorf("expected ETag, Location and Preference-Applied to be exposed, got %q", got)
+	}
+}
+
+func TestCORSMiddleware_DisallowedOrigin(t *testing.T) {
+	r := newCORSRouter(CORSConfig{AllowOrigins: []string{"https://app.example.com"}})
+
+	w := doCORS(r, http.MethodGet, "https://evil.example.com", false)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("expected no CORS headers, got origin %q", got)
+	}
+}
+
+// TestCORSMiddleware_PreflightMaxAge: preflights are answered with 204 and cached for MaxAge
+func TestCORSMiddleware_PreflightMaxAge(t *testing.T) {
+	r := newCORSRouter(CORSConfig{AllowOrigins: []string{"*"}, MaxAge: 10 * time.Minute})
+
+	w := doCORS(r, http.MethodOptions, "https://app.example.com", true)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("expected max age 600, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("expected wildcard origin, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("expected no credentials header, got %q", got)
+	}
+}
+
+func TestCORSConfig_Validate(t *testing.T) {
+	if err := (CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true}).Validate(); err == nil {
+		t.Error("expected credentials with a wildcard origin to be rejected")
+	}
+	if err := (CORSConfig{AllowOrigins: []string{"https://app.example.com"}, AllowCredentials: true}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
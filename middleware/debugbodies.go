@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+)
+
+// DefaultBodyLogLimit is how many bytes of each body DebugBodies logs.
+const DefaultBodyLogLimit = 2048
+
+const redacted = "[REDACTED]"
+
+// secretHeaders are never logged, whatever the request.
+var secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// secretFields are JSON keys whose values are never logged, at any depth.
+var secretFields = []string{"password", "token", "key", "secret"}
+
+// DebugBodies writes the headers and bodies of every request and response
+// to out, for diagnosing client problems in staging. Bodies are cut to
+// maxBytes. Credentials are redacted: secret headers, the values of secret
+// JSON fields, and the whole bodies of secretPaths, such as the endpoints
+// that issue tokens and keys. It is a debugging aid and must stay off in
+// production.
+func DebugBodies(out io.Writer, maxBytes int, secretPaths ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret := slices.Contains(secretPaths, c.Request.URL.Path)
+
+		var reqBody []byte
+		if c.Request.Body != nil {
+			var err error
+			if reqBody, err = io.ReadAll(c.Request.Body); err != nil {
+				apperr.Abort(c, apperr.ErrBadRequest.With("failed to read request body"))
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(reqBody))
+		}
+		w := &bodyLogWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		fmt.Fprintf(out, "[BODY] --> %s %s headers: %s body: %s\n",
+			c.Request.Method, c.Request.URL.RequestURI(),
+			logHeaders(c.Request.Header), logBody(reqBody, secret, maxBytes))
+		fmt.Fprintf(out, "[BODY] <-- %d %s %s headers: %s body: %s\n",
+			w.Status(), c.Request.Method, c.Request.URL.Path,
+			logHeaders(w.Header()), logBody(w.body.Bytes(), secret, maxBytes))
+	}
+}
+
+// bodyLogWriter keeps a copy of the response body as it is written.
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyLogWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyLogWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// logHeaders formats h on one line, sorted, with secret headers redacted.
+func logHeaders(h http.Header) string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.Join(h[name], ", ")
+		if slices.ContainsFunc(secretHeaders, func(s string) bool { return strings.EqualFold(s, name) }) {
+			value = redacted
+		}
+		parts = append(parts, name+": "+value)
+	}
+	return "{" + strings.Join(parts, "; ") + "}"
+}
+
+// logBody returns body with secret JSON fields redacted, cut to maxBytes.
+// Secret bodies are redacted whole.
+func logBody(body []byte, secret bool, maxBytes int) string {
+	switch {
+	case len(body) == 0:
+		return "-"
+	case secret:
+		return redacted
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err == nil {
+		if out, err := json.Marshal(redactFields(v)); err == nil {
+			body = out
+		}
+	}
+	if len(body) > maxBytes {
+		return fmt.Sprintf("%s... (%d bytes)", body[:maxBytes], len(body))
+	}
+	return string(body)
+}
+
+// redactFields replaces the values of secret fields in a decoded JSON value.
+func redactFields(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, inner := range val {
+			if slices.Contains(secretFields, strings.ToLower(k)) {
+				val[k] = redacted
+				continue
+			}
+			val[k] = redactFields(inner)
+		}
+		return val
+	case []any:
+		for i, inner := range val {
+			val[i] = redactFields(inner)
+		}
+		return val
+	default:
+		return v
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func doDebugBodies(t *testing.T, req *http.Request) (string, *httptest.ResponseRecorder) {
+	t.Helper()
+	var log bytes.Buffer
+	r := gin.New()
+	r.Use(DebugBodies(&log, 100, "/tokenz"))
+	echo := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, "application/json", body)
+	}
+	r.POST("/tokenz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"jwt": "secret.jwt.value"})
+	})
+	r.POST("/echo", echo)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return log.String(), w
+}
+
+// TestDebugBodies_LogsBodies: request and response bodies are logged and still reach the handler and client
+func TestDebugBodies_LogsBodies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`{"text":"Buy milk"}`))
+	log, w := doDebugBodies(t, req)
+
+	if w.Body.String() != `{"text":"Buy milk"}` {
+		t.Errorf("expected the body to pass through, got %q", w.Body.String())
+	}
+	if strings.Count(log, `{"text":"Buy milk"}`) != 2 {
+		t.Errorf("expected request and response bodies in the log, got %q", log)
+	}
+}
+
+// TestDebugBodies_Redacts: credentials in headers, JSON fields and secret paths never reach the log
+func TestDebugBodies_Redacts(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/echo",
+		strings.NewReader(`{"username":"alice","password":"hunter2","nested":[{"Token":"tok-123"}]}`))
+	req.Header.Set("Authorization", "Bearer eyJhbGciOi.abc.def")
+	req.Header.Set("X-API-Key", "tdk_abcdef")
+	req.Header.Set("Cookie", "session=s3cr3t")
+	log, _ := doDebugBodies(t, req)
+
+	for _, leak := range []string{"hunter2", "tok-123", "eyJhbGciOi", "tdk_abcdef", "s3cr3t"} {
+		if strings.Contains(log, leak) {
+			t.Errorf("log leaks %q: %s", leak, log)
+		}
+	}
+	if !strings.Contains(log, "alice") || !strings.Contains(log, "Authorization: [REDACTED]") {
+		t.Errorf("expected redacted headers and other fields kept, got %s", log)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/tokenz", strings.NewReader(`{"username":"alice","pass":"hunter2"}`))
+	log, w := doDebugBodies(t, req)
+	if !strings.Contains(w.Body.String(), "secret.jwt.value") {
+		t.Fatalf("expected the token in the response, got %q", w.Body.String())
+	}
+	if strings.Contains(log, "hunter2") || strings.Contains(log, "secret.jwt.value") || strings.Contains(log, "alice") {
+		t.Errorf("expected secret path bodies to be redacted whole, got %s", log)
+	}
+}
+
+// TestDebugBodies_Truncates: long bodies are cut to the limit
+func TestDebugBodies_Truncates(t *testing.T) {
+	long := `"` + strings.Repeat("x", 200) + `"`
+	log, _ := doDebugBodies(t, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(long)))
+
+	if strings.Contains(log, long) || !strings.Contains(log, "... (202 bytes)") {
+		t.Errorf("expected the body cut to 100 bytes, got %s", log)
+	}
+}
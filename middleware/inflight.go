@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InFlight counts the requests being handled. The zero value is ready to
+// use.
+type InFlight struct {
+	n atomic.Int64
+}
+
+// Track counts each request from when it reaches the middleware until the
+// rest of the chain returns.
+func (f *InFlight) Track() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f.n.Add(1)
+		defer f.n.Add(-1)
+		c.Next()
+	}
+}
+
+// Count returns the number of requests being handled right now.
+func (f *InFlight) Count() int64 {
+	return f.n.Load()
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestInFlight_Track: a request is counted while it is handled and released afterwards
+func TestInFlight_Track(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var inFlight InFlight
+	var during int64
+	r := gin.New()
+	r.Use(inFlight.Track())
+	r.GET("/", func(c *gin.Context) {
+		during = inFlight.Count()
+		c.Status(http.StatusNoContent)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if during != 1 {
+		t.Errorf("expected 1 request in flight while handling, got %d", during)
+	}
+	if n := inFlight.Count(); n != 0 {
+		t.Errorf("expected no requests in flight afterwards, got %d", n)
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrettyJSON indents JSON responses for people reading them by hand. It
+// applies to every response when always is set, and otherwise only to
+// requests with ?pretty=true. Other responses pass through untouched.
+func PrettyJSON(always bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if pretty, _ := strconv.ParseBool(c.Query("pretty")); !always && !pretty {
+			c.Next()
+			return
+		}
+
+		w := &prettyWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		w.flush()
+	}
+}
+
+// prettyWriter holds the body back until the handler is done, so it can be
+// indented as a whole.
+type prettyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *prettyWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *prettyWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *prettyWriter) flush() {
+	if w.body.Len() == 0 {
+		return
+	}
+	out := w.body.Bytes()
+	if strings.Contains(w.Header().Get("Content-Type"), "json") {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, out, "", "  "); err == nil {
+			out = indented.Bytes()
+		}
+	}
+	w.ResponseWriter.Write(out)
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func doPretty(always bool, target string) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.Use(PrettyJSON(always))
+	r.GET("/", func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"id": 1, "tags": []string{"a"}})
+	})
+	r.GET("/text", func(c *gin.Context) {
+		c.String(http.StatusOK, "{not json}")
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+const (
+	compactBody  = `{"id":1,"tags":["a"]}`
+	indentedBody = "{\n  \"id\": 1,\n  \"tags\": [\n    \"a\"\n  ]\n}"
+)
+
+// TestPrettyJSON: output is compact by default and indented on request or when always on
+func TestPrettyJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		always bool
+		target string
+		want   string
+	}{
+		{"default", false, "/", compactBody},
+		{"pretty=false", false, "/?pretty=false", compactBody},
+		{"pretty=true", false, "/?pretty=true", indentedBody},
+		{"always", true, "/", indentedBody},
+	}
+	for _, tc := range tests {
+		w := doPretty(tc.always, tc.target)
+		if w.Code != http.StatusCreated {
+			t.Errorf("%s: expected status %d, got %d", tc.name, http.StatusCreated, w.Code)
+		}
+		if got := w.Body.String(); got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+// TestPrettyJSON_NonJSON: other content types are left as written
+func TestPrettyJSON_NonJSON(t *testing.T) {
+	w := doPretty(true, "/text")
+
+	if got := w.Body.String(); got != "{not json}" {
+		t.Errorf("expected the text body unchanged, got %q", got)
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// QueryCountHeader carries the number of database queries a request ran.
+const QueryCountHeader = "X-DB-Queries"
+
+type queryCountKey struct{}
+
+// RegisterQueryCounter adds GORM callbacks that count every statement run
+// with a context prepared by QueryCounter. Queries run without that
+// context are not counted.
+func RegisterQueryCounter(db *gorm.DB) error {
+	count := func(tx *gorm.DB) {
+		if n, ok := tx.Statement.Context.Value(queryCountKey{}).(*atomic.Int64); ok {
+			n.Add(1)
+		}
+	}
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().After("gorm:create").Register("querycount:create", count),
+		cb.Query().After("gorm:query").Register("querycount:query", count),
+		cb.Update().After("gorm:update").Register("querycount:update", count),
+		cb.Delete().After("gorm:delete").Register("querycount:delete", count),
+		cb.Row().After("gorm:row").Register("querycount:row", count),
+		cb.Raw().After("gorm:raw").Register("querycount:raw", count),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueryCounter counts the queries each request runs and reports the total
+// in the X-DB-Queries response header. It is a debugging aid for spotting
+// N+1 queries and needs RegisterQueryCounter on the database.
+func QueryCounter() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		n := new(atomic.Int64)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), queryCountKey{}, n))
+		c.Writer = &queryCountWriter{ResponseWriter: c.Writer, n: n}
+		c.Next()
+	}
+}
+
+// queryCountWriter sets the header just before the response headers are
+// sent, when the handler has finished querying.
+type queryCountWriter struct {
+	gin.ResponseWriter
+	n *atomic.Int64
+}
+
+func (w *queryCountWriter) setHeader() {
+	if !w.Written() {
+		w.Header().Set(QueryCountHeader, strconv.FormatInt(w.n.Load(), 10))
+	}
+}
+
+func (w *queryCountWriter) WriteHeader(code int) {
+	w.setHeader()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *queryCountWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *queryCountWriter) Write(data []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *queryCountWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
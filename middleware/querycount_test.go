@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newQueryCountRouter(t *testing.T, queries int, useContext bool) *gin.Engine {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := RegisterQueryCounter(db); err != nil {
+		t.Fatalf("failed to register query counter: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(QueryCounter())
+	r.GET("/", func(c *gin.Context) {
+		conn := db
+		if useContext {
+			conn = db.WithContext(c.Request.Context())
+		}
+		for range queries {
+			var n int
+			conn.Raw("SELECT 1").Scan(&n)
+		}
+		c.Status(http.StatusNoContent)
+	})
+	return r
+}
+
+func doQueryCount(r *gin.Engine) string {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w.Header().Get(QueryCountHeader)
+}
+
+func TestQueryCounter_CountsRequestQueries(t *testing.T) {
+	r := newQueryCountRouter(t, 3, true)
+
+	if got := doQueryCount(r); got != "3" {
+		t.Errorf("expected 3 queries, got %q", got)
+	}
+	if got := doQueryCount(r); got != "3" {
+		t.Errorf("expected the count to reset per request, got %q", got)
+	}
+}
+
+// TestQueryCounter_IgnoresQueriesWithoutContext: only queries run with the request context count
+func TestQueryCounter_IgnoresQueriesWithoutContext(t *testing.T) {
+	r := newQueryCountRouter(t, 2, false)
+
+	if got := doQueryCount(r); got != "0" {
+		t.Errorf("expected 0 queries, got %q", got)
+	}
+}
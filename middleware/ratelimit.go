@@ -1,10 +1,10 @@
 package middleware
 
 import (
-	"net/http"
 	"sync"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
 	"golang.org/x/time/rate"
 )
 
@@ -26,6 +26,19 @@ func NewIPLimiter(r rate.Limit, burst int) *IPLimiter {
 	}
 }
 
+// Update applies the rate and burst of next, for reloading the
+// configuration without a restart. Clients keep the tokens they have left,
+// refilled at the new rate.
+func (l *IPLimiter) Update(next *IPLimiter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.r, l.burst = next.r, next.burst
+	for _, lim := range l.limiters {
+		lim.SetLimit(l.r)
+		lim.SetBurst(l.burst)
+	}
+}
+
 // get returns (or creates) a limiter for the given IP.
 func (l *IPLimiter) get(ip string) *rate.Limiter {
 	l.mu.Lock()
@@ -41,7 +54,7 @@ func (l *IPLimiter) get(ip string) *rate.Limiter {
 func RateLimitMiddleware(l *IPLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if !l.get(c.ClientIP()).Allow() {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many requests, please try again later"})
+			apperr.Abort(c, apperr.ErrTooManyRequests.With("too many requests, please try again later"))
 			return
 		}
 		c.Next()
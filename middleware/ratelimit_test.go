@@ -125,3 +125,26 @@ func TestRateLimitMiddleware_DifferentIPsAreIndependent(t *testing.T) {
 		t.Fatalf("expected IP B to be allowed (200), got %d", w.Code)
 	}
 }
+
+// TestIPLimiter_Update: a reload lifts the limit for clients already being limited
+func TestIPLimiter_Update(t *testing.T) {
+	limiter := NewIPLimiter(5, 1)
+	r := newTestRouter(limiter)
+	do := func() int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.7:1234"
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	do()
+	if code := do(); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 before the update, got %d", code)
+	}
+
+	limiter.Update(NewIPLimiter(rate.Inf, 0))
+
+	if code := do(); code != http.StatusOK {
+		t.Fatalf("expected 200 after the update, got %d", code)
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"slices"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+)
+
+// Readiness records whether the server has finished starting up. The zero
+// value is not ready; a nil *Readiness is always ready.
+type Readiness struct {
+	ready atomic.Bool
+}
+
+// SetReady marks startup as complete.
+func (r *Readiness) SetReady() {
+	r.ready.Store(true)
+}
+
+// Ready reports whether startup is complete.
+func (r *Readiness) Ready() bool {
+	return r == nil || r.ready.Load()
+}
+
+// Gate answers 503 Service Unavailable until SetReady is called, so
+// requests that arrive while the database is being prepared fail cleanly.
+// Requests for the skip paths pass through and can report the state
+// themselves.
+func (r *Readiness) Gate(skip ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if r.Ready() || slices.Contains(skip, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		c.Header("Retry-After", "1")
+		apperr.Abort(c, apperr.ErrUnavailable.With("server is starting"))
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func doReady(r *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+// TestReadiness_Gate: requests get 503 until SetReady, except for skipped paths
+func TestReadiness_Gate(t *testing.T) {
+	var ready Readiness
+	r := gin.New()
+	r.Use(ready.Gate("/healthz"))
+	r.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	r.GET("/todos", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := doReady(r, "/todos")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 before ready, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("expected Retry-After: 1, got %q", got)
+	}
+	var body struct{ Error, Code string }
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != "unavailable" || body.Error == "" {
+		t.Errorf("expected an apperr body with code unavailable, got %s", w.Body)
+	}
+	if w := doReady(r, "/healthz"); w.Code != http.StatusNoContent {
+		t.Errorf("expected /healthz to pass through, got %d", w.Code)
+	}
+
+	ready.SetReady()
+	if w := doReady(r, "/todos"); w.Code != http.StatusOK {
+		t.Errorf("expected 200 once ready, got %d", w.Code)
+	}
+}
+
+func TestReadiness_NilIsReady(t *testing.T) {
+	var ready *Readiness
+	if !ready.Ready() {
+		t.Error("expected a nil Readiness to be ready")
+	}
+}
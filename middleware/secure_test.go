@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func doSecure(req *http.Request) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.Use(SecureHeaders())
+	r.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestSecureHeaders_Baseline(t *testing.T) {
+	w := doSecure(httptest.NewRequest(http.MethodGet, "/", nil))
+
+	for header, want := range map[string]string{
+		"X-Content-Type-Options": "nosniff",
+		"X-Frame-Options":        "DENY",
+		"Referrer-Policy":        "no-referrer",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s: expected %q, got %q", header, want, got)
+		}
+	}
+	if got := w.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("expected no HSTS over plain HTTP, got %q", got)
+	}
+}
+
+// TestSecureHeaders_HSTSBehindProxy: HSTS is sent when a proxy reports HTTPS
+func TestSecureHeaders_HSTSBehindProxy(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	if got := doSecure(req).Header().Get("Strict-Transport-Security"); got != hstsValue {
+		t.Errorf("expected HSTS %q, got %q", hstsValue, got)
+	}
+}
+
+func TestSecureHeaders_HSTSDirectTLS(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = &tls.ConnectionState{}
+
+	if got := doSecure(req).Header().Get("Strict-Transport-Security"); got != hstsValue {
+		t.Errorf("expected HSTS %q, got %q", hstsValue, got)
+	}
+}
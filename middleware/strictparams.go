@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+)
+
+// globalParams are accepted on every route.
+var globalParams = []string{"pretty", "strict_params"}
+
+// StrictParams returns a constructor for per-route middleware that rejects
+// query parameters the route doesn't know, so typos such as ?complete=true
+// fail loudly instead of being ignored. Checking is on for every request
+// when always is set, and otherwise only for requests with
+// ?strict_params=true.
+func StrictParams(always bool) func(known ...string) gin.HandlerFunc {
+	return func(known ...string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			query := c.Request.URL.Query()
+			if strict, _ := strconv.ParseBool(query.Get("strict_params")); !always && !strict {
+				c.Next()
+				return
+			}
+
+			var unknown []string
+			for key := range query {
+				if !slices.Contains(known, key) && !slices.Contains(globalParams, key) {
+					unknown = append(unknown, key)
+				}
+			}
+			if len(unknown) > 0 {
+				slices.Sort(unknown)
+				apperr.Abort(c, apperr.ErrBadRequest.With("unknown query parameters: "+strings.Join(unknown, ", ")).WithDetail("unknown", unknown))
+				return
+			}
+			c.Next()
+		}
+	}
+}
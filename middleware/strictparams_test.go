@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func doStrict(always bool, target string) *httptest.ResponseRecorder {
+	r := gin.New()
+	strict := StrictParams(always)
+	r.GET("/todos", strict("completed", "page"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestStrictParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		always bool
+		target string
+		want   int
+	}{
+		{"lenient by default", false, "/todos?complete=true", http.StatusOK},
+		{"strict on request", false, "/todos?complete=true&strict_params=true", http.StatusBadRequest},
+		{"strict always", true, "/todos?complete=true", http.StatusBadRequest},
+		{"known keys", true, "/todos?completed=true&page=2", http.StatusOK},
+		{"global keys", true, "/todos?pretty=true&strict_params=false", http.StatusOK},
+	}
+	for _, tc := range tests {
+		if w := doStrict(tc.always, tc.target); w.Code != tc.want {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.want, w.Code)
+		}
+	}
+}
+
+// TestStrictParams_ListsUnknown: the 400 names every unknown key, sorted
+func TestStrictParams_ListsUnknown(t *testing.T) {
+	w := doStrict(true, "/todos?sort=id&complete=true&page=1")
+
+	var resp struct {
+		Error   string   `json:"error"`
+		Unknown []string `json:"unknown"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if !slices.Equal(resp.Unknown, []string{"complete", "sort"}) {
+		t.Errorf("expected [complete sort], got %v", resp.Unknown)
+	}
+	if resp.Error != "unknown query parameters: complete, sort" {
+		t.Errorf("unexpected error message %q", resp.Error)
+	}
+}
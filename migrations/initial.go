@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// The tables of 0001_initial, frozen as they were, so later changes to
+// the models don't change what this migration creates.
+
+type initialTodo struct {
+	Title        string
+	DueDate      *time.Time
+	Completed    bool
+	CompletedAt  *time.Time
+	Priority     string
+	UserID       uint   `gorm:"index"`
+	Status       string `gorm:"not null;default:open"`
+	DeleteReason string
+	gorm.Model
+}
+
+func (initialTodo) TableName(namer schema.Namer) string { return namer.TableName("Todo") }
+
+type initialUser struct {
+	gorm.Model
+	Username string `gorm:"uniqueIndex;not null"`
+	Password string `gorm:"not null"`
+	Role     string `gorm:"not null;default:user"`
+}
+
+func (initialUser) TableName(namer schema.Namer) string { return namer.TableName("User") }
+
+type initialAPIKey struct {
+	gorm.Model
+	UserID uint   `gorm:"index;not null"`
+	Name   string `gorm:"not null"`
+	Prefix string `gorm:"not null"`
+	Hash   string `gorm:"uniqueIndex;not null"`
+}
+
+func (initialAPIKey) TableName(namer schema.Namer) string { return namer.TableName("APIKey") }
+
+type initialAuditLog struct {
+	ID        uint            `gorm:"primarykey"`
+	Action    string          `gorm:"not null"`
+	TodoID    uint            `gorm:"index"`
+	UserID    uint            `gorm:"index"`
+	Changes   json.RawMessage `gorm:"type:text"`
+	CreatedAt time.Time
+}
+
+func (initialAuditLog) TableName(namer schema.Namer) string { return namer.TableName("AuditLog") }
+
+var initialSchema = []any{&initialTodo{}, &initialUser{}, &initialAPIKey{}, &initialAuditLog{}}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// metadataTodo is the column 0003_todo_metadata adds to todos.
+type metadataTodo struct {
+	Metadata datatypes.JSON
+}
+
+func (metadataTodo) TableName(namer schema.Namer) string { return namer.TableName("Todo") }
+
+func addTodoMetadata(tx *gorm.DB) error {
+	if tx.Migrator().HasColumn(&metadataTodo{}, "Metadata") {
+		return nil
+	}
+	return tx.Migrator().AddColumn(&metadataTodo{}, "Metadata")
+}
+
+func dropTodoMetadata(tx *gorm.DB) error {
+	return tx.Migrator().DropColumn(&metadataTodo{}, "Metadata")
+}
@@ -0,0 +1,93 @@
+// Package migrations evolves the database schema through numbered, ordered
+// migrations. Each one runs once and is recorded in the schema_migrations
+// table, so data changes and removals happen exactly when intended instead
+// of being guessed by AutoMigrate.
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"github.com/pradist/todoapi/todo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// TableName records the applied migrations, after the naming strategy's
+// table prefix.
+const TableName = "schema_migrations"
+
+// all lists every migration in the order it runs. Append new migrations
+// with the next number; never edit or reorder one that has shipped.
+var all = []*gormigrate.Migration{
+	{
+		// The schema as AutoMigrate left it before migrations existed.
+		// On such databases it changes nothing and is only recorded.
+		ID:       "0001_initial",
+		Migrate:  func(tx *gorm.DB) error { return tx.AutoMigrate(initialSchema...) },
+		Rollback: func(tx *gorm.DB) error { return tx.Migrator().DropTable(reversed(initialSchema)...) },
+	},
+	{
+		// Todos completed before Status existed become done. There is no
+		// way back: open and done can't be told apart once set.
+		ID:      "0002_backfill_status",
+		Migrate: todo.MigrateStatus,
+	},
+	{
+		ID:       "0003_todo_metadata",
+		Migrate:  addTodoMetadata,
+		Rollback: dropTodoMetadata,
+	},
+	{
+		ID:       "0004_todo_time_tracking",
+		Migrate:  addTodoTimer,
+		Rollback: dropTodoTimer,
+	},
+	{
+		ID:       "0005_user_settings",
+		Migrate:  createUserSettings,
+		Rollback: dropUserSettings,
+	},
+	{
+		ID:       "0006_todo_templates",
+		Migrate:  createTodoTemplates,
+		Rollback: dropTodoTemplates,
+	},
+}
+
+// Run applies every pending migration in order, all in one transaction.
+// Migrations already recorded are skipped, so it is safe on every start.
+func Run(db *gorm.DB) error {
+	return newMigrator(db, all).Migrate()
+}
+
+// Rollback undoes the most recently applied migration. Migrations without
+// a Rollback can't be undone and return gormigrate.ErrRollbackImpossible.
+func Rollback(db *gorm.DB) error {
+	return newMigrator(db, all).RollbackLast()
+}
+
+func newMigrator(db *gorm.DB, migrations []*gormigrate.Migration) *gormigrate.Gormigrate {
+	return gormigrate.New(db, &gormigrate.Options{
+		TableName:                 tableName(db),
+		IDColumnName:              "id",
+		IDColumnSize:              255,
+		UseTransaction:            true,
+		ValidateUnknownMigrations: true,
+	}, migrations)
+}
+
+// tableName applies the table prefix of db's naming strategy, so databases
+// shared through DB_TABLE_PREFIX keep their migrations apart.
+func tableName(db *gorm.DB) string {
+	if ns, ok := db.NamingStrategy.(schema.NamingStrategy); ok {
+		return ns.TablePrefix + TableName
+	}
+	return TableName
+}
+
+func reversed(models []any) []any {
+	out := make([]any, len(models))
+	for i, m := range models {
+		out[len(models)-1-i] = m
+	}
+	return out
+}
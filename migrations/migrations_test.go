@@ -0,0 +1,169 @@
+package migrations
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"github.com/pradist/todoapi/audit"
+	"github.com/pradist/todoapi/auth"
+	"github.com/pradist/todoapi/settings"
+	"github.com/pradist/todoapi/todo"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func openTestDB(t *testing.T, naming schema.NamingStrategy) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{NamingStrategy: naming})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
+
+func applied(t *testing.T, db *gorm.DB, table string) []string {
+	t.Helper()
+	var ids []string
+	if err := db.Table(table).Order("id").Pluck("id", &ids).Error; err != nil {
+		t.Fatalf("failed to read %s: %v", table, err)
+	}
+	return ids
+}
+
+var models = []any{&todo.Todo{}, &auth.User{}, &auth.APIKey{}, &audit.Log{}, &settings.Settings{}, &todo.Template{}}
+
+// TestRun_Idempotent: every migration is applied once and recorded; running again changes nothing
+func TestRun_Idempotent(t *testing.T) {
+	db := openTestDB(t, schema.NamingStrategy{})
+
+	for i := range 2 {
+		if err := Run(db); err != nil {
+			t.Fatalf("run %d: %v", i+1, err)
+		}
+	}
+
+	want := []string{"0001_initial", "0002_backfill_status", "0003_todo_metadata", "0004_todo_time_tracking", "0005_user_settings", "0006_todo_templates"}
+	if got := applied(t, db, "schema_migrations"); !slices.Equal(got, want) {
+		t.Errorf("expected %v applied, got %v", want, got)
+	}
+	for _, model := range models {
+		if !db.Migrator().HasTable(model) {
+			t.Errorf("expected a table for %T", model)
+		}
+	}
+}
+
+// TestRun_ExistingSchema: a database created by AutoMigrate before migrations existed is adopted and backfilled
+func TestRun_ExistingSchema(t *testing.T) {
+	db := openTestDB(t, schema.NamingStrategy{})
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("failed to auto-migrate: %v", err)
+	}
+	db.Create(&todo.Todo{Title: "Old", Completed: true, UserID: 1})
+
+	if err := Run(db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got todo.Todo
+	db.First(&got)
+	if got.Status != todo.StatusDone {
+		t.Errorf("expected the completed todo to be backfilled as done, got %q", got.Status)
+	}
+}
+
+// TestRun_MatchesModels: the migrated tables have the columns the models expect
+func TestRun_MatchesModels(t *testing.T) {
+	migrated := openTestDB(t, schema.NamingStrategy{})
+	if err := Run(migrated); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	auto := openTestDB(t, schema.NamingStrategy{})
+	if err := auto.AutoMigrate(models...); err != nil {
+		t.Fatalf("failed to auto-migrate: %v", err)
+	}
+
+	for _, model := range models {
+		if got, want := columns(t, migrated, model), columns(t, auto, model); !slices.Equal(got, want) {
+			t.Errorf("%T: migrations give columns %v, the model %v", model, got, want)
+		}
+	}
+}
+
+func columns(t *testing.T, db *gorm.DB, model any) []string {
+	t.Helper()
+	types, err := db.Migrator().ColumnTypes(model)
+	if err != nil {
+		t.Fatalf("failed to read columns of %T: %v", model, err)
+	}
+	var names []string
+	for _, ct := range types {
+		typ, _ := ct.ColumnType()
+		names = append(names, ct.Name()+" "+typ)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// TestRun_TablePrefix: the migrations table takes the table prefix too
+func TestRun_TablePrefix(t *testing.T) {
+	db := openTestDB(t, schema.NamingStrategy{TablePrefix: "app_"})
+	if err := Run(db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !db.Migrator().HasTable("app_schema_migrations") || !db.Migrator().HasTable("app_todos") {
+		t.Error("expected prefixed tables")
+	}
+}
+
+// TestRollback: migrations are undone newest first and can be applied again; the backfill can't be undone
+func TestRollback(t *testing.T) {
+	db := openTestDB(t, schema.NamingStrategy{})
+	if err := Run(db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := Rollback(db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.Migrator().HasTable(&todo.Template{}) {
+		t.Error("expected the templates table to be dropped")
+	}
+	if err := Rollback(db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.Migrator().HasTable(&settings.Settings{}) {
+		t.Error("expected the settings table to be dropped")
+	}
+	if err := Rollback(db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.Migrator().HasColumn(&todo.Todo{}, "ActualMinutes") {
+		t.Error("expected the time tracking columns to be dropped")
+	}
+	if err := Rollback(db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.Migrator().HasColumn(&todo.Todo{}, "Metadata") {
+		t.Error("expected the metadata column to be dropped")
+	}
+	if err := Rollback(db); !errors.Is(err, gormigrate.ErrRollbackImpossible) {
+		t.Fatalf("expected the backfill to be irreversible, got %v", err)
+	}
+
+	initial := newMigrator(db, all[:1])
+	if err := initial.RollbackLast(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.Migrator().HasTable(&todo.Todo{}) || slices.Contains(applied(t, db, "schema_migrations"), "0001_initial") {
+		t.Error("expected the initial migration to be undone")
+	}
+	if err := Run(db); err != nil {
+		t.Fatalf("re-run failed: %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// userSettings is the table 0005_user_settings creates.
+type userSettings struct {
+	UserID    uint           `gorm:"primaryKey;autoIncrement:false"`
+	Data      datatypes.JSON `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+func (userSettings) TableName(namer schema.Namer) string { return namer.TableName("UserSettings") }
+
+func createUserSettings(tx *gorm.DB) error {
+	if tx.Migrator().HasTable(&userSettings{}) {
+		return nil
+	}
+	return tx.Migrator().CreateTable(&userSettings{})
+}
+
+func dropUserSettings(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&userSettings{})
+}
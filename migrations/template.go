@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// todoTemplate is the table 0006_todo_templates creates.
+type todoTemplate struct {
+	gorm.Model
+	UserID           uint `gorm:"index"`
+	Title            string
+	Priority         string
+	EstimatedMinutes int
+	Metadata         datatypes.JSON
+}
+
+func (todoTemplate) TableName(namer schema.Namer) string { return namer.TableName("Template") }
+
+func createTodoTemplates(tx *gorm.DB) error {
+	if tx.Migrator().HasTable(&todoTemplate{}) {
+		return nil
+	}
+	return tx.Migrator().CreateTable(&todoTemplate{})
+}
+
+func dropTodoTemplates(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(&todoTemplate{})
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// timerTodo holds the columns 0004_todo_time_tracking adds to todos.
+type timerTodo struct {
+	EstimatedMinutes int
+	ActualMinutes    int
+	TimerStartedAt   *time.Time
+}
+
+func (timerTodo) TableName(namer schema.Namer) string { return namer.TableName("Todo") }
+
+var timerColumns = []string{"EstimatedMinutes", "ActualMinutes", "TimerStartedAt"}
+
+func addTodoTimer(tx *gorm.DB) error {
+	for _, column := range timerColumns {
+		if tx.Migrator().HasColumn(&timerTodo{}, column) {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(&timerTodo{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dropTodoTimer(tx *gorm.DB) error {
+	for _, column := range timerColumns {
+		if err := tx.Migrator().DropColumn(&timerTodo{}, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
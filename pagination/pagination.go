@@ -0,0 +1,144 @@
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+	// DefaultMaxOffset is the deepest row a page may start at. SQLite
+	// reads and discards every row before the offset, so deeper pages get
+	// slower and slower.
+	DefaultMaxOffset = 10000
+)
+
+// Page is a 1-based page number and page size read from the query string.
+type Page struct {
+	Number int
+	Limit  int
+}
+
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.Limit
+}
+
+// Limits bounds page sizes and depth. Zero fields fall back to
+// DefaultLimit, MaxLimit and DefaultMaxOffset, so the zero value is the
+// default configuration.
+type Limits struct {
+	Default int
+	Max     int
+	// MaxOffset is the largest offset a page may start at.
+	MaxOffset int
+}
+
+// withDefaults fills unset sizes, keeping the default within the max.
+func (l Limits) withDefaults() Limits {
+	if l.Max == 0 {
+		l.Max = max(MaxLimit, l.Default)
+	}
+	if l.Default == 0 {
+		l.Default = min(DefaultLimit, l.Max)
+	}
+	if l.MaxOffset == 0 {
+		l.MaxOffset = DefaultMaxOffset
+	}
+	return l
+}
+
+// WithDefault returns l with the default page size n, clamped to the max.
+func (l Limits) WithDefault(n int) Limits {
+	l = l.withDefaults()
+	l.Default = min(n, l.Max)
+	return l
+}
+
+// Validate rejects non-positive sizes and a default larger than the max.
+func (l Limits) Validate() error {
+	if l.Default < 0 || l.Max < 0 {
+		return errors.New("page sizes must be positive")
+	}
+	if l.MaxOffset < 0 {
+		return errors.New("max offset must be positive")
+	}
+	l = l.withDefaults()
+	if l.Default > l.Max {
+		return fmt.Errorf("default page size %d exceeds max page size %d", l.Default, l.Max)
+	}
+	return nil
+}
+
+// FromQuery reads ?page= and ?limit=. Missing values fall back to page 1 and
+// the default size; limits above the max are clamped, however large. Pages
+// starting beyond MaxOffset are rejected rather than scanned.
+func (l Limits) FromQuery(c *gin.Context) (Page, error) {
+	l = l.withDefaults()
+	p := Page{Number: 1, Limit: l.Default}
+	if v := c.Query("page"); v != "" {
+		n, ok := positiveInt(v)
+		if !ok {
+			return Page{}, errors.New("page must be a positive integer")
+		}
+		p.Number = n
+	}
+	if v := c.Query("limit"); v != "" {
+		n, ok := positiveInt(v)
+		if !ok {
+			return Page{}, errors.New("limit must be a positive integer")
+		}
+		p.Limit = min(n, l.Max)
+	}
+	if err := l.checkDepth(p); err != nil {
+		return Page{}, err
+	}
+	return p, nil
+}
+
+// Page returns page number of size limit, for callers that don't read the
+// query string. Zero values fall back to page 1 and the default size; the
+// size is clamped and the depth checked as by FromQuery.
+func (l Limits) Page(number, limit int) (Page, error) {
+	l = l.withDefaults()
+	if number < 0 || limit < 0 {
+		return Page{}, errors.New("page and limit must be positive integers")
+	}
+	p := Page{Number: max(number, 1), Limit: l.Default}
+	if limit > 0 {
+		p.Limit = min(limit, l.Max)
+	}
+	if err := l.checkDepth(p); err != nil {
+		return Page{}, err
+	}
+	return p, nil
+}
+
+// checkDepth rejects pages starting beyond MaxOffset rather than scanning
+// to them.
+func (l Limits) checkDepth(p Page) error {
+	if p.Number-1 > l.MaxOffset/p.Limit {
+		return fmt.Errorf("page %d is too deep: pages may start at most %d items in; narrow the results with filters instead", p.Number, l.MaxOffset)
+	}
+	return nil
+}
+
+// positiveInt parses a positive decimal integer. Numbers too large for an
+// int are math.MaxInt, so they are clamped like any other large value
+// instead of failing to parse.
+func positiveInt(v string) (int, bool) {
+	n, err := strconv.Atoi(v)
+	if errors.Is(err, strconv.ErrRange) && n > 0 {
+		return math.MaxInt, true
+	}
+	return n, err == nil && n > 0
+}
+
+// FromQuery reads the page with the default Limits.
+func FromQuery(c *gin.Context) (Page, error) {
+	return Limits{}.FromQuery(c)
+}
@@ -0,0 +1,163 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func contextWithQuery(query string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/"+query, nil)
+	return c
+}
+
+func TestFromQuery_Defaults(t *testing.T) {
+	p, err := FromQuery(contextWithQuery(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Number != 1 || p.Limit != DefaultLimit || p.Offset() != 0 {
+		t.Errorf("expected page 1 of %d, got %+v", DefaultLimit, p)
+	}
+}
+
+func TestFromQuery_Values(t *testing.T) {
+	p, err := FromQuery(contextWithQuery("?page=3&limit=10"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Number != 3 || p.Limit != 10 || p.Offset() != 20 {
+		t.Errorf("expected page 3 of 10 at offset 20, got %+v (offset %d)", p, p.Offset())
+	}
+}
+
+// TestFromQuery_ClampsLimit: limits above MaxLimit are clamped rather than rejected
+func TestFromQuery_ClampsLimit(t *testing.T) {
+	p, err := FromQuery(contextWithQuery("?limit=5000"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Limit != MaxLimit {
+		t.Errorf("expected limit %d, got %d", MaxLimit, p.Limit)
+	}
+}
+
+// TestFromQuery_HugeLimit: a limit too large for an int is clamped like any other
+func TestFromQuery_HugeLimit(t *testing.T) {
+	p, err := FromQuery(contextWithQuery("?limit=999999999999999999999"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Limit != MaxLimit {
+		t.Errorf("expected limit %d, got %d", MaxLimit, p.Limit)
+	}
+}
+
+func TestFromQuery_Invalid(t *testing.T) {
+	for _, query := range []string{"?page=0", "?page=abc", "?limit=0", "?limit=-5", "?limit=-999999999999999999999", "?limit=1e3", "?page=999999999999999999999"} {
+		if _, err := FromQuery(contextWithQuery(query)); err == nil {
+			t.Errorf("%s: expected error", query)
+		}
+	}
+}
+
+// TestFromQuery_TooDeep: pages starting past DefaultMaxOffset are rejected
+func TestFromQuery_TooDeep(t *testing.T) {
+	if _, err := FromQuery(contextWithQuery("?page=501&limit=20")); err != nil {
+		t.Errorf("expected the page at offset %d to be allowed, got %v", DefaultMaxOffset, err)
+	}
+	for _, query := range []string{"?page=502&limit=20", "?page=1000000", "?page=9223372036854775807"} {
+		if _, err := FromQuery(contextWithQuery(query)); err == nil {
+			t.Errorf("%s: expected error", query)
+		}
+	}
+}
+
+// TestLimits_MaxOffset: a configured MaxOffset replaces the default
+func TestLimits_MaxOffset(t *testing.T) {
+	l := Limits{MaxOffset: 50}
+	if _, err := l.FromQuery(contextWithQuery("?page=6&limit=10")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := l.FromQuery(contextWithQuery("?page=7&limit=10")); err == nil {
+		t.Error("expected a page starting at offset 60 to be rejected")
+	}
+	if err := (Limits{MaxOffset: -1}).Validate(); err == nil {
+		t.Error("expected a negative MaxOffset to be rejected")
+	}
+}
+
+// TestLimits_Page: pages built from numbers get the same defaults, clamping and depth checks as the query
+func TestLimits_Page(t *testing.T) {
+	l := Limits{Default: 5, Max: 10, MaxOffset: 50}
+
+	if p, err := l.Page(0, 0); err != nil || p != (Page{Number: 1, Limit: 5}) {
+		t.Errorf("expected page 1 of 5, got %+v (%v)", p, err)
+	}
+	if p, err := l.Page(3, 50); err != nil || p != (Page{Number: 3, Limit: 10}) {
+		t.Errorf("expected page 3 clamped to 10, got %+v (%v)", p, err)
+	}
+	for _, pair := range [][2]int{{-1, 0}, {0, -1}, {7, 10}} {
+		if _, err := l.Page(pair[0], pair[1]); err == nil {
+			t.Errorf("page %d, limit %d: expected error", pair[0], pair[1])
+		}
+	}
+}
+
+// TestLimits_ClampsToConfiguredMax: configured sizes replace the package defaults
+func TestLimits_ClampsToConfiguredMax(t *testing.T) {
+	l := Limits{Default: 5, Max: 10}
+
+	p, err := l.FromQuery(contextWithQuery(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Limit != 5 {
+		t.Errorf("expected default limit 5, got %d", p.Limit)
+	}
+
+	p, err = l.FromQuery(contextWithQuery("?limit=50"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Limit != 10 {
+		t.Errorf("expected limit clamped to 10, got %d", p.Limit)
+	}
+}
+
+// TestLimits_PartialConfig: an unset size never ends up on the wrong side of the other
+func TestLimits_PartialConfig(t *testing.T) {
+	if p, _ := (Limits{Max: 10}).FromQuery(contextWithQuery("")); p.Limit != 10 {
+		t.Errorf("expected default limit lowered to the max of 10, got %d", p.Limit)
+	}
+	if p, _ := (Limits{Default: 500}).FromQuery(contextWithQuery("?limit=400")); p.Limit != 400 {
+		t.Errorf("expected max raised to the default of 500, got limit %d", p.Limit)
+	}
+}
+
+func TestLimits_Validate(t *testing.T) {
+	if err := (Limits{Default: 50, Max: 10}).Validate(); err == nil {
+		t.Error("expected a default above the max to be rejected")
+	}
+	if err := (Limits{Default: 10, Max: 10}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (Limits{}).Validate(); err != nil {
+		t.Errorf("unexpected error for zero Limits: %v", err)
+	}
+}
+
+// TestLimits_WithDefault: a new default page size is kept within the max
+func TestLimits_WithDefault(t *testing.T) {
+	l := Limits{Max: 50}
+
+	if got := l.WithDefault(30).Default; got != 30 {
+		t.Errorf("expected default 30, got %d", got)
+	}
+	if got := l.WithDefault(500).Default; got != 50 {
+		t.Errorf("expected default clamped to 50, got %d", got)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pradist/todoapi/todo"
+	"gorm.io/gorm"
+)
+
+// maxPurgeInterval is the longest the trash purger sleeps between runs.
+const maxPurgeInterval = time.Hour
+
+// purgeTrash runs purgeExpired straight away, then every interval until
+// ctx is done.
+func purgeTrash(ctx context.Context, db *gorm.DB, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purgeExpired(ctx, db, retention)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeExpired permanently deletes todos that have been in the trash longer
+// than retention. Failures are reported; the next run tries again.
+func purgeExpired(ctx context.Context, db *gorm.DB, retention time.Duration) {
+	n, err := todo.Purge(db.WithContext(ctx), time.Now().Add(-retention))
+	switch {
+	case err != nil && ctx.Err() == nil:
+		fmt.Printf("trash purge failed: %s\n", err)
+	case n > 0:
+		fmt.Printf("purged %d todos from the trash\n", n)
+	}
+}
+
+// startTrashPurger registers a shutdown hook for the trash purger and
+// returns the function that starts it, to be called once the database is
+// ready. Without a retention the purger never runs.
+func startTrashPurger(db *gorm.DB, retention time.Duration, lc *lifecycle) func() {
+	if retention <= 0 {
+		return func() {}
+	}
+	return lc.job("trash purger", func(ctx context.Context) {
+		purgeTrash(ctx, db, retention, min(retention, maxPurgeInterval))
+	})
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pradist/todoapi/todo"
+)
+
+// TestPurgeExpired_RemovesExpired: only todos deleted before the retention are removed
+func TestPurgeExpired_RemovesExpired(t *testing.T) {
+	db := setupTestDB(t)
+	old := todo.Todo{Title: "deleted long ago"}
+	recent := todo.Todo{Title: "deleted just now"}
+	db.Create(&[]*todo.Todo{&old, &recent, {Title: "active"}})
+	db.Delete(&recent)
+	db.Delete(&old)
+	db.Unscoped().Model(&old).Update("deleted_at", time.Now().Add(-48*time.Hour))
+
+	purgeExpired(context.Background(), db, 24*time.Hour)
+
+	var ids []uint
+	db.Unscoped().Model(&todo.Todo{}).Order("id").Pluck("id", &ids)
+	if len(ids) != 2 || ids[0] != recent.ID {
+		t.Errorf("expected only the old todo to be purged, got ids %v", ids)
+	}
+}
+
+// TestStartTrashPurger_StopsOnShutdown: the shutdown hook waits for the purger to stop
+func TestStartTrashPurger_StopsOnShutdown(t *testing.T) {
+	var lc lifecycle
+	start := startTrashPurger(setupTestDB(t), time.Hour, &lc)
+	start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := lc.shutdown(ctx); err != nil {
+		t.Fatalf("expected the purger to stop, got %v", err)
+	}
+}
+
+// TestStartTrashPurger_Disabled: without a retention nothing is registered
+func TestStartTrashPurger_Disabled(t *testing.T) {
+	var lc lifecycle
+	startTrashPurger(setupTestDB(t), 0, &lc)()
+	if len(lc.hooks) != 0 {
+		t.Errorf("expected no shutdown hook, got %d", len(lc.hooks))
+	}
+}
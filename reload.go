@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/joho/godotenv"
+	"github.com/pradist/todoapi/middleware"
+)
+
+// reloadableEnv are the variables a SIGHUP applies without a restart.
+var reloadableEnv = []string{"RATE_LIMIT", "RATE_BURST"}
+
+// environNames returns the names of the variables set in the process
+// environment. Taken before .env is loaded, it is the set of variables
+// .env can't override, on startup or on reload.
+func environNames() map[string]bool {
+	names := map[string]bool{}
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		names[name] = true
+	}
+	return names
+}
+
+// reloadEnvFile reads envFile again and sets the reloadable variables it
+// holds. It returns the other variables whose values changed, which only
+// take effect after a restart. Variables removed from the file keep their
+// current values. As on startup, variables in environ, those set in the
+// process environment, win over the file and are skipped.
+func reloadEnvFile(envFile string, environ map[string]bool) ([]string, error) {
+	values, err := godotenv.Read(envFile)
+	if err != nil {
+		return nil, err
+	}
+	var restart []string
+	for name, value := range values {
+		if environ[name] {
+			continue
+		}
+		if current, ok := os.LookupEnv(name); ok && current == value {
+			continue
+		}
+		if !slices.Contains(reloadableEnv, name) {
+			restart = append(restart, name)
+			continue
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return nil, err
+		}
+	}
+	slices.Sort(restart)
+	return restart, nil
+}
+
+// reload applies envFile, less the variables in environ, to the running
+// server: the rate limiter is rebuilt from the environment and swapped in.
+// Failures are reported and leave the configuration as it was.
+func reload(envFile string, environ map[string]bool, limiter *middleware.IPLimiter) {
+	restart, err := reloadEnvFile(envFile, environ)
+	if err != nil {
+		fmt.Printf("configuration reload failed: %s\n", err)
+		return
+	}
+	limiter.Update(ipLimiterFromEnv())
+	for _, name := range restart {
+		fmt.Printf("configuration reload: %s changed; restart to apply it\n", name)
+	}
+	fmt.Println("configuration reloaded")
+}
+
+// startReloader reloads the configuration from envFile on every SIGHUP
+// until shutdown, as reload does. It does nothing if SIGHUP is a shutdown
+// signal.
+func startReloader(envFile string, environ map[string]bool, limiter *middleware.IPLimiter, shutdownSignals []os.Signal, lc *lifecycle) {
+	if slices.Contains(shutdownSignals, os.Signal(syscall.SIGHUP)) {
+		return
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	done := make(chan struct{})
+	lc.onShutdown("config reloader", func(context.Context) error {
+		signal.Stop(hup)
+		close(done)
+		return nil
+	})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-hup:
+				reload(envFile, environ, limiter)
+			}
+		}
+	}()
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/middleware"
+)
+
+func writeEnvFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+	return path
+}
+
+// limitedRouter serves GET / behind limiter and returns a function that
+// sends one request from a fixed client.
+func limitedRouter(limiter *middleware.IPLimiter) func() int {
+	r := gin.New()
+	r.GET("/", middleware.RateLimitMiddleware(limiter), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return func() int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+}
+
+// TestReloadEnvFile: reloadable variables are set, others are only reported
+func TestReloadEnvFile(t *testing.T) {
+	t.Setenv("RATE_LIMIT", "5")
+	t.Setenv("PORT", "8080")
+	t.Setenv("SIGN", "secret")
+	t.Setenv("TZ", "Asia/Bangkok")
+	path := writeEnvFile(t, "RATE_LIMIT=0\nPORT=9090\nSIGN=secret\nTZ=UTC\n")
+
+	restart, err := reloadEnvFile(path, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(restart, []string{"PORT", "TZ"}) {
+		t.Errorf("expected PORT and TZ to need a restart, got %v", restart)
+	}
+	if v := os.Getenv("RATE_LIMIT"); v != "0" {
+		t.Errorf("expected RATE_LIMIT reloaded to 0, got %q", v)
+	}
+	if v := os.Getenv("PORT"); v != "8080" {
+		t.Errorf("expected PORT left at 8080, got %q", v)
+	}
+
+	if _, err := reloadEnvFile(filepath.Join(t.TempDir(), "missing"), nil); err == nil {
+		t.Error("expected an error for a missing env file")
+	}
+}
+
+// TestReloadEnvFile_EnvironmentWins: variables set in the process environment beat .env on reload, as on startup
+func TestReloadEnvFile_EnvironmentWins(t *testing.T) {
+	t.Setenv("RATE_LIMIT", "100")
+	t.Setenv("PORT", "8080")
+	environ := environNames()
+	// RATE_BURST was loaded from .env at startup, so a reload may change it.
+	t.Setenv("RATE_BURST", "5")
+	delete(environ, "RATE_BURST")
+	path := writeEnvFile(t, "RATE_LIMIT=10\nRATE_BURST=2\nPORT=9090\n")
+
+	restart, err := reloadEnvFile(path, environ)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(restart) != 0 {
+		t.Errorf("expected no restart for variables the environment sets, got %v", restart)
+	}
+	if v := os.Getenv("RATE_LIMIT"); v != "100" {
+		t.Errorf("expected RATE_LIMIT to keep the environment's 100, got %q", v)
+	}
+	if v := os.Getenv("RATE_BURST"); v != "2" {
+		t.Errorf("expected RATE_BURST from .env to be reloaded, got %q", v)
+	}
+}
+
+// TestReload_RateLimit: reloading swaps in the new rate limit without a restart
+func TestReload_RateLimit(t *testing.T) {
+	t.Setenv("RATE_LIMIT", "1")
+	t.Setenv("RATE_BURST", "1")
+	limiter := ipLimiterFromEnv()
+	do := limitedRouter(limiter)
+	do()
+	if code := do(); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 before the reload, got %d", code)
+	}
+
+	reload(writeEnvFile(t, "RATE_LIMIT=0\n"), nil, limiter)
+
+	if code := do(); code != http.StatusOK {
+		t.Fatalf("expected 200 after the reload, got %d", code)
+	}
+}
+
+// TestStartReloader_SIGHUP: a SIGHUP triggers the reload; shutdown stops listening for it
+func TestStartReloader_SIGHUP(t *testing.T) {
+	t.Setenv("RATE_LIMIT", "1")
+	t.Setenv("RATE_BURST", "1")
+	limiter := ipLimiterFromEnv()
+	do := limitedRouter(limiter)
+	do()
+	var lc lifecycle
+	startReloader(writeEnvFile(t, "RATE_LIMIT=0\n"), nil, limiter, defaultShutdownSignals, &lc)
+	defer lc.shutdown(context.Background())
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("failed to send SIGHUP: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for do() != http.StatusOK {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the rate limit to be lifted after SIGHUP")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestStartReloader_HUPShutsDown: with SIGHUP as a shutdown signal, no reloader is started
+func TestStartReloader_HUPShutsDown(t *testing.T) {
+	var lc lifecycle
+	startReloader(".env", nil, ipLimiterFromEnv(), []os.Signal{syscall.SIGTERM, syscall.SIGHUP}, &lc)
+	if len(lc.hooks) != 0 {
+		t.Errorf("expected no reloader, got %d shutdown hooks", len(lc.hooks))
+	}
+}
@@ -0,0 +1,120 @@
+// Package response shapes the successful JSON responses of every handler
+// the same way: with the configured Options, bodies are wrapped in an
+// envelope and ids are written as strings. Errors are left to apperr and
+// are never shaped.
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/pagination"
+)
+
+const (
+	jsonMediaType = "application/json; charset=utf-8"
+	pageKey       = "response.page"
+)
+
+// Options is how successful JSON responses are shaped. The zero value
+// writes bodies as they are.
+type Options struct {
+	// Envelope wraps bodies as {"data": ..., "meta": ...}.
+	Envelope bool
+	// IDsAsStrings writes "ID" and "id" numbers as JSON strings, which
+	// JavaScript clients can hold without losing precision.
+	IDsAsStrings bool
+}
+
+// JSON writes body with status, shaped as o says.
+func (o Options) JSON(c *gin.Context, status int, body any) {
+	data := body
+	if o.Envelope {
+		body = Envelope(c, body, data)
+	}
+	if o.IDsAsStrings {
+		var err error
+		if body, err = StringifyIDs(body); err != nil {
+			apperr.Write(c, err)
+			return
+		}
+	}
+	out, err := json.Marshal(body)
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	c.Data(status, jsonMediaType, out)
+}
+
+// SetPage stores the page a list handler served, for the envelope's meta.
+func SetPage(c *gin.Context, p pagination.Page) {
+	c.Set(pageKey, p)
+}
+
+// Envelope wraps body, the JSON form of data, as {"data": ..., "meta": ...}.
+// The meta block has the page stored by SetPage with the number of items
+// on it, and the X-Request-Id the request came with, when there is one.
+func Envelope(c *gin.Context, body, data any) gin.H {
+	meta := gin.H{}
+	if p, ok := c.Value(pageKey).(pagination.Page); ok {
+		meta["page"], meta["limit"] = p.Number, p.Limit
+		if v := reflect.ValueOf(data); v.Kind() == reflect.Slice {
+			meta["count"] = v.Len()
+		}
+	}
+	if id := c.GetHeader("X-Request-Id"); id != "" {
+		meta["request_id"] = id
+	}
+	return gin.H{"data": body, "meta": meta}
+}
+
+// StringifyIDs returns body with every "ID" or "id" number written as a
+// string. JavaScript clients lose precision on ids above 2^53.
+func StringifyIDs(body any) (any, error) {
+	generic, err := Generic(body)
+	if err != nil {
+		return nil, err
+	}
+	return stringifyIDs(generic), nil
+}
+
+func stringifyIDs(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, inner := range val {
+			if n, ok := inner.(json.Number); ok && (k == "ID" || k == "id") {
+				val[k] = n.String()
+				continue
+			}
+			val[k] = stringifyIDs(inner)
+		}
+		return val
+	case []any:
+		for i, inner := range val {
+			val[i] = stringifyIDs(inner)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// Generic round-trips v through JSON so it can be reshaped as maps and
+// slices. Numbers are kept as json.Number to preserve large ids.
+func Generic(v any) (any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
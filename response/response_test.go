@@ -0,0 +1,55 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/pagination"
+)
+
+type item struct {
+	ID     uint `json:"id"`
+	UserID uint `json:"user_id"`
+}
+
+func doShaped(opts Options, page bool) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		if page {
+			SetPage(c, pagination.Page{Number: 2, Limit: 10})
+		}
+		opts.JSON(c, http.StatusOK, []item{{ID: 1 << 53, UserID: 3}})
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestOptions_JSON: bodies are written as they are by default, and enveloped or with string ids when asked
+func TestOptions_JSON(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		page bool
+		want string
+	}{
+		{"plain", Options{}, true, `[{"id":9007199254740992,"user_id":3}]`},
+		{"ids", Options{IDsAsStrings: true}, false, `[{"id":"9007199254740992","user_id":3}]`},
+		{"envelope", Options{Envelope: true}, false, `{"data":[{"id":9007199254740992,"user_id":3}],"meta":{"request_id":"req-1"}}`},
+		{"paged envelope", Options{Envelope: true}, true, `{"data":[{"id":9007199254740992,"user_id":3}],"meta":{"count":1,"limit":10,"page":2,"request_id":"req-1"}}`},
+	}
+	for _, tc := range tests {
+		w := doShaped(tc.opts, tc.page)
+		if w.Code != http.StatusOK || w.Body.String() != tc.want {
+			t.Errorf("%s: expected %s, got %d %s", tc.name, tc.want, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Content-Type"); got != jsonMediaType {
+			t.Errorf("%s: expected Content-Type %q, got %q", tc.name, jsonMediaType, got)
+		}
+	}
+}
@@ -27,6 +27,6 @@ func seedAdminUser(db *gorm.DB, hashFn func(string) (string, error)) {
 		fmt.Printf("failed to hash admin password: %s\n", err)
 		return
 	}
-	db.Create(&auth.User{Username: username, Password: hashed})
+	db.Create(&auth.User{Username: username, Password: hashed, Role: auth.RoleAdmin})
 	fmt.Println("Admin user seeded")
 }
@@ -47,6 +47,9 @@ func TestSeedAdminUser_CreatesUser(t *testing.T) {
 	if !auth.CheckPassword("secret123", u.Password) {
 		t.Error("password was not stored as a valid bcrypt hash")
 	}
+	if u.Role != auth.RoleAdmin {
+		t.Errorf("expected role=admin, got %q", u.Role)
+	}
 }
 
 func TestSeedAdminUser_SkipsWhenUsersExist(t *testing.T) {
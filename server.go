@@ -6,22 +6,25 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
+	"github.com/pradist/todoapi/app"
 	"github.com/pradist/todoapi/auth"
 	"github.com/pradist/todoapi/middleware"
-	"github.com/pradist/todoapi/todo"
 	"golang.org/x/time/rate"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 // ipLimiterFromEnv builds an IPLimiter from environment variables.
 //
-//	RATE_LIMIT  - requests per minute per IP (default: 5; set to 0 to disable)
-//	RATE_BURST  - maximum burst size (default: 5)
+//	RATE_LIMIT         - requests per minute per IP (default: 5; set to 0 to disable)
+//	RATE_BURST         - maximum burst size (default: 5)
+//
+// The limiter only guards POST /tokenz, so it exempts no paths.
 func ipLimiterFromEnv() *middleware.IPLimiter {
 	limitPerMin := 5
 	burst := 5
@@ -44,31 +47,85 @@ func ipLimiterFromEnv() *middleware.IPLimiter {
 	return middleware.NewIPLimiter(r, burst)
 }
 
-func setupDB(dsn string) (*gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+// busyTimeoutDSN sets the busy_timeout of every connection opened through
+// the SQLite dsn, so a statement waiting on another's lock fails after
+// timeout instead of the driver's default of 5s. SQLite has no statement
+// timeout of its own; this bounds how long a query can hang on the
+// database. Zero leaves dsn unchanged.
+func busyTimeoutDSN(dsn string, timeout time.Duration) string {
+	if timeout <= 0 {
+		return dsn
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return fmt.Sprintf("%s%s_busy_timeout=%d", dsn, sep, timeout.Milliseconds())
+}
+
+// openDB opens the SQLite database at dsn, naming tables with naming.
+func openDB(dsn string, naming schema.NamingStrategy) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Open(dsn), &gorm.Config{NamingStrategy: naming})
+}
+
+// maxDBConnectBackoff caps the doubling wait between connection attempts.
+const maxDBConnectBackoff = 30 * time.Second
+
+// connectDB calls open until it succeeds or the retries run out, so the
+// server can start before its database is up. It waits backoff before the
+// first retry and doubles the wait each time, up to maxDBConnectBackoff.
+func connectDB(open func() (*gorm.DB, error), retries int, backoff time.Duration) (*gorm.DB, error) {
+	for attempt := 1; ; attempt++ {
+		db, err := open()
+		if err == nil || attempt > retries {
+			return db, err
+		}
+		fmt.Printf("database connection attempt %d of %d failed: %s; retrying in %s\n", attempt, retries+1, err, backoff)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxDBConnectBackoff)
+	}
+}
+
+// initDB migrates the schema and seeds the admin user. It can take a while
+// on a large database, so the server runs it after it starts listening.
+func initDB(db *gorm.DB) error {
+	if err := app.Migrate(db); err != nil {
+		return err
+	}
+	seedAdminUser(db, auth.HashPassword)
+	return nil
+}
+
+// fallbackDB returns db if connecting succeeded. Otherwise, if enabled, it
+// warns loudly and opens an empty in-memory SQLite database instead, so the
+// server can run in development without its database; the data is lost on
+// exit.
+func fallbackDB(db *gorm.DB, err error, enabled bool, naming schema.NamingStrategy) (*gorm.DB, error) {
+	if err == nil || !enabled {
+		return db, err
+	}
+	fmt.Printf("WARNING: database unavailable (%s); DB_FALLBACK_MEMORY is set, so serving from an empty in-memory database. Nothing will be saved.\n", err)
+	db, err = openDB(":memory:", naming)
 	if err != nil {
 		return nil, err
 	}
-	db.AutoMigrate(&todo.Todo{}, &auth.User{})
-	seedAdminUser(db, auth.HashPassword)
+	// Every connection to :memory: opens a database of its own.
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
 	return db, nil
 }
 
-func setupRouter(db *gorm.DB, sign string, limiter *middleware.IPLimiter) *gin.Engine {
-	r := gin.Default()
-	r.GET("/healthz", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
-	r.POST("/tokenz", middleware.RateLimitMiddleware(limiter), auth.AccessToken(db, sign, func(token *jwt.Token, key any) (string, error) {
-		return token.SignedString(key)
-	}))
-	protected := r.Group("", auth.Protect([]byte(sign)))
-	handler := todo.NewTodoHandler(db)
-	protected.POST("/todos", handler.NewTask)
-	return r
+// setupRouter builds the API router from the server configuration.
+func setupRouter(db *gorm.DB, cfg config) *gin.Engine {
+	return app.NewRouter(db, cfg.Config)
 }
 
-func startServer(ctx context.Context, r http.Handler, addr string) error {
+// startServer serves r on addr until ctx is cancelled. It then stops the
+// server and runs the rest of lc's hooks, all within shutdownTimeout.
+func startServer(ctx context.Context, r http.Handler, addr string, shutdownTimeout time.Duration, lc *lifecycle) error {
 	s := &http.Server{
 		Addr:           addr,
 		Handler:        r,
@@ -77,6 +134,8 @@ func startServer(ctx context.Context, r http.Handler, addr string) error {
 		MaxHeaderBytes: 1 << 20,
 	}
 
+	lc.onShutdown("http server", s.Shutdown)
+
 	go func() {
 		if err := s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			fmt.Printf("listen: %s\n", err)
@@ -86,8 +145,8 @@ func startServer(ctx context.Context, r http.Handler, addr string) error {
 	<-ctx.Done()
 	fmt.Println("Shutting down gracefully, press Ctrl+C again to force")
 
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
 
-	return s.Shutdown(ctxTimeout)
+	return lc.shutdown(ctxTimeout)
 }
@@ -4,19 +4,26 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/app"
 	"github.com/pradist/todoapi/auth"
 	"github.com/pradist/todoapi/middleware"
 	"github.com/pradist/todoapi/todo"
 	"golang.org/x/time/rate"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 func setupTestDB(t *testing.T) *gorm.DB {
@@ -25,7 +32,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatalf("failed to open test db: %v", err)
 	}
-	if err := db.AutoMigrate(&todo.Todo{}, &auth.User{}); err != nil {
+	if err := app.Migrate(db); err != nil {
 		t.Fatalf("failed to migrate: %v", err)
 	}
 	return db
@@ -63,11 +70,16 @@ func noLimiter() *middleware.IPLimiter {
 	return middleware.NewIPLimiter(rate.Inf, 0)
 }
 
+// testConfig returns the router configuration shared by the router tests.
+func testConfig() config {
+	return config{Config: app.Config{Sign: "secret", TokenTTL: 5 * time.Minute, Limiter: noLimiter()}}
+}
+
 // --- setupRouter tests ---
 
 func TestSetupRouter_Ping(t *testing.T) {
 	db := setupTestDB(t)
-	r := setupRouter(db, "secret", noLimiter())
+	r := setupRouter(db, testConfig())
 
 	w := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
@@ -83,10 +95,60 @@ func TestSetupRouter_Ping(t *testing.T) {
 	}
 }
 
+// TestSetupRouter_HealthDetails: /healthz/details reports uptime, versions, the database and requests in flight
+func TestSetupRouter_HealthDetails(t *testing.T) {
+	r := setupRouter(setupTestDB(t), testConfig())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz/details", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var body struct {
+		Status        string `json:"status"`
+		Uptime        string `json:"uptime"`
+		UptimeSeconds *int64 `json:"uptime_seconds"`
+		Version       string `json:"version"`
+		GoVersion     string `json:"go_version"`
+		Database      struct {
+			Driver  string `json:"driver"`
+			Version string `json:"version"`
+		} `json:"database"`
+		InFlight int64 `json:"in_flight"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Status != "ok" || body.Uptime == "" || body.UptimeSeconds == nil || body.Version == "" {
+		t.Errorf("expected status, uptime and version, got %+v", body)
+	}
+	if body.GoVersion != runtime.Version() {
+		t.Errorf("expected go_version %q, got %q", runtime.Version(), body.GoVersion)
+	}
+	if body.Database.Driver != "sqlite" || !strings.HasPrefix(body.Database.Version, "3.") {
+		t.Errorf("expected a SQLite 3 database, got %+v", body.Database)
+	}
+	if body.InFlight != 1 {
+		t.Errorf("expected the request itself in flight, got %d", body.InFlight)
+	}
+}
+
+func TestSetupRouter_Version(t *testing.T) {
+	r := setupRouter(setupTestDB(t), testConfig())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
 func TestSetupRouter_Tokenz_ValidCredentials(t *testing.T) {
 	db := setupTestDB(t)
 	seedTestUser(t, db, "admin", "pass123")
-	r := setupRouter(db, "secret", noLimiter())
+	r := setupRouter(db, testConfig())
 
 	body, _ := json.Marshal(map[string]string{"username": "admin", "password": "pass123"})
 	req := httptest.NewRequest(http.MethodPost, "/tokenz", bytes.NewBuffer(body))
@@ -107,7 +169,7 @@ func TestSetupRouter_Tokenz_ValidCredentials(t *testing.T) {
 func TestSetupRouter_Tokenz_InvalidCredentials(t *testing.T) {
 	db := setupTestDB(t)
 	seedTestUser(t, db, "admin", "pass123")
-	r := setupRouter(db, "secret", noLimiter())
+	r := setupRouter(db, testConfig())
 
 	body, _ := json.Marshal(map[string]string{"username": "admin", "password": "wrong"})
 	req := httptest.NewRequest(http.MethodPost, "/tokenz", bytes.NewBuffer(body))
@@ -122,7 +184,7 @@ func TestSetupRouter_Tokenz_InvalidCredentials(t *testing.T) {
 
 func TestSetupRouter_Todos_WithoutAuth(t *testing.T) {
 	db := setupTestDB(t)
-	r := setupRouter(db, "secret", noLimiter())
+	r := setupRouter(db, testConfig())
 
 	body, _ := json.Marshal(map[string]string{"text": "hello"})
 	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBuffer(body))
@@ -138,7 +200,7 @@ func TestSetupRouter_Todos_WithoutAuth(t *testing.T) {
 func TestSetupRouter_Todos_WithValidToken(t *testing.T) {
 	db := setupTestDB(t)
 	seedTestUser(t, db, "admin", "pass123")
-	r := setupRouter(db, "secret", noLimiter())
+	r := setupRouter(db, testConfig())
 
 	token := getToken(t, r, "admin", "pass123")
 
@@ -154,6 +216,342 @@ func TestSetupRouter_Todos_WithValidToken(t *testing.T) {
 	}
 }
 
+// TestSetupRouter_Todos_WithAPIKey: a key created with a token works in place of the token
+func TestSetupRouter_Todos_WithAPIKey(t *testing.T) {
+	db := setupTestDB(t)
+	seedTestUser(t, db, "admin", "pass123")
+	r := setupRouter(db, testConfig())
+
+	token := getToken(t, r, "admin", "pass123")
+	req := httptest.NewRequest(http.MethodPost, "/apikeys", bytes.NewBufferString(`{"name": "ci"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201 from /apikeys, got %d", w.Code)
+	}
+	var created map[string]any
+	json.NewDecoder(w.Body).Decode(&created)
+
+	req = httptest.NewRequest(http.MethodGet, "/todos", nil)
+	req.Header.Set("X-API-Key", created["key"].(string))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestSetupRouter_PutTodo_WithValidToken(t *testing.T) {
+	db := setupTestDB(t)
+	seedTestUser(t, db, "admin", "pass123")
+	r := setupRouter(db, testConfig())
+
+	token := getToken(t, r, "admin", "pass123")
+
+	body, _ := json.Marshal(map[string]string{"text": "upserted"})
+	req := httptest.NewRequest(http.MethodPut, "/todos/5", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/todos/5", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestSetupRouter_Me(t *testing.T) {
+	db := setupTestDB(t)
+	seedTestUser(t, db, "admin", "pass123")
+	r := setupRouter(db, testConfig())
+
+	token := getToken(t, r, "admin", "pass123")
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp map[string]any
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp["subject"] != "1" {
+		t.Errorf("expected subject=1, got %v", resp["subject"])
+	}
+}
+
+func TestSetupRouter_Audit_RequiresAdmin(t *testing.T) {
+	db := setupTestDB(t)
+	seedTestUser(t, db, "alice", "pass123")
+	hashed, _ := auth.HashPassword("pass123")
+	db.Create(&auth.User{Username: "root", Password: hashed, Role: auth.RoleAdmin})
+	r := setupRouter(db, testConfig())
+
+	for _, tc := range []struct {
+		username string
+		want     int
+	}{
+		{"alice", http.StatusForbidden},
+		{"root", http.StatusOK},
+	} {
+		token := getToken(t, r, tc.username, "pass123")
+		req := httptest.NewRequest(http.MethodGet, "/audit", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.username, tc.want, w.Code)
+		}
+	}
+}
+
+// TestSetupRouter_UserStats_RequiresAdmin: only admins may list per-user stats
+func TestSetupRouter_UserStats_RequiresAdmin(t *testing.T) {
+	db := setupTestDB(t)
+	seedTestUser(t, db, "alice", "pass123")
+	hashed, _ := auth.HashPassword("pass123")
+	db.Create(&auth.User{Username: "root", Password: hashed, Role: auth.RoleAdmin})
+	r := setupRouter(db, testConfig())
+
+	for _, tc := range []struct {
+		username string
+		want     int
+	}{
+		{"alice", http.StatusForbidden},
+		{"root", http.StatusOK},
+	} {
+		token := getToken(t, r, tc.username, "pass123")
+		req := httptest.NewRequest(http.MethodGet, "/admin/users/stats", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.username, tc.want, w.Code)
+		}
+	}
+}
+
+// TestSetupRouter_PurgeTrash_RequiresAdmin: only admins may empty the trash
+func TestSetupRouter_PurgeTrash_RequiresAdmin(t *testing.T) {
+	db := setupTestDB(t)
+	seedTestUser(t, db, "alice", "pass123")
+	hashed, _ := auth.HashPassword("pass123")
+	db.Create(&auth.User{Username: "root", Password: hashed, Role: auth.RoleAdmin})
+	r := setupRouter(db, testConfig())
+
+	for _, tc := range []struct {
+		username string
+		want     int
+	}{
+		{"alice", http.StatusForbidden},
+		{"root", http.StatusOK},
+	} {
+		token := getToken(t, r, tc.username, "pass123")
+		req := httptest.NewRequest(http.MethodDelete, "/todos/trash", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.username, tc.want, w.Code)
+		}
+	}
+}
+
+// TestSetupRouter_CORSPreflight: preflights for protected routes succeed without a token
+func TestSetupRouter_CORSPreflight(t *testing.T) {
+	cfg := testConfig()
+	cfg.CORS = middleware.CORSConfig{AllowOrigins: []string{"https://app.example.com"}, MaxAge: time.Hour, AllowCredentials: true}
+	r := setupRouter(setupTestDB(t), cfg)
+
+	req := httptest.NewRequest(http.MethodOptions, "/todos", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "3600" {
+		t.Errorf("expected max age 3600, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("expected credentials header, got %q", got)
+	}
+}
+
+// TestSetupRouter_DebugSQL: a simple create reports its insert and audit write
+func TestSetupRouter_DebugSQL(t *testing.T) {
+	db := setupTestDB(t)
+	seedTestUser(t, db, "alice", "pass123")
+	if err := middleware.RegisterQueryCounter(db); err != nil {
+		t.Fatalf("failed to register query counter: %v", err)
+	}
+	cfg := testConfig()
+	cfg.DebugSQL = true
+	r := setupRouter(db, cfg)
+	token := getToken(t, r, "alice", "pass123")
+
+	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBufferString(`{"text":"count me"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+	if got := w.Header().Get(middleware.QueryCountHeader); got != "2" {
+		t.Errorf("expected 2 queries, got %q", got)
+	}
+}
+
+func TestSetupRouter_DebugSQLOff(t *testing.T) {
+	r := setupRouter(setupTestDB(t), testConfig())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if got := w.Header().Get(middleware.QueryCountHeader); got != "" {
+		t.Errorf("expected no query count header, got %q", got)
+	}
+}
+
+func TestSetupRouter_SecureHeaders(t *testing.T) {
+	cfg := testConfig()
+	cfg.SecureHeaders = true
+	r := setupRouter(setupTestDB(t), cfg)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("expected nosniff, got %q", got)
+	}
+	if got := w.Header().Get("X-Frame-Options"); got != "DENY" {
+		t.Errorf("expected DENY, got %q", got)
+	}
+}
+
+// TestSetupRouter_PrettyJSON: ?pretty=true indents responses, PRETTY_JSON indents all of them
+func TestSetupRouter_PrettyJSON(t *testing.T) {
+	const compact, indented = `{"status":"ok"}`, "{\n  \"status\": \"ok\"\n}"
+	cfg := testConfig()
+	tests := []struct {
+		always bool
+		target string
+		want   string
+	}{
+		{false, "/healthz", compact},
+		{false, "/healthz?pretty=true", indented},
+		{true, "/healthz", indented},
+	}
+	for _, tc := range tests {
+		cfg.PrettyJSON = tc.always
+		w := httptest.NewRecorder()
+		setupRouter(setupTestDB(t), cfg).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.target, nil))
+		if got := w.Body.String(); got != tc.want {
+			t.Errorf("%s (always=%v): expected %q, got %q", tc.target, tc.always, tc.want, got)
+		}
+	}
+}
+
+// TestSetupRouter_StrictParams: with STRICT_PARAMS a typo in a list filter is a 400, known parameters pass
+func TestSetupRouter_StrictParams(t *testing.T) {
+	db := setupTestDB(t)
+	seedTestUser(t, db, "alice", "pass123")
+	cfg := testConfig()
+	cfg.StrictParams = true
+	r := setupRouter(db, cfg)
+	token := getToken(t, r, "alice", "pass123")
+
+	for target, want := range map[string]int{
+		"/todos?complete=true":                      http.StatusBadRequest,
+		"/todos?completed=true&page=1&pretty=false": http.StatusOK,
+		"/todos/trash?completed=true":               http.StatusBadRequest,
+		"/healthz?verbose=1":                        http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("%s: expected %d, got %d", target, want, w.Code)
+		}
+	}
+}
+
+// TestSetupRouter_LogSkipPaths: skipped paths leave no access log entry, others still do
+func TestSetupRouter_LogSkipPaths(t *testing.T) {
+	var logs bytes.Buffer
+	defer func(w io.Writer) { gin.DefaultWriter = w }(gin.DefaultWriter)
+	gin.DefaultWriter = &logs
+	cfg := testConfig()
+	cfg.LogSkipPaths = []string{"/healthz"}
+	r := setupRouter(setupTestDB(t), cfg)
+	logs.Reset() // drop gin's route listing
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if logs.Len() != 0 {
+		t.Fatalf("expected no log entry for /healthz, got %q", logs.String())
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/version", nil))
+	if !strings.Contains(logs.String(), "/version") {
+		t.Errorf("expected a log entry for /version, got %q", logs.String())
+	}
+}
+
+// TestSetupRouter_DisabledEndpoints: disabled routes are 404 even without a token, the rest still work
+func TestSetupRouter_DisabledEndpoints(t *testing.T) {
+	db := setupTestDB(t)
+	seedTestUser(t, db, "alice", "pass123")
+	cfg := testConfig()
+	cfg.DisabledEndpoints = []string{"bulk", "trash"}
+	r := setupRouter(db, cfg)
+	token := getToken(t, r, "alice", "pass123")
+
+	for _, tc := range []struct {
+		method, target, token string
+		want                  int
+	}{
+		{http.MethodPost, "/todos/bulk-update", token, http.StatusNotFound},
+		{http.MethodPost, "/todos/bulk-update", "", http.StatusNotFound},
+		{http.MethodGet, "/todos/trash", token, http.StatusNotFound},
+		{http.MethodDelete, "/todos/trash", token, http.StatusNotFound},
+		{http.MethodGet, "/todos", token, http.StatusOK},
+		{http.MethodGet, "/todos/today", token, http.StatusOK},
+	} {
+		req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s %s: expected %d, got %d", tc.method, tc.target, tc.want, w.Code)
+		}
+	}
+}
+
 // --- ipLimiterFromEnv tests ---
 
 func TestIPLimiterFromEnv_Defaults(t *testing.T) {
@@ -169,7 +567,9 @@ func TestIPLimiterFromEnv_Defaults(t *testing.T) {
 func TestIPLimiterFromEnv_Disabled(t *testing.T) {
 	t.Setenv("RATE_LIMIT", "0")
 
-	r := setupRouter(setupTestDB(t), "secret", ipLimiterFromEnv())
+	cfg := testConfig()
+	cfg.Limiter = ipLimiterFromEnv()
+	r := setupRouter(setupTestDB(t), cfg)
 
 	// 20 requests should all pass when limiting is disabled
 	for i := 0; i < 20; i++ {
@@ -187,7 +587,9 @@ func TestIPLimiterFromEnv_CustomValues(t *testing.T) {
 	t.Setenv("RATE_LIMIT", "10")
 	t.Setenv("RATE_BURST", "2")
 
-	r := setupRouter(setupTestDB(t), "secret", ipLimiterFromEnv())
+	cfg := testConfig()
+	cfg.Limiter = ipLimiterFromEnv()
+	r := setupRouter(setupTestDB(t), cfg)
 
 	// burst is 2, first 2 requests to /tokenz pass (rate limiter allows them)
 	for i := 0; i < 2; i++ {
@@ -215,10 +617,10 @@ func TestIPLimiterFromEnv_CustomValues(t *testing.T) {
 	}
 }
 
-// --- setupDB tests ---
+// --- openDB / initDB tests ---
 
-func TestSetupDB_Success(t *testing.T) {
-	db, err := setupDB(":memory:")
+func TestOpenDB_Success(t *testing.T) {
+	db, err := openDB(":memory:", schema.NamingStrategy{})
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
@@ -227,26 +629,192 @@ func TestSetupDB_Success(t *testing.T) {
 	}
 }
 
-func TestSetupDB_OpenError(t *testing.T) {
+func TestOpenDB_OpenError(t *testing.T) {
 	// SQLite cannot create a file inside a non-existent subdirectory
 	dsn := t.TempDir() + "/nonexistent/test.db"
-	_, err := setupDB(dsn)
+	_, err := openDB(dsn, schema.NamingStrategy{})
 	if err == nil {
 		t.Fatal("expected error for invalid dsn, got nil")
 	}
 }
 
+// TestBusyTimeoutDSN: the configured timeout is applied to the SQLite connection
+func TestBusyTimeoutDSN(t *testing.T) {
+	if got := busyTimeoutDSN("todo.db", 0); got != "todo.db" {
+		t.Errorf("expected the DSN unchanged without a timeout, got %q", got)
+	}
+	if got := busyTimeoutDSN("file:todo.db?mode=rwc", 2*time.Second); got != "file:todo.db?mode=rwc&_busy_timeout=2000" {
+		t.Errorf("unexpected DSN %q", got)
+	}
+
+	db, err := openDB(busyTimeoutDSN(t.TempDir()+"/test.db", 1500*time.Millisecond), schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var timeout int
+	if err := db.Raw("PRAGMA busy_timeout").Scan(&timeout).Error; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if timeout != 1500 {
+		t.Errorf("expected busy_timeout 1500, got %d", timeout)
+	}
+}
+
+// TestConnectDB_Retries: a database that comes up after a few failures is still connected
+func TestConnectDB_Retries(t *testing.T) {
+	calls := 0
+	open := func() (*gorm.DB, error) {
+		calls++
+		if calls < 3 {
+			return nil, errors.New("connection refused")
+		}
+		return openDB(":memory:", schema.NamingStrategy{})
+	}
+
+	db, err := connectDB(open, 5, time.Millisecond)
+
+	if err != nil || db == nil {
+		t.Fatalf("expected a connection, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+// TestConnectDB_GivesUp: the last error is returned once the retries run out
+func TestConnectDB_GivesUp(t *testing.T) {
+	calls := 0
+	open := func() (*gorm.DB, error) {
+		calls++
+		return nil, errors.New("connection refused")
+	}
+
+	if _, err := connectDB(open, 2, time.Millisecond); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 3 {
+		t.Errorf("expected the first attempt and 2 retries, got %d", calls)
+	}
+}
+
+// TestFallbackDB: a failed connection falls back to a working in-memory database only when enabled
+func TestFallbackDB(t *testing.T) {
+	failure := errors.New("connection refused")
+
+	if _, err := fallbackDB(nil, failure, false, schema.NamingStrategy{}); !errors.Is(err, failure) {
+		t.Fatalf("expected the failure without the fallback, got %v", err)
+	}
+
+	db, err := fallbackDB(nil, failure, true, schema.NamingStrategy{})
+	if err != nil || db == nil {
+		t.Fatalf("expected the fallback database, got %v", err)
+	}
+	if err := initDB(db); err != nil {
+		t.Fatalf("expected the fallback database to be usable, got %v", err)
+	}
+	if err := db.Create(&todo.Todo{Title: "kept"}).Error; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var count int64
+	db.Model(&todo.Todo{}).Count(&count)
+	if count != 1 {
+		t.Errorf("expected the todo to be kept across connections, got %d", count)
+	}
+}
+
+func TestInitDB_Migrates(t *testing.T) {
+	db, err := openDB(":memory:", schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := initDB(db); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !db.Migrator().HasTable(&auth.User{}) {
+		t.Error("expected the users table to exist")
+	}
+}
+
+// TestOpenDB_NamingStrategy: tables take the configured prefix and number, columns stay snake_case
+func TestOpenDB_NamingStrategy(t *testing.T) {
+	db, err := openDB(":memory:", schema.NamingStrategy{TablePrefix: "legacy_", SingularTable: true})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if err := initDB(db); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for _, table := range []string{"legacy_todo", "legacy_user", "legacy_api_key", "legacy_audit_log"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("expected table %s to exist", table)
+		}
+	}
+	if !db.Migrator().HasColumn("legacy_todo", "due_date") {
+		t.Error("expected the legacy_todo.due_date column to exist")
+	}
+
+	seedTestUser(t, db, "alice", "pass123")
+	r := setupRouter(db, testConfig())
+	token := getToken(t, r, "alice", "pass123")
+	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBufferString(`{"text": "buy milk"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var count int64
+	db.Table("legacy_audit_log").Count(&count)
+	if count != 1 {
+		t.Errorf("expected the audit entry in legacy_audit_log, found %d", count)
+	}
+}
+
+// TestSetupRouter_NotReady: until startup completes every route is 503 and /healthz says so
+func TestSetupRouter_NotReady(t *testing.T) {
+	cfg := testConfig()
+	cfg.Ready = new(middleware.Readiness)
+	r := setupRouter(setupTestDB(t), cfg)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/healthz", nil),
+		httptest.NewRequest(http.MethodGet, "/version", nil),
+		httptest.NewRequest(http.MethodPost, "/tokenz", bytes.NewBufferString(`{}`)),
+		httptest.NewRequest(http.MethodGet, "/todos", nil),
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s: expected 503, got %d", req.Method, req.URL.Path, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if body := w.Body.String(); body != `{"status":"starting"}` {
+		t.Errorf("expected /healthz to report starting, got %s", body)
+	}
+
+	cfg.Ready.SetReady()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 once ready, got %d", w.Code)
+	}
+}
+
 // --- startServer tests ---
 
 func TestStartServer_GracefulShutdown(t *testing.T) {
 	db := setupTestDB(t)
-	r := setupRouter(db, "secret", noLimiter())
+	r := setupRouter(db, testConfig())
 
 	ctx, cancel := context.WithCancel(context.Background())
 
 	done := make(chan error, 1)
 	go func() {
-		done <- startServer(ctx, r, ":0")
+		done <- startServer(ctx, r, ":0", 5*time.Second, new(lifecycle))
 	}()
 
 	// Give the server goroutine time to start ListenAndServe
@@ -263,6 +831,42 @@ func TestStartServer_GracefulShutdown(t *testing.T) {
 	}
 }
 
+// TestStartServer_RunsHooksAfterServer: registered hooks run once the server has stopped
+func TestStartServer_RunsHooksAfterServer(t *testing.T) {
+	r := setupRouter(setupTestDB(t), testConfig())
+	var lc lifecycle
+	hookRan := make(chan struct{})
+	lc.onShutdown("database", func(context.Context) error {
+		close(hookRan)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- startServer(ctx, r, ":0", 5*time.Second, &lc)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected nil error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down in time")
+	}
+	select {
+	case <-hookRan:
+	default:
+		t.Error("expected the database hook to run")
+	}
+	if names := []string{lc.hooks[0].name, lc.hooks[1].name}; names[1] != "http server" {
+		t.Errorf("expected the http server to be registered last, got %v", names)
+	}
+}
+
 func TestStartServer_ListenError(t *testing.T) {
 	// Occupy a port so startServer's ListenAndServe fails with a real error
 	ln, err := net.Listen("tcp", ":0")
@@ -273,13 +877,13 @@ func TestStartServer_ListenError(t *testing.T) {
 	port := fmt.Sprintf(":%d", ln.Addr().(*net.TCPAddr).Port)
 
 	db := setupTestDB(t)
-	r := setupRouter(db, "secret", noLimiter())
+	r := setupRouter(db, testConfig())
 
 	ctx, cancel := context.WithCancel(context.Background())
 
 	done := make(chan error, 1)
 	go func() {
-		done <- startServer(ctx, r, port)
+		done <- startServer(ctx, r, port, 5*time.Second, new(lifecycle))
 	}()
 
 	// Give the goroutine time to hit the listen error and print it
@@ -295,7 +899,7 @@ func TestStartServer_ListenError(t *testing.T) {
 
 func TestStartServer_ServesRequestsBeforeShutdown(t *testing.T) {
 	db := setupTestDB(t)
-	r := setupRouter(db, "secret", noLimiter())
+	r := setupRouter(db, testConfig())
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -305,7 +909,7 @@ func TestStartServer_ServesRequestsBeforeShutdown(t *testing.T) {
 	// Use httptest to capture the actual address
 	go func() {
 		// Start server on a random port via httptest server approach
-		_ = startServer(ctx, r, ":0")
+		_ = startServer(ctx, r, ":0", 5*time.Second, new(lifecycle))
 	}()
 	close(ready)
 
@@ -0,0 +1,84 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/auth"
+	"github.com/pradist/todoapi/response"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Handler struct {
+	db    *gorm.DB
+	shape response.Options
+}
+
+// NewHandler returns the settings handler. Only the envelope of shape is
+// used: settings are the client's own JSON, so their ids are left alone.
+func NewHandler(db *gorm.DB, shape response.Options) *Handler {
+	return &Handler{db: db, shape: response.Options{Envelope: shape.Envelope}}
+}
+
+// Get returns the caller's settings object, or {} if nothing is stored.
+func (h *Handler) Get(c *gin.Context) {
+	userID, ok := auth.UserID(c)
+	if !ok {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	var s Settings
+	if err := h.db.WithContext(c.Request.Context()).Where("user_id = ?", userID).Limit(1).Find(&s).Error; err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	if s.Data == nil {
+		s.Data = datatypes.JSON("{}")
+	}
+	h.shape.JSON(c, http.StatusOK, json.RawMessage(s.Data))
+}
+
+// Put replaces the caller's settings object. A body that isn't JSON is 400;
+// one that isn't an object, is over MaxSize or has invalid preferences is
+// 422.
+func (h *Handler) Put(c *gin.Context) {
+	userID, ok := auth.UserID(c)
+	if !ok {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, MaxSize+1))
+	if err != nil {
+		apperr.Write(c, apperr.ErrBadRequest.Wrap(err))
+		return
+	}
+	var compact bytes.Buffer
+	if len(body) <= MaxSize {
+		if err := json.Compact(&compact, body); err != nil {
+			apperr.Write(c, apperr.ErrBadRequest.With("invalid JSON: "+err.Error()))
+			return
+		}
+		body = compact.Bytes()
+	}
+	if err := validate(body); err != nil {
+		apperr.Write(c, apperr.ErrValidation.Wrap(err))
+		return
+	}
+
+	s := Settings{UserID: userID, Data: datatypes.JSON(body)}
+	err = h.db.WithContext(c.Request.Context()).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data", "updated_at"}),
+	}).Create(&s).Error
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	h.shape.JSON(c, http.StatusOK, json.RawMessage(s.Data))
+}
@@ -0,0 +1,84 @@
+// Package settings stores each user's preferences as a JSON object, such as
+// how GET /todos sorts and pages when the request doesn't say.
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// MaxSize is the largest settings object accepted, in bytes.
+const MaxSize = 4096
+
+// Sorts are the orders GET /todos accepts in ?sort= and as a stored
+// default. A leading "-" sorts descending.
+var Sorts = []string{"id", "-id", "due_date", "-due_date", "updated_at", "-updated_at"}
+
+// Settings is one user's stored settings.
+type Settings struct {
+	UserID    uint           `gorm:"primaryKey;autoIncrement:false"`
+	Data      datatypes.JSON `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+// TableName is "user_settings" under GORM's default naming, with the
+// naming strategy's prefix and pluralisation applied.
+func (Settings) TableName(namer schema.Namer) string {
+	return namer.TableName("UserSettings")
+}
+
+// Preferences are the settings the API itself applies. Other keys are kept
+// for the client but have no effect.
+type Preferences struct {
+	// Sort is the default ?sort= of GET /todos, one of Sorts.
+	Sort string `json:"sort"`
+	// PageSize is the default ?limit= of GET /todos.
+	PageSize int `json:"page_size"`
+}
+
+var errNotObject = errors.New("settings must be a JSON object")
+
+// validate checks a settings object and the preferences in it.
+func validate(data []byte) error {
+	if len(data) > MaxSize {
+		return fmt.Errorf("settings must be at most %d bytes", MaxSize)
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil || obj == nil {
+		return errNotObject
+	}
+	var p Preferences
+	if err := json.Unmarshal(data, &p); err != nil {
+		return errors.New("sort must be a string and page_size an integer")
+	}
+	if _, ok := obj["sort"]; ok && !slices.Contains(Sorts, p.Sort) {
+		return fmt.Errorf("sort must be one of %s, got %q", strings.Join(Sorts, ", "), p.Sort)
+	}
+	if _, ok := obj["page_size"]; ok && p.PageSize < 1 {
+		return fmt.Errorf("page_size must be a positive integer, got %d", p.PageSize)
+	}
+	return nil
+}
+
+// Load returns the preferences userID has stored, or zero Preferences if
+// there are none.
+func Load(db *gorm.DB, userID uint) (Preferences, error) {
+	var s Settings
+	err := db.Where("user_id = ?", userID).Limit(1).Find(&s).Error
+	if err != nil || s.Data == nil {
+		return Preferences{}, err
+	}
+	var p Preferences
+	if err := json.Unmarshal(s.Data, &p); err != nil {
+		return Preferences{}, err
+	}
+	return p, nil
+}
@@ -0,0 +1,120 @@
+package settings
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/pradist/todoapi/auth"
+	"github.com/pradist/todoapi/response"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupHandler(t *testing.T) (*gorm.DB, *gin.Engine) {
+	t.Helper()
+	return setupShapedHandler(t, response.Options{})
+}
+
+// setupShapedHandler is setupHandler with responses shaped by shape.
+func setupShapedHandler(t *testing.T, shape response.Options) (*gorm.DB, *gin.Engine) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	if err := db.AutoMigrate(&Settings{}); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	h := NewHandler(db, shape)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		auth.SetClaims(c, &auth.Claims{StandardClaims: jwt.StandardClaims{Subject: c.GetHeader("X-Test-User")}})
+	})
+	r.GET("/me/settings", h.Get)
+	r.PUT("/me/settings", h.Put)
+	return db, r
+}
+
+func doSettings(r *gin.Engine, method, user, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/me/settings", bytes.NewBufferString(body))
+	req.Header.Set("X-Test-User", user)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestSettings_SaveAndGet: settings are stored per user and replaced as a whole
+func TestSettings_SaveAndGet(t *testing.T) {
+	db, r := setupHandler(t)
+
+	if w := doSettings(r, http.MethodGet, "1", ""); w.Code != http.StatusOK || w.Body.String() != "{}" {
+		t.Fatalf("expected {} before anything is saved, got %d %s", w.Code, w.Body.String())
+	}
+
+	w := doSettings(r, http.MethodPut, "1", `{"sort": "-id", "page_size": 50, "theme": "dark"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	doSettings(r, http.MethodPut, "2", `{"sort": "due_date"}`)
+	doSettings(r, http.MethodPut, "1", `{"sort": "-updated_at", "theme": "light"}`)
+
+	want := `{"sort":"-updated_at","theme":"light"}`
+	if w := doSettings(r, http.MethodGet, "1", ""); w.Body.String() != want {
+		t.Errorf("expected %s, got %s", want, w.Body.String())
+	}
+	prefs, err := Load(db, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prefs != (Preferences{Sort: "-updated_at"}) {
+		t.Errorf("expected the replaced preferences, got %+v", prefs)
+	}
+	if prefs, _ := Load(db, 3); prefs != (Preferences{}) {
+		t.Errorf("expected no preferences for a user without settings, got %+v", prefs)
+	}
+}
+
+// TestSettings_Envelope: with the envelope, settings come in data; their own ids are never rewritten
+func TestSettings_Envelope(t *testing.T) {
+	_, r := setupShapedHandler(t, response.Options{Envelope: true, IDsAsStrings: true})
+
+	want := `{"data":{"theme":"dark","id":7},"meta":{}}`
+	if w := doSettings(r, http.MethodPut, "1", `{"theme": "dark", "id": 7}`); w.Body.String() != want {
+		t.Errorf("expected %s, got %s", want, w.Body.String())
+	}
+	if w := doSettings(r, http.MethodGet, "1", ""); w.Body.String() != want {
+		t.Errorf("expected %s, got %s", want, w.Body.String())
+	}
+}
+
+// TestSettings_Invalid: malformed JSON is 400; non-objects, oversized objects and bad preferences are 422
+func TestSettings_Invalid(t *testing.T) {
+	_, r := setupHandler(t)
+
+	if w := doSettings(r, http.MethodPut, "1", `{"sort": `); w.Code != http.StatusBadRequest {
+		t.Errorf("malformed: expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	for _, body := range []string{
+		`[1, 2]`,
+		`null`,
+		`{"sort": "title"}`,
+		`{"sort": 1}`,
+		`{"page_size": 0}`,
+		`{"page_size": "ten"}`,
+		`{"blob": "` + strings.Repeat("x", MaxSize) + `"}`,
+	} {
+		if w := doSettings(r, http.MethodPut, "1", body); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%.30s: expected status %d, got %d", body, http.StatusUnprocessableEntity, w.Code)
+		}
+	}
+	if w := doSettings(r, http.MethodGet, "1", ""); w.Body.String() != "{}" {
+		t.Errorf("expected nothing to be saved, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,136 @@
+// Package testutil starts a complete copy of the API, with every route and
+// middleware, for integration tests in any package.
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/app"
+	"github.com/pradist/todoapi/auth"
+	"github.com/pradist/todoapi/middleware"
+	"golang.org/x/time/rate"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Password is the password of every user created by CreateUser.
+const Password = "password"
+
+// Server is a running API backed by an in-memory database. It is closed
+// automatically when the test ends.
+type Server struct {
+	*httptest.Server
+	DB     *gorm.DB
+	Config app.Config
+}
+
+// NewTestServer starts the full API with rate limiting disabled. Options
+// may adjust the configuration before the router is built.
+func NewTestServer(t testing.TB, options ...func(*app.Config)) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	// Every pooled connection to :memory: would get its own empty database.
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get database handle: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := app.Migrate(db); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	cfg := app.Config{
+		Sign:     "testutil-secret",
+		TokenTTL: 5 * time.Minute,
+		Limiter:  middleware.NewIPLimiter(rate.Inf, 0),
+	}
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	s := &Server{
+		Server: httptest.NewServer(app.NewRouter(db, cfg)),
+		DB:     db,
+		Config: cfg,
+	}
+	t.Cleanup(func() {
+		s.Close()
+		sqlDB.Close()
+	})
+	return s
+}
+
+// CreateUser adds a user with the given role and Password.
+func (s *Server) CreateUser(t testing.TB, username, role string) auth.User {
+	t.Helper()
+	hashed, err := auth.HashPassword(Password)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	user := auth.User{Username: username, Password: hashed, Role: role}
+	if err := s.DB.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user %q: %v", username, err)
+	}
+	return user
+}
+
+// Token creates a user with the given role and returns a valid token for
+// them, obtained from POST /tokenz.
+func (s *Server) Token(t testing.TB, username, role string) string {
+	t.Helper()
+	s.CreateUser(t, username, role)
+
+	resp := s.Do(t, http.MethodPost, "/tokenz", "", map[string]string{"username": username, "password": Password})
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 from /tokenz, got %d", resp.StatusCode)
+	}
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode token: %v", err)
+	}
+	return body.Token
+}
+
+// Do sends a request to the server. A non-empty token is sent as a bearer
+// token and a non-nil body is encoded as JSON. The caller closes the body.
+func (s *Server) Do(t testing.TB, method, path, token string, body any) *http.Response {
+	t.Helper()
+	var r io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("failed to encode body: %v", err)
+		}
+		r = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequest(method, s.URL+path, r)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	return resp
+}
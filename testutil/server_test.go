@@ -0,0 +1,71 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/pradist/todoapi/app"
+	"github.com/pradist/todoapi/auth"
+)
+
+func TestNewTestServer_Healthz(t *testing.T) {
+	s := NewTestServer(t)
+
+	resp := s.Do(t, http.MethodGet, "/healthz", "", nil)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+}
+
+// TestNewTestServer_TodoRoundTrip: a minted token works against the protected routes
+func TestNewTestServer_TodoRoundTrip(t *testing.T) {
+	s := NewTestServer(t)
+	token := s.Token(t, "alice", auth.RoleUser)
+
+	resp := s.Do(t, http.MethodPost, "/todos", token, map[string]any{"text": "buy milk"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", resp.StatusCode)
+	}
+
+	resp = s.Do(t, http.MethodGet, "/todos", token, nil)
+	defer resp.Body.Close()
+	var todos []map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&todos); err != nil {
+		t.Fatalf("failed to decode todos: %v", err)
+	}
+	if len(todos) != 1 || todos[0]["text"] != "buy milk" {
+		t.Errorf("expected the created todo, got %v", todos)
+	}
+}
+
+func TestNewTestServer_Roles(t *testing.T) {
+	s := NewTestServer(t)
+
+	for role, want := range map[string]int{
+		auth.RoleUser:  http.StatusForbidden,
+		auth.RoleAdmin: http.StatusOK,
+	} {
+		resp := s.Do(t, http.MethodGet, "/audit", s.Token(t, role+"-user", role), nil)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s: expected %d, got %d", role, want, resp.StatusCode)
+		}
+	}
+}
+
+func TestNewTestServer_Options(t *testing.T) {
+	s := NewTestServer(t, func(cfg *app.Config) {
+		cfg.SecureHeaders = true
+	})
+
+	resp := s.Do(t, http.MethodGet, "/healthz", "", nil)
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("X-Frame-Options"); got != "DENY" {
+		t.Errorf("expected the option to be applied, got X-Frame-Options %q", got)
+	}
+}
@@ -0,0 +1,101 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/pradist/todoapi/apperr"
+)
+
+// bindJSON decodes the request body into v. It writes an error response
+// with bindError and returns false on failure. Under StrictJSON, a field v
+// doesn't declare is a 400 naming the field.
+func (t *TodoHandler) bindJSON(c *gin.Context, v any) bool {
+	if err := t.decodeJSON(c, v); err != nil {
+		writeBindError(c, err)
+		return false
+	}
+	return true
+}
+
+// bindOptionalJSON is bindJSON for an optional body: an empty one leaves v
+// as it is.
+func (t *TodoHandler) bindOptionalJSON(c *gin.Context, v any) bool {
+	if err := t.decodeJSON(c, v); err != nil && !errors.Is(err, io.EOF) {
+		writeBindError(c, err)
+		return false
+	}
+	return true
+}
+
+func (t *TodoHandler) decodeJSON(c *gin.Context, v any) error {
+	if !t.cfg.StrictJSON {
+		return c.ShouldBindJSON(v)
+	}
+	if err := decodeStrict(c.Request.Body, v); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(v)
+}
+
+// writeBindError writes the error response for a body decodeJSON refused.
+func writeBindError(c *gin.Context, err error) {
+	if field, ok := unknownField(err); ok {
+		apperr.Write(c, apperr.ErrBadRequest.With("unknown field "+strconv.Quote(field)).WithDetail("field", field))
+		return
+	}
+	bindError(c, err)
+}
+
+// decodeStrict decodes r into v, rejecting fields v doesn't declare. A
+// todo is decoded through its wire form, as DisallowUnknownFields doesn't
+// reach into an UnmarshalJSON method.
+func decodeStrict(r io.Reader, v any) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	todo, ok := v.(*Todo)
+	if !ok {
+		return dec.Decode(v)
+	}
+	w := todo.wire()
+	if err := dec.Decode(w); err != nil {
+		return err
+	}
+	w.apply()
+	return nil
+}
+
+// unknownField returns the field named by a DisallowUnknownFields error.
+// encoding/json has no error type for it, only the message.
+func unknownField(err error) (string, bool) {
+	quoted, ok := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !ok {
+		return "", false
+	}
+	field, err := strconv.Unquote(quoted)
+	return field, err == nil
+}
+
+// bindError reports a failed bind. A body that can't be decoded gets 400;
+// one that decodes but breaks its binding rules gets 422. Arrays are
+// validated element by element and report a SliceValidationError.
+func bindError(c *gin.Context, err error) {
+	var verrs validator.ValidationErrors
+	var serrs binding.SliceValidationError
+	if errors.As(err, &verrs) || errors.As(err, &serrs) {
+		invalid(c, err)
+		return
+	}
+	apperr.Write(c, apperr.ErrBadRequest.Wrap(err))
+}
+
+// invalid writes a 422 for a well-formed request that breaks a rule.
+func invalid(c *gin.Context, err error) {
+	apperr.Write(c, apperr.ErrValidation.Wrap(err))
+}
@@ -0,0 +1,83 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// TestBindJSON_Lenient: by default unknown fields are ignored
+func TestBindJSON_Lenient(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+
+	w := doJSON(router, http.MethodPost, "/todos", `{"text": "Typo", "title": "Typo", "priority": "high"}`)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+}
+
+// TestBindJSON_Strict: under StrictJSON an unknown field is a 400 that names it
+func TestBindJSON_Strict(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	handler.cfg.StrictJSON = true
+	router.POST("/todos", handler.NewTask)
+	router.POST("/todos/:id/status", handler.AdvanceStatus)
+
+	w := doJSON(router, http.MethodPost, "/todos", `{"title": "Typo"}`)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	var response struct {
+		Error string `json:"error"`
+		Field string `json:"field"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.Field != "title" || response.Error != `unknown field "title"` {
+		t.Errorf("expected the error to name title, got %+v", response)
+	}
+
+	w = doJSON(router, http.MethodPost, "/todos", `{"text": "Known", "ID": "7", "completed": false, "priority": "high", "metadata": {"any": 1}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected known fields to be accepted, got %d: %s", w.Code, w.Body.String())
+	}
+	var created Todo
+	handler.db.First(&created)
+	if created.Priority != PriorityHigh || string(created.Metadata) != `{"any":1}` {
+		t.Errorf("expected the todo to be decoded, got priority %q metadata %s", created.Priority, created.Metadata)
+	}
+
+	w = doJSON(router, http.MethodPost, "/todos", `{"text": "Bad", "priority": "urgent"}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected binding rules to still apply, got %d", w.Code)
+	}
+	w = doJSON(router, http.MethodPost, "/todos/1/status", `{"status": "done", "note": "x"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected other endpoints to be strict too, got %d", w.Code)
+	}
+}
+
+// TestBindJSON_StrictOptionalBody: optional bodies are strict too, and may still be left out
+func TestBindJSON_StrictOptionalBody(t *testing.T) {
+	handler, router := setupTemplates(t)
+	handler.cfg.StrictJSON = true
+	router.DELETE("/todos/:id", handler.DeleteTask)
+	handler.db.Create(&Template{UserID: testUserID, Title: "Plain"})
+
+	if w := doJSON(router, http.MethodPost, "/todos/from-template/1", `{"due": "2030-01-01T00:00:00Z"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown template field to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(router, http.MethodPost, "/todos/from-template/1", ""); w.Code != http.StatusCreated {
+		t.Fatalf("expected an empty body to be accepted, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(router, http.MethodDelete, "/todos/1", `{"why": "done"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown delete field to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(router, http.MethodDelete, "/todos/1", ""); w.Code != http.StatusNoContent {
+		t.Errorf("expected an empty delete body to be accepted, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,255 @@
+package todo
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/audit"
+	"gorm.io/gorm"
+)
+
+// DefaultMaxBulkItems is the most items one bulk request may carry when
+// Config.MaxBulkItems is zero.
+const DefaultMaxBulkItems = 100
+
+func (t *TodoHandler) maxBulkItems() int {
+	if t.cfg.MaxBulkItems > 0 {
+		return t.cfg.MaxBulkItems
+	}
+	return DefaultMaxBulkItems
+}
+
+// checkBulkSize rejects a bulk request carrying n items, before any of them
+// reaches the database. It writes a 400 and returns false if n is over the
+// configured maximum, and a 422 if n is zero.
+func (t *TodoHandler) checkBulkSize(c *gin.Context, n int) bool {
+	if max := t.maxBulkItems(); n > max {
+		apperr.Write(c, apperr.ErrBadRequest.With(fmt.Sprintf("at most %d items may be sent at once, got %d", max, n)))
+		return false
+	}
+	if n == 0 {
+		invalid(c, errors.New("at least one item must be sent"))
+		return false
+	}
+	return true
+}
+
+type bulkUpdateRequest struct {
+	Filter Filter     `json:"filter"`
+	Set    bulkFields `json:"set"`
+}
+
+// bulkFields are the columns BulkUpdate may change. Nil fields are left
+// untouched.
+type bulkFields struct {
+	Completed *bool     `json:"completed"`
+	Priority  *string   `json:"priority"`
+	DueDate   *flexTime `json:"due_date"`
+}
+
+func (b bulkFields) validate() error {
+	if b.Priority != nil && !validPriority(*b.Priority) {
+		return errInvalidPriority
+	}
+	return nil
+}
+
+// check refuses b if it would reopen one of todos that is verified, as
+// stampCompletion does.
+func (b bulkFields) check(todos []Todo) error {
+	if b.Completed == nil || *b.Completed {
+		return nil
+	}
+	for _, todo := range todos {
+		if todo.Status == StatusVerified {
+			return fmt.Errorf("todo %d: %w", todo.ID, errReopenVerified)
+		}
+	}
+	return nil
+}
+
+// columns returns the column updates for b. Completing a todo stamps
+// completed_at and status only on rows that were not already complete.
+func (b bulkFields) columns() map[string]any {
+	updates := map[string]any{}
+	if b.Completed != nil {
+		updates["completed"] = *b.Completed
+		if *b.Completed {
+			updates["completed_at"] = gorm.Expr("CASE WHEN completed THEN completed_at ELSE ? END", time.Now().UTC())
+			updates["status"] = gorm.Expr("CASE WHEN completed THEN status ELSE ? END", StatusDone)
+		} else {
+			updates["completed_at"] = nil
+			updates["status"] = StatusOpen
+		}
+	}
+	if b.Priority != nil {
+		updates["priority"] = *b.Priority
+	}
+	if b.DueDate != nil {
+		updates["due_date"] = b.DueDate.UTC()
+	}
+	return updates
+}
+
+// BulkUpdate applies the same field changes to every todo of the caller
+// that matches the filter, in a single UPDATE. An empty filter is refused
+// unless ?all=true is passed, so a missing filter can't touch everything.
+// Each changed todo gets its own audit entry in the same transaction.
+func (t *TodoHandler) BulkUpdate(c *gin.Context) {
+	_, userID, ok := t.owned(c)
+	if !ok {
+		return
+	}
+
+	var req bulkUpdateRequest
+	if !t.bindJSON(c, &req) {
+		return
+	}
+	if err := cmp.Or(req.Filter.validate(), req.Set.validate(), t.checkDueDate(req.Set.DueDate.ptr(), nil)); err != nil {
+		invalid(c, err)
+		return
+	}
+
+	all, ok := allFlag(c)
+	if !ok {
+		return
+	}
+	if req.Filter.isEmpty() && !all {
+		invalid(c, errors.New("filter is empty; pass ?all=true to update every todo"))
+		return
+	}
+
+	updates := req.Set.columns()
+	if len(updates) == 0 {
+		invalid(c, errors.New("set must contain at least one field"))
+		return
+	}
+
+	updated, err := t.updateMatching(c, userID, req.Filter.apply, req.Set.check, updates)
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	t.respond(c, http.StatusOK, gin.H{"updated": updated})
+}
+
+// allFlag reads ?all=, which lets a bulk request with no filter apply to
+// every todo. It writes a 400 and returns false if the flag is invalid.
+func allFlag(c *gin.Context) (bool, bool) {
+	v := c.Query("all")
+	if v == "" {
+		return false, true
+	}
+	all, err := strconv.ParseBool(v)
+	if err != nil {
+		apperr.Write(c, apperr.ErrBadRequest.With("all must be true or false"))
+		return false, false
+	}
+	return all, true
+}
+
+// updateMatching applies updates to the todos of userID that scope selects,
+// in a single UPDATE, and returns how many changed. check, if given, may
+// refuse the selected todos, and nothing changes. Each changed todo gets
+// its own audit entry in the same transaction.
+func (t *TodoHandler) updateMatching(c *gin.Context, userID uint, scope func(*gorm.DB) *gorm.DB, check func([]Todo) error, updates map[string]any) (int64, error) {
+	var updated int64
+	err := t.transaction(c, func(tx *gorm.DB) error {
+		updated = 0
+		var before []Todo
+		if err := scope(tx.Where("user_id = ?", userID)).Order("id").Find(&before).Error; err != nil {
+			return err
+		}
+		if len(before) == 0 {
+			return nil
+		}
+		if check != nil {
+			if err := check(before); err != nil {
+				return err
+			}
+		}
+		ids := make([]uint, len(before))
+		for i, todo := range before {
+			ids[i] = todo.ID
+		}
+
+		r := tx.Model(&Todo{}).Where("id IN ?", ids).Updates(updates)
+		if r.Error != nil {
+			return r.Error
+		}
+		updated = r.RowsAffected
+
+		var after []Todo
+		if err := tx.Where("id IN ?", ids).Order("id").Find(&after).Error; err != nil {
+			return err
+		}
+		for i := range after {
+			if err := audit.Record(tx, audit.ActionUpdate, after[i].ID, userID, before[i], after[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return updated, err
+}
+
+// CompleteBy marks every incomplete todo of the caller that matches the
+// query complete, stamping completed_at, and returns how many it completed.
+// It takes the list filters other than completed, plus due_before for
+// todos due before an RFC 3339 time; due_before always applies, even with
+// match=any. As with BulkUpdate, no filter at all is refused unless
+// ?all=true is passed. A completed filter is refused, so it can't stand
+// in for one.
+func (t *TodoHandler) CompleteBy(c *gin.Context) {
+	_, userID, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	if _, ok := c.GetQuery("completed"); ok {
+		apperr.Write(c, apperr.ErrBadRequest.With("completed is not a filter of complete-by; it only completes incomplete todos"))
+		return
+	}
+	f, err := filterFromQuery(c)
+	if err != nil {
+		apperr.Write(c, apperr.ErrBadRequest.Wrap(err))
+		return
+	}
+	var dueBefore *time.Time
+	if raw, ok := c.GetQuery("due_before"); ok {
+		due, err := parseTime(raw)
+		if err != nil {
+			apperr.Write(c, apperr.ErrBadRequest.With("due_before must be an RFC 3339 timestamp or epoch milliseconds"))
+			return
+		}
+		dueBefore = &due
+	}
+	all, ok := allFlag(c)
+	if !ok {
+		return
+	}
+	if f.isEmpty() && dueBefore == nil && !all {
+		invalid(c, errors.New("no filter given; pass ?all=true to complete every todo"))
+		return
+	}
+
+	done := true
+	scope := func(q *gorm.DB) *gorm.DB {
+		q = f.apply(q.Where("completed = ?", false))
+		if dueBefore != nil {
+			q = q.Where("due_date < ?", dueBefore.UTC())
+		}
+		return q
+	}
+	completed, err := t.updateMatching(c, userID, scope, nil, bulkFields{Completed: &done}.columns())
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	t.respond(c, http.StatusOK, gin.H{"completed": completed})
+}
@@ -0,0 +1,281 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/audit"
+)
+
+func doBulkUpdate(t *testing.T, router *gin.Engine, query string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	jsonData, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/todos/bulk-update"+query, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func decodeUpdated(t *testing.T, w *httptest.ResponseRecorder) int {
+	t.Helper()
+	var resp struct {
+		Updated int `json:"updated"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return resp.Updated
+}
+
+// TestBulkUpdate_OverdueToHigh: every overdue todo of the caller becomes high priority
+func TestBulkUpdate_OverdueToHigh(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos/bulk-update", handler.BulkUpdate)
+	past := time.Now().Add(-time.Hour)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "late 1", DueDate: &past, Priority: PriorityLow})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "late 2", DueDate: &past, Priority: PriorityMedium})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "no deadline", Priority: PriorityLow})
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "someone else's", DueDate: &past, Priority: PriorityLow})
+
+	w := doBulkUpdate(t, router, "", map[string]any{
+		"filter": map[string]any{"overdue": true},
+		"set":    map[string]any{"priority": "high"},
+	})
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if n := decodeUpdated(t, w); n != 2 {
+		t.Errorf("expected 2 updated, got %d", n)
+	}
+
+	var high int64
+	handler.db.Model(&Todo{}).Where("priority = ?", PriorityHigh).Count(&high)
+	if high != 2 {
+		t.Errorf("expected 2 high priority todos, got %d", high)
+	}
+	var other Todo
+	handler.db.First(&other, 4)
+	if other.Priority != PriorityLow {
+		t.Errorf("expected another user's todo to be untouched, got %q", other.Priority)
+	}
+}
+
+// TestBulkUpdate_CompleteStampsCompletedAt: completing in bulk stamps completed_at
+func TestBulkUpdate_CompleteStampsCompletedAt(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos/bulk-update", handler.BulkUpdate)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "a", Priority: PriorityLow})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "b", Priority: PriorityHigh})
+
+	w := doBulkUpdate(t, router, "", map[string]any{
+		"filter": map[string]any{"priority": "low"},
+		"set":    map[string]any{"completed": true},
+	})
+
+	if n := decodeUpdated(t, w); n != 1 {
+		t.Fatalf("expected 1 updated, got %d", n)
+	}
+	var done Todo
+	handler.db.First(&done, 1)
+	if !done.Completed || done.CompletedAt == nil {
+		t.Errorf("expected completed todo with completed_at, got %+v", done)
+	}
+}
+
+// TestBulkUpdate_EmptyFilterRequiresAll: an empty filter is refused unless ?all=true
+func TestBulkUpdate_EmptyFilterRequiresAll(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos/bulk-update", handler.BulkUpdate)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "a", Priority: PriorityLow})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "b", Priority: PriorityLow})
+	body := map[string]any{"set": map[string]any{"priority": "high"}}
+
+	w := doBulkUpdate(t, router, "", body)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d without all=true, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+
+	w = doBulkUpdate(t, router, "?all=true", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d with all=true, got %d", http.StatusOK, w.Code)
+	}
+	if n := decodeUpdated(t, w); n != 2 {
+		t.Errorf("expected 2 updated, got %d", n)
+	}
+}
+
+// TestBulkUpdate_InvalidRequests: malformed input is 400, well-formed but invalid bodies are 422
+func TestBulkUpdate_InvalidRequests(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos/bulk-update", handler.BulkUpdate)
+
+	tests := []struct {
+		name  string
+		query string
+		body  any
+		want  int
+	}{
+		{"empty set", "", map[string]any{"filter": map[string]any{"completed": false}}, http.StatusUnprocessableEntity},
+		{"bad filter priority", "", map[string]any{"filter": map[string]any{"priority": "x"}, "set": map[string]any{"completed": true}}, http.StatusUnprocessableEntity},
+		{"bad set priority", "", map[string]any{"filter": map[string]any{"completed": false}, "set": map[string]any{"priority": "x"}}, http.StatusUnprocessableEntity},
+		{"bad all", "?all=sure", map[string]any{"set": map[string]any{"completed": true}}, http.StatusBadRequest},
+		{"malformed", "", "not an object", http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doBulkUpdate(t, router, tc.query, tc.body)
+			if w.Code != tc.want {
+				t.Errorf("expected status %d, got %d", tc.want, w.Code)
+			}
+		})
+	}
+}
+
+// TestBulkUpdate_RecordsAuditPerTodo: each updated todo gets its own audit entry
+func TestBulkUpdate_RecordsAuditPerTodo(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos/bulk-update", handler.BulkUpdate)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "a", Priority: PriorityLow})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "b", Priority: PriorityLow})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "c", Priority: PriorityHigh})
+
+	doBulkUpdate(t, router, "", map[string]any{
+		"filter": map[string]any{"priority": "low"},
+		"set":    map[string]any{"priority": "medium"},
+	})
+
+	var entries []audit.Log
+	handler.db.Order("todo_id").Find(&entries)
+	if len(entries) != 2 || entries[0].TodoID != 1 || entries[1].TodoID != 2 {
+		t.Fatalf("expected audit entries for todos 1 and 2, got %+v", entries)
+	}
+	for _, e := range entries {
+		if e.Action != audit.ActionUpdate {
+			t.Errorf("expected update action, got %q", e.Action)
+		}
+	}
+}
+
+// TestCheckBulkSize_OverLimit: bulk requests over MaxBulkItems are a 400 on every bulk endpoint, with nothing saved
+func TestCheckBulkSize_OverLimit(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	handler.cfg.MaxBulkItems = 2
+	router.POST("/todos/sync", handler.Sync)
+	router.POST("/todos/status", handler.Freshness)
+
+	for path, body := range map[string]string{
+		"/todos/sync":   `[{"id": 1, "text": "a"}, {"id": 2, "text": "b"}, {"id": 3, "text": "c"}]`,
+		"/todos/status": `{"ids": [1, 2, 3]}`,
+	} {
+		if w := doJSON(router, http.MethodPost, path, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusBadRequest, w.Code)
+		}
+	}
+	var count int64
+	handler.db.Model(&Todo{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected nothing to be saved, found %d todos", count)
+	}
+
+	if w := doJSON(router, http.MethodPost, "/todos/status", `{"ids": [1, 2]}`); w.Code != http.StatusOK {
+		t.Errorf("expected status %d at the limit, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func doCompleteBy(router *gin.Engine, query string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/todos/complete-by"+query, nil))
+	return w
+}
+
+// TestCompleteBy_FilteredSubset: only the caller's incomplete todos matching the priority and due_before are completed
+func TestCompleteBy_FilteredSubset(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos/complete-by", handler.CompleteBy)
+	past := time.Now().Add(-48 * time.Hour)
+	soon := time.Now().Add(48 * time.Hour)
+	earlier := time.Now().Add(-72 * time.Hour)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "low, late", DueDate: &past, Priority: PriorityLow})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "low, later", DueDate: &soon, Priority: PriorityLow})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "high, late", DueDate: &past, Priority: PriorityHigh})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "low, done", DueDate: &past, Priority: PriorityLow, Completed: true, CompletedAt: &earlier})
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "someone else's", DueDate: &past, Priority: PriorityLow})
+
+	w := doCompleteBy(router, "?priority=low&due_before="+time.Now().Format(time.RFC3339))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp struct {
+		Completed int `json:"completed"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Completed != 1 {
+		t.Errorf("expected 1 completed, got %d", resp.Completed)
+	}
+
+	var todos []Todo
+	handler.db.Order("id").Find(&todos)
+	for _, todo := range todos {
+		wantDone := todo.ID == 1 || todo.ID == 4
+		if todo.Completed != wantDone {
+			t.Errorf("%s: expected completed %v, got %v", todo.Title, wantDone, todo.Completed)
+		}
+		if wantDone && todo.CompletedAt == nil {
+			t.Errorf("%s: expected completed_at to be set", todo.Title)
+		}
+	}
+	if !todos[3].CompletedAt.Equal(earlier.UTC()) {
+		t.Errorf("expected an already completed todo to keep completed_at %s, got %s", earlier, todos[3].CompletedAt)
+	}
+	if got := auditActions(t, handler); len(got) != 1 {
+		t.Errorf("expected 1 audit entry, got %v", got)
+	}
+}
+
+// TestCompleteBy_RequiresFilter: no filter is refused unless all=true, completed doesn't count as one, and bad parameters are rejected
+func TestCompleteBy_RequiresFilter(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos/complete-by", handler.CompleteBy)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "a", Priority: PriorityLow})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "b", Priority: PriorityHigh})
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", http.StatusUnprocessableEntity},
+		{"?all=false", http.StatusUnprocessableEntity},
+		{"?all=maybe", http.StatusBadRequest},
+		{"?due_before=yesterday", http.StatusBadRequest},
+		{"?priority=urgent", http.StatusBadRequest},
+		{"?completed=false", http.StatusBadRequest},
+		{"?completed=true&priority=low", http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		if w := doCompleteBy(router, tc.query); w.Code != tc.want {
+			t.Errorf("%q: expected status %d, got %d", tc.query, tc.want, w.Code)
+		}
+	}
+	var done int64
+	handler.db.Model(&Todo{}).Where("completed = ?", true).Count(&done)
+	if done != 0 {
+		t.Fatalf("expected rejected requests to complete nothing, got %d", done)
+	}
+
+	if w := doCompleteBy(router, "?all=true"); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	handler.db.Model(&Todo{}).Where("completed = ?", true).Count(&done)
+	if done != 2 {
+		t.Errorf("expected all=true to complete every todo, got %d", done)
+	}
+}
@@ -0,0 +1,99 @@
+package todo
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/auth"
+)
+
+type deleteRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// DeleteTask soft-deletes one of the caller's todos. The body is optional;
+// when it carries a reason, the reason is stored with the todo before it is
+// deleted and shown in the trash. Deleting is idempotent, so a retried
+// request succeeds: a todo already in the trash is left as it is, and an id
+// the caller never had is a 204 too unless DeleteNotFound is set.
+func (t *TodoHandler) DeleteTask(c *gin.Context) {
+	_, userID, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var req deleteRequest
+	if !t.bindOptionalJSON(c, &req) {
+		return
+	}
+
+	err := t.Delete(c.Request.Context(), userID, id, req.Reason)
+	if errors.Is(err, errTodoNotFound) && !t.cfg.DeleteNotFound {
+		err = nil
+	}
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListTrash returns the caller's deleted todos, most recently deleted first.
+func (t *TodoHandler) ListTrash(c *gin.Context) {
+	q, _, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	p, ok := t.page(c)
+	if !ok {
+		return
+	}
+	if !selectFields(c) || !selectZone(c) || !selectTruncate(c) {
+		return
+	}
+
+	todos := []Todo{}
+	err := t.retry(c, func() error {
+		return q.Unscoped().Where("deleted_at IS NOT NULL").
+			Order("deleted_at DESC").Order("id DESC").
+			Limit(p.Limit).Offset(p.Offset()).Find(&todos).Error
+	})
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	t.respond(c, http.StatusOK, todos)
+}
+
+// includeDeleted reads ?include_deleted=true, which returns deleted todos
+// alongside live ones with deleted_at set, as adminFlag does.
+func includeDeleted(c *gin.Context) (bool, bool) {
+	return adminFlag(c, "include_deleted")
+}
+
+// adminFlag reads the boolean query parameter name, which only admins may
+// set to true. It writes a 400 or 403 and returns false if the flag is
+// invalid or not allowed.
+func adminFlag(c *gin.Context, name string) (bool, bool) {
+	raw, ok := c.GetQuery(name)
+	if !ok {
+		return false, true
+	}
+	flag, err := strconv.ParseBool(raw)
+	if err != nil {
+		apperr.Write(c, apperr.ErrBadRequest.With(name+" must be a boolean"))
+		return false, false
+	}
+	if flag && !auth.HasRole(c, auth.RoleAdmin) {
+		apperr.Write(c, apperr.ErrForbidden.With(name+" requires the admin role"))
+		return false, false
+	}
+	return flag, true
+}
@@ -0,0 +1,245 @@
+package todo
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/pradist/todoapi/audit"
+	"github.com/pradist/todoapi/auth"
+)
+
+func doDelete(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodDelete, path, bytes.NewBufferString(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func setupDeleteHandler(t *testing.T) (*TodoHandler, *gin.Engine) {
+	t.Helper()
+	handler, router := setupTestHandler(t)
+	router.DELETE("/todos/:id", handler.DeleteTask)
+	router.GET("/todos/trash", handler.ListTrash)
+	router.GET("/todos/:id", handler.GetTask)
+	return handler, router
+}
+
+// TestDeleteTask_WithReason: the reason is stored and shown in the trash
+func TestDeleteTask_WithReason(t *testing.T) {
+	handler, router := setupDeleteHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Old plan"})
+
+	w := doDelete(router, "/todos/1", `{"reason": "  plans changed "}`)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if w := doList(t, router, "/1"); w.Code != http.StatusNotFound {
+		t.Errorf("expected deleted todo to be gone, got %d", w.Code)
+	}
+
+	trash := decodeTodos(t, doList(t, router, "/trash"))
+	if len(trash) != 1 {
+		t.Fatalf("expected 1 todo in the trash, got %d", len(trash))
+	}
+	if trash[0].DeleteReason != "plans changed" {
+		t.Errorf("expected reason 'plans changed', got %q", trash[0].DeleteReason)
+	}
+	if !trash[0].DeletedAt.Valid {
+		t.Error("expected DeletedAt to be set")
+	}
+}
+
+// TestDeleteTask_NoBody: the reason is optional
+func TestDeleteTask_NoBody(t *testing.T) {
+	handler, router := setupDeleteHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Old plan"})
+
+	if w := doDelete(router, "/todos/1", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	trash := decodeTodos(t, doList(t, router, "/trash"))
+	if len(trash) != 1 || trash[0].DeleteReason != "" {
+		t.Errorf("expected one trashed todo without a reason, got %+v", trash)
+	}
+}
+
+func TestDeleteTask_InvalidBody(t *testing.T) {
+	handler, router := setupDeleteHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Keep me"})
+
+	if w := doDelete(router, "/todos/1", `{"reason": `); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := doDelete(router, "/todos/1", `{"reason": "`+strings.Repeat("x", 501)+`"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d for an overlong reason, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+// TestDeleteTask_Twice: deleting again succeeds and leaves the todo as the first delete did
+func TestDeleteTask_Twice(t *testing.T) {
+	handler, router := setupDeleteHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Old plan"})
+
+	for i := range 2 {
+		if w := doDelete(router, "/todos/1", `{"reason": "attempt `+strconv.Itoa(i+1)+`"}`); w.Code != http.StatusNoContent {
+			t.Fatalf("delete %d: expected status %d, got %d", i+1, http.StatusNoContent, w.Code)
+		}
+	}
+
+	trash := decodeTodos(t, doList(t, router, "/trash"))
+	if len(trash) != 1 || trash[0].DeleteReason != "attempt 1" {
+		t.Errorf("expected one trashed todo with the first reason, got %+v", trash)
+	}
+	if actions := auditActions(t, handler); len(actions) != 1 {
+		t.Errorf("expected one audited delete, got %v", actions)
+	}
+}
+
+// TestDeleteTask_Missing: ids the caller never had are 204, or 404 under DeleteNotFound
+func TestDeleteTask_Missing(t *testing.T) {
+	handler, router := setupDeleteHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "Not yours"})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Mine"})
+	doDelete(router, "/todos/2", "")
+
+	for _, path := range []string{"/todos/1", "/todos/99"} {
+		if w := doDelete(router, path, ""); w.Code != http.StatusNoContent {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusNoContent, w.Code)
+		}
+	}
+	var other Todo
+	if err := handler.db.First(&other, 1).Error; err != nil {
+		t.Errorf("expected another user's todo to be untouched: %v", err)
+	}
+
+	handler.cfg.DeleteNotFound = true
+	for path, want := range map[string]int{"/todos/1": http.StatusNotFound, "/todos/99": http.StatusNotFound, "/todos/2": http.StatusNoContent} {
+		if w := doDelete(router, path, ""); w.Code != want {
+			t.Errorf("DeleteNotFound %s: expected status %d, got %d", path, want, w.Code)
+		}
+	}
+}
+
+func TestDeleteTask_RecordsAudit(t *testing.T) {
+	handler, router := setupDeleteHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Old plan"})
+
+	doDelete(router, "/todos/1", "")
+
+	if actions := auditActions(t, handler); len(actions) != 1 || actions[0] != audit.ActionDelete {
+		t.Errorf("expected [delete], got %v", actions)
+	}
+}
+
+// TestListTrash_OnlyOwnDeleted: the trash holds only the caller's deleted todos
+func TestListTrash_OnlyOwnDeleted(t *testing.T) {
+	handler, router := setupDeleteHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "active"})
+	mine := Todo{UserID: testUserID, Title: "deleted"}
+	theirs := Todo{UserID: testUserID + 1, Title: "someone else's"}
+	handler.db.Create(&mine)
+	handler.db.Create(&theirs)
+	handler.db.Delete(&mine)
+	handler.db.Delete(&theirs)
+
+	trash := decodeTodos(t, doList(t, router, "/trash"))
+	if len(trash) != 1 || trash[0].Title != "deleted" {
+		t.Errorf("expected only the caller's deleted todo, got %+v", trash)
+	}
+}
+
+// asAdmin is asUser with the admin role.
+func asAdmin(userID uint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth.SetClaims(c, &auth.Claims{
+			StandardClaims: jwt.StandardClaims{Subject: strconv.FormatUint(uint64(userID), 10)},
+			Roles:          []string{auth.RoleAdmin},
+		})
+	}
+}
+
+// TestIncludeDeleted_Admin: admins see deleted todos inline, marked by deleted_at
+func TestIncludeDeleted_Admin(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+	router := gin.New()
+	router.Use(asAdmin(testUserID))
+	router.GET("/todos", handler.ListTasks)
+	router.GET("/todos/:id", handler.GetTask)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "active"})
+	deleted := Todo{UserID: testUserID, Title: "deleted"}
+	handler.db.Create(&deleted)
+	handler.db.Delete(&deleted)
+
+	if todos := decodeTodos(t, doList(t, router, "")); len(todos) != 1 {
+		t.Fatalf("expected deleted todos hidden by default, got %+v", todos)
+	}
+	todos := decodeTodos(t, doList(t, router, "?include_deleted=true"))
+	if len(todos) != 2 {
+		t.Fatalf("expected 2 todos, got %+v", todos)
+	}
+	if todos[0].DeletedAt.Valid || !todos[1].DeletedAt.Valid {
+		t.Errorf("expected only the deleted todo to carry deleted_at, got %+v", todos)
+	}
+
+	if w := doList(t, router, "/2?include_deleted=true"); w.Code != http.StatusOK {
+		t.Errorf("expected status %d getting a deleted todo, got %d", http.StatusOK, w.Code)
+	}
+}
+
+// TestIncludeDeleted_Denied: non-admins get 403, and a malformed flag is 400
+func TestIncludeDeleted_Denied(t *testing.T) {
+	handler, router := setupDeleteHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	deleted := Todo{UserID: testUserID, Title: "deleted"}
+	handler.db.Create(&deleted)
+	handler.db.Delete(&deleted)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"?include_deleted=true", http.StatusForbidden},
+		{"/1?include_deleted=true", http.StatusForbidden},
+		{"?include_deleted=false", http.StatusOK},
+		{"?include_deleted=maybe", http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		if w := doList(t, router, tc.query); w.Code != tc.want {
+			t.Errorf("%s: expected status %d, got %d", tc.query, tc.want, w.Code)
+		}
+	}
+}
+
+// TestDeleteReason_NotSettable: create and PUT ignore a delete_reason sent with a live todo
+func TestDeleteReason_NotSettable(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+	router.PUT("/todos/:id", handler.PutTask)
+
+	w := doJSON(router, http.MethodPost, "/todos", `{"text": "a", "delete_reason": "x"}`)
+	if w.Code != http.StatusCreated || strings.Contains(w.Body.String(), "delete_reason") {
+		t.Fatalf("expected the todo without a delete reason, got %d: %s", w.Code, w.Body.String())
+	}
+	w = doJSON(router, http.MethodPut, "/todos/2", `{"text": "b", "delete_reason": "y"}`)
+	if w.Code != http.StatusCreated || strings.Contains(w.Body.String(), "delete_reason") {
+		t.Fatalf("expected the todo without a delete reason, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var reasons []string
+	handler.db.Model(&Todo{}).Pluck("delete_reason", &reasons)
+	for _, reason := range reasons {
+		if reason != "" {
+			t.Errorf("expected no stored delete reason, got %q", reason)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package todo
+
+import (
+	"time"
+
+	"github.com/pradist/todoapi/apperr"
+)
+
+// pastDueTolerance allows for clocks of clients and server that disagree
+// by up to a minute.
+const pastDueTolerance = time.Minute
+
+var errPastDue = apperr.ErrValidation.With("due_date must not be in the past")
+
+// checkDueDate returns errPastDue when Config.RejectPastDue is on and due
+// lies in the past. A todo that keeps its stored due date (previous) is
+// accepted even if that date has since passed, so overdue todos can still
+// be edited.
+func (s *TodoService) checkDueDate(due, previous *time.Time) error {
+	if !s.cfg.RejectPastDue || due == nil {
+		return nil
+	}
+	if previous != nil && previous.Equal(*due) {
+		return nil
+	}
+	if due.Before(time.Now().Add(-pastDueTolerance)) {
+		return errPastDue
+	}
+	return nil
+}
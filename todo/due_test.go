@@ -0,0 +1,75 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func doWithDue(router *gin.Engine, method, path string, due time.Time) *httptest.ResponseRecorder {
+	jsonData, _ := json.Marshal(map[string]any{"text": "Pay rent", "due_date": due})
+	req := httptest.NewRequest(method, path, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestRejectPastDue_Create: past dates are 422; now (within the skew tolerance) and later are accepted
+func TestRejectPastDue_Create(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	handler.cfg.RejectPastDue = true
+	router.POST("/todos", handler.NewTask)
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		due  time.Time
+		want int
+	}{
+		{"past", now.Add(-time.Hour), http.StatusUnprocessableEntity},
+		{"now", now, http.StatusCreated},
+		{"slightly behind", now.Add(-pastDueTolerance / 2), http.StatusCreated},
+		{"future", now.Add(time.Hour), http.StatusCreated},
+	}
+	for _, tc := range tests {
+		w := doWithDue(router, http.MethodPost, "/todos", tc.due)
+		if w.Code != tc.want {
+			t.Errorf("%s: expected status %d, got %d: %s", tc.name, tc.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestRejectPastDue_Off: past dates are accepted unless the option is on
+func TestRejectPastDue_Off(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+
+	if w := doWithDue(router, http.MethodPost, "/todos", time.Now().Add(-time.Hour)); w.Code != http.StatusCreated {
+		t.Errorf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+// TestRejectPastDue_Put: an overdue todo can be edited while it keeps its due date
+func TestRejectPastDue_Put(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	handler.cfg.RejectPastDue = true
+	router.PUT("/todos/:id", handler.PutTask)
+	overdue := time.Now().Add(-24 * time.Hour).UTC().Truncate(time.Second)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Overdue", DueDate: &overdue})
+
+	if w := doWithDue(router, http.MethodPut, "/todos/1", overdue); w.Code != http.StatusOK {
+		t.Errorf("expected the unchanged due date to be accepted, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doWithDue(router, http.MethodPut, "/todos/1", overdue.Add(time.Hour)); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected a new past due date to be rejected, got %d", w.Code)
+	}
+	if w := doWithDue(router, http.MethodPut, "/todos/2", overdue); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected creating with a past due date to be rejected, got %d", w.Code)
+	}
+}
@@ -0,0 +1,76 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRespond_Envelope: with ResponseEnvelope, todos are wrapped in data with the page and request id in meta
+func TestRespond_Envelope(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	handler.cfg.ResponseEnvelope = true
+	router.GET("/todos", handler.ListTasks)
+	router.GET("/todos/:id", handler.GetTask)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "First"})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Second"})
+
+	req := httptest.NewRequest(http.MethodGet, "/todos?page=1&limit=5", nil)
+	req.Header.Set("X-Request-Id", "req-42")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var list struct {
+		Data []Todo         `json:"data"`
+		Meta map[string]any `json:"meta"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(list.Data) != 2 || list.Data[0].Title != "First" {
+		t.Errorf("expected both todos in data, got %+v", list.Data)
+	}
+	want := map[string]any{"page": 1.0, "limit": 5.0, "count": 2.0, "request_id": "req-42"}
+	for k, v := range want {
+		if list.Meta[k] != v {
+			t.Errorf("expected meta %s = %v, got %v", k, v, list.Meta[k])
+		}
+	}
+
+	w = doList(t, router, "/1")
+	var single struct {
+		Data Todo           `json:"data"`
+		Meta map[string]any `json:"meta"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &single); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if single.Data.Title != "First" || len(single.Meta) != 0 {
+		t.Errorf("expected the todo in data and an empty meta, got %+v and %v", single.Data, single.Meta)
+	}
+}
+
+// TestRespond_NoEnvelope: by default todos are returned bare, and errors never get the envelope
+func TestRespond_NoEnvelope(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "First"})
+
+	if todos := decodeTodos(t, doList(t, router, "")); len(todos) != 1 {
+		t.Errorf("expected a bare array of 1 todo, got %+v", todos)
+	}
+
+	handler.cfg.ResponseEnvelope = true
+	w := doList(t, router, "?page=0")
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if w.Code != http.StatusBadRequest || body["error"] == nil || body["data"] != nil {
+		t.Errorf("expected a plain 400 error, got %d %v", w.Code, body)
+	}
+}
@@ -0,0 +1,123 @@
+package todo
+
+import (
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"gorm.io/gorm"
+)
+
+var (
+	errStale         = apperr.ErrPreconditionFailed.With("todo has changed since it was read")
+	errMatchRequired = apperr.ErrPreconditionRequired.With("If-Match is required to replace a todo")
+)
+
+// etag identifies the representation of todo the request gets: the stored
+// version, followed by a hash of what shapes the body (the Accept header and
+// the fields, tz and truncate parameters). The tag is strong, so bodies that
+// differ never share it.
+func etag(c *gin.Context, todo Todo) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%s|%s|%s", c.GetHeader("Accept"),
+		c.Query("fields"), c.Query("tz"), c.Query("truncate"))
+	return fmt.Sprintf(`"%s-%x"`, version(todo), h.Sum64())
+}
+
+// version identifies the stored version of todo. It changes whenever the
+// todo is saved.
+func version(todo Todo) string {
+	return fmt.Sprintf("%d-%x", todo.ID, todo.UpdatedAt.UnixNano())
+}
+
+func setETag(c *gin.Context, todo Todo) {
+	c.Header("ETag", etag(c, todo))
+}
+
+// entityTag is one entry of an If-Match or If-None-Match list.
+type entityTag struct {
+	weak bool
+	// opaque is the quoted tag, or "*" for the wildcard.
+	opaque string
+}
+
+// parseETags splits an If-Match or If-None-Match header value into its
+// entity tags. Tags are read quote to quote, as they may hold commas; a
+// malformed entry ends the list.
+func parseETags(header string) []entityTag {
+	var tags []entityTag
+	for {
+		header = strings.TrimLeft(header, " \t,")
+		if header == "" {
+			return tags
+		}
+		if rest, ok := strings.CutPrefix(header, "*"); ok {
+			tags = append(tags, entityTag{opaque: "*"})
+			header = rest
+			continue
+		}
+		var tag entityTag
+		header, tag.weak = strings.CutPrefix(header, "W/")
+		if !strings.HasPrefix(header, `"`) {
+			return tags
+		}
+		end := strings.IndexByte(header[1:], '"')
+		if end < 0 {
+			return tags
+		}
+		tag.opaque, header = header[:end+2], header[end+2:]
+		tags = append(tags, tag)
+	}
+}
+
+// matches reports whether an If-Match header value accepts current: "*"
+// accepts any todo, otherwise one of the listed tags must be an ETag of its
+// stored version, in whichever representation it was read. If-Match
+// compares strongly, so weak tags never match.
+func matches(ifMatch string, current Todo) bool {
+	want := `"` + version(current) + "-"
+	for _, tag := range parseETags(ifMatch) {
+		if tag.opaque == "*" || !tag.weak && strings.HasPrefix(tag.opaque, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectionETag identifies the state of every todo userID owns, deleted
+// ones included, as seen by this request. The tag is weak: equal tags mean
+// the same todos, not byte-identical bodies. Creating, updating, deleting,
+// purging or transferring a todo changes its count or latest timestamps,
+// and the query, the Accept header and view, which describes anything else
+// the list depends on, are mixed in so each representation of the list gets
+// its own tag.
+func collectionETag(c *gin.Context, db *gorm.DB, userID uint, view string) (string, error) {
+	var count int64
+	var updated, deleted sql.NullString
+	err := db.Unscoped().Model(&Todo{}).Where("user_id = ?", userID).
+		Select("COUNT(*), MAX(updated_at), MAX(deleted_at)").
+		Row().Scan(&count, &updated, &deleted)
+	if err != nil {
+		return "", err
+	}
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d|%s|%s|%s|%s|%s", count, updated.String, deleted.String,
+		c.Request.URL.RawQuery, c.GetHeader("Accept"), view)
+	return fmt.Sprintf(`W/"c%d-%x"`, count, h.Sum64()), nil
+}
+
+// noneMatch reports whether an If-None-Match header value lists tag, so
+// the client's copy is current. If-None-Match compares weakly: W/ is
+// ignored on both sides.
+func noneMatch(ifNoneMatch, tag string) bool {
+	want := strings.TrimPrefix(tag, "W/")
+	for _, candidate := range parseETags(ifNoneMatch) {
+		if candidate.opaque == "*" || candidate.opaque == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,299 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupETagHandler(t *testing.T) (*TodoHandler, *gin.Engine) {
+	t.Helper()
+	handler, router := setupTestHandler(t)
+	router.GET("/todos/:id", handler.GetTask)
+	router.PUT("/todos/:id", handler.PutTask)
+	return handler, router
+}
+
+func doPutIfMatch(router *gin.Engine, path, title, ifMatch string) *httptest.ResponseRecorder {
+	jsonData, _ := json.Marshal(map[string]any{"text": title})
+	req := httptest.NewRequest(http.MethodPut, path, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func storedTitle(t *testing.T, handler *TodoHandler, id uint) string {
+	t.Helper()
+	var todo Todo
+	if err := handler.db.First(&todo, id).Error; err != nil {
+		t.Fatalf("failed to load todo %d: %v", id, err)
+	}
+	return todo.Title
+}
+
+// TestPutTask_IfMatch: the ETag from GET allows one update, after which it is stale
+func TestPutTask_IfMatch(t *testing.T) {
+	handler, router := setupETagHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Original"})
+
+	tag := doList(t, router, "/1").Header().Get("ETag")
+	if tag == "" {
+		t.Fatal("expected GET to return an ETag")
+	}
+
+	w := doPutIfMatch(router, "/todos/1", "First edit", tag)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	newTag := w.Header().Get("ETag")
+	if newTag == "" || newTag == tag {
+		t.Errorf("expected a new ETag after the update, got %q", newTag)
+	}
+	if got := doList(t, router, "/1").Header().Get("ETag"); got != newTag {
+		t.Errorf("expected GET to return the PUT's ETag %q, got %q", newTag, got)
+	}
+
+	if w := doPutIfMatch(router, "/todos/1", "Lost update", tag); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected status %d for a stale ETag, got %d", http.StatusPreconditionFailed, w.Code)
+	}
+	if got := storedTitle(t, handler, 1); got != "First edit" {
+		t.Errorf("expected the stale update to be rejected, got %q", got)
+	}
+}
+
+// TestPutTask_IfMatchList: any listed ETag or * matches; weak tags never do
+func TestPutTask_IfMatchList(t *testing.T) {
+	handler, router := setupETagHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Original"})
+	tag := doList(t, router, "/1").Header().Get("ETag")
+
+	tests := []struct {
+		ifMatch string
+		want    int
+	}{
+		{`"other", ` + tag, http.StatusOK},
+		{"*", http.StatusOK},
+		{"W/" + doList(t, router, "/1").Header().Get("ETag"), http.StatusPreconditionFailed},
+	}
+	for _, tc := range tests {
+		if w := doPutIfMatch(router, "/todos/1", "Edit", tc.ifMatch); w.Code != tc.want {
+			t.Errorf("If-Match %s: expected status %d, got %d", tc.ifMatch, tc.want, w.Code)
+		}
+	}
+}
+
+// TestPutTask_IfMatchMissingTodo: If-Match never creates a todo
+func TestPutTask_IfMatchMissingTodo(t *testing.T) {
+	handler, router := setupETagHandler(t)
+
+	if w := doPutIfMatch(router, "/todos/7", "New", "*"); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected status %d, got %d", http.StatusPreconditionFailed, w.Code)
+	}
+	var count int64
+	handler.db.Model(&Todo{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected nothing to be created, found %d todos", count)
+	}
+}
+
+// TestPutTask_RequireIfMatch: replacing needs If-Match when configured, creating does not
+func TestPutTask_RequireIfMatch(t *testing.T) {
+	handler, router := setupETagHandler(t)
+	handler.cfg.RequireIfMatch = true
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Original"})
+
+	if w := doPutIfMatch(router, "/todos/1", "Blind edit", ""); w.Code != http.StatusPreconditionRequired {
+		t.Errorf("expected status %d, got %d", http.StatusPreconditionRequired, w.Code)
+	}
+	if got := storedTitle(t, handler, 1); got != "Original" {
+		t.Errorf("expected the todo to be unchanged, got %q", got)
+	}
+	if w := doPutIfMatch(router, "/todos/2", "New", ""); w.Code != http.StatusCreated {
+		t.Errorf("expected status %d when creating, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+func doListIfNoneMatch(router *gin.Engine, query, ifNoneMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/todos"+query, nil)
+	req.Header.Set("If-None-Match", ifNoneMatch)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestListTasks_NotModified: the list ETag gives 304 until a todo is created, updated or deleted
+func TestListTasks_NotModified(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	router.POST("/todos", handler.NewTask)
+	router.PUT("/todos/:id", handler.PutTask)
+	router.DELETE("/todos/:id", handler.DeleteTask)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "First"})
+
+	tag := doList(t, router, "").Header().Get("ETag")
+	if tag == "" {
+		t.Fatal("expected the list to return an ETag")
+	}
+	w := doListIfNoneMatch(router, "", tag)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("expected an empty %d, got %d: %s", http.StatusNotModified, w.Code, w.Body.String())
+	}
+	if doListIfNoneMatch(router, "", strings.TrimPrefix(tag, "W/")).Code != http.StatusNotModified {
+		t.Error("expected the tag without W/ to match too")
+	}
+	if doListIfNoneMatch(router, "?completed=true", tag).Code != http.StatusOK {
+		t.Error("expected another query to have its own ETag")
+	}
+
+	changes := []func() *httptest.ResponseRecorder{
+		func() *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBufferString(`{"text": "Second"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		},
+		func() *httptest.ResponseRecorder { return doPutIfMatch(router, "/todos/1", "Edited", "") },
+		func() *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/todos/2", nil))
+			return w
+		},
+	}
+	for i, change := range changes {
+		if w := change(); w.Code >= 300 {
+			t.Fatalf("change %d failed with %d: %s", i, w.Code, w.Body.String())
+		}
+		time.Sleep(time.Millisecond)
+		w := doListIfNoneMatch(router, "", tag)
+		if w.Code != http.StatusOK {
+			t.Fatalf("change %d: expected status %d, got %d", i, http.StatusOK, w.Code)
+		}
+		newTag := w.Header().Get("ETag")
+		if newTag == tag {
+			t.Fatalf("change %d: expected a new ETag", i)
+		}
+		tag = newTag
+	}
+}
+
+// TestListTasks_OverdueHasNoETag: overdue results change with the clock, so they are never cached
+func TestListTasks_OverdueHasNoETag(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+
+	if tag := doList(t, router, "?overdue=true").Header().Get("ETag"); tag != "" {
+		t.Errorf("expected no ETag, got %q", tag)
+	}
+}
+
+// TestETags_WeakAndStrong: lists get weak tags, single todos strong ones
+func TestETags_WeakAndStrong(t *testing.T) {
+	handler, router := setupETagHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Tagged"})
+
+	if tag := doList(t, router, "").Header().Get("ETag"); !strings.HasPrefix(tag, `W/"`) {
+		t.Errorf("expected a weak list ETag, got %q", tag)
+	}
+	if tag := doList(t, router, "/1").Header().Get("ETag"); !strings.HasPrefix(tag, `"`) {
+		t.Errorf("expected a strong todo ETag, got %q", tag)
+	}
+}
+
+// TestGetTask_NotModified: If-None-Match compares weakly and accepts lists and *
+func TestGetTask_NotModified(t *testing.T) {
+	handler, router := setupETagHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Cached"})
+	tag := doList(t, router, "/1").Header().Get("ETag")
+
+	tests := []struct {
+		ifNoneMatch string
+		want        int
+	}{
+		{tag, http.StatusNotModified},
+		{"W/" + tag, http.StatusNotModified},
+		{`"other", ` + tag, http.StatusNotModified},
+		{"*", http.StatusNotModified},
+		{`"other"`, http.StatusOK},
+		{`W/"other"`, http.StatusOK},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/todos/1", nil)
+		req.Header.Set("If-None-Match", tc.ifNoneMatch)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("If-None-Match %s: expected status %d, got %d", tc.ifNoneMatch, tc.want, w.Code)
+		}
+		if w.Header().Get("ETag") != tag {
+			t.Errorf("If-None-Match %s: expected the ETag to be sent", tc.ifNoneMatch)
+		}
+	}
+}
+
+// TestParseETags: tags are split on commas outside quotes, keeping W/ and *
+func TestParseETags(t *testing.T) {
+	got := parseETags(` "a", W/"b,c" ,*,"d"`)
+
+	want := []entityTag{{opaque: `"a"`}, {weak: true, opaque: `"b,c"`}, {opaque: "*"}, {opaque: `"d"`}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("tag %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+	if got := parseETags(`"a", unquoted, "b"`); len(got) != 1 {
+		t.Errorf("expected parsing to stop at a malformed tag, got %v", got)
+	}
+}
+
+// TestGetTask_ETagPerRepresentation: each representation gets its own strong tag, and any of them allows a PUT
+func TestGetTask_ETagPerRepresentation(t *testing.T) {
+	handler, router := setupETagHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Shaped"})
+
+	get := func(path, accept string) string {
+		req := httptest.NewRequest(http.MethodGet, "/todos"+path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected status %d, got %d", path, http.StatusOK, w.Code)
+		}
+		return w.Header().Get("ETag")
+	}
+	plain := get("/1", "")
+	tags := map[string]string{
+		"xml":      get("/1", "application/xml"),
+		"fields":   get("/1?fields=text", ""),
+		"tz":       get("/1?tz=Asia/Bangkok", ""),
+		"truncate": get("/1?truncate=3", ""),
+	}
+	for name, tag := range tags {
+		if tag == plain {
+			t.Errorf("%s: expected its own ETag, got the plain one %q", name, tag)
+		}
+	}
+	if get("/1", "") != plain {
+		t.Error("expected the same representation to keep its ETag")
+	}
+
+	if w := doPutIfMatch(router, "/todos/1", "Edited", tags["fields"]); w.Code != http.StatusOK {
+		t.Errorf("expected the ETag of another representation to match, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,67 @@
+package todo
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+)
+
+const fieldsKey = "todo.fields"
+
+// todoFields are the names accepted by ?fields=, in snake_case. Requested
+// names are matched in any case style, so "dueDate" and "DueDate" work too.
+var todoFields = []string{
+	"id", "text", "due_date", "completed", "completed_at", "status", "priority",
+	"user_id", "metadata", "estimated_minutes", "actual_minutes", "timer_started_at",
+	"delete_reason", "deleted", "truncated", "created_at", "updated_at", "deleted_at",
+}
+
+// selectFields parses ?fields=a,b,c and stores the selection for respond.
+// It writes a 400 and returns false if a name is not a todo field.
+func selectFields(c *gin.Context) bool {
+	raw := c.Query("fields")
+	if raw == "" {
+		return true
+	}
+	keep := make(map[string]bool)
+	for _, name := range strings.Split(raw, ",") {
+		name = toSnake(strings.TrimSpace(name))
+		if !slices.Contains(todoFields, name) {
+			apperr.Write(c, apperr.ErrBadRequest.With(fmt.Sprintf("unknown field %q; allowed: %s", name, strings.Join(todoFields, ", "))))
+			return false
+		}
+		keep[name] = true
+	}
+	c.Set(fieldsKey, keep)
+	return true
+}
+
+// selectedFields returns the selection stored by selectFields, or nil.
+func selectedFields(c *gin.Context) map[string]bool {
+	keep, _ := c.Value(fieldsKey).(map[string]bool)
+	return keep
+}
+
+// project drops every key of a decoded todo, or list of todos, that is not
+// in keep.
+func project(v any, keep map[string]bool) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k := range val {
+			if !keep[toSnake(k)] {
+				delete(val, k)
+			}
+		}
+		return val
+	case []any:
+		for i, inner := range val {
+			val[i] = project(inner, keep)
+		}
+		return val
+	default:
+		return v
+	}
+}
@@ -0,0 +1,106 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func decodeObjects(t *testing.T, w *httptest.ResponseRecorder) []map[string]any {
+	t.Helper()
+	var objects []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &objects); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return objects
+}
+
+// TestListTasks_Fields: ?fields= returns only the requested keys
+func TestListTasks_Fields(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Buy milk"})
+
+	w := doList(t, router, "?fields=id,text,completed")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	objects := decodeObjects(t, w)
+	if len(objects) != 1 {
+		t.Fatalf("expected 1 todo, got %d", len(objects))
+	}
+	if len(objects[0]) != 3 || objects[0]["ID"] == nil || objects[0]["text"] != "Buy milk" || objects[0]["completed"] != false {
+		t.Errorf("expected only ID, text and completed, got %v", objects[0])
+	}
+}
+
+// TestListTasks_FieldsAnyCase: field names may be given in camelCase
+func TestListTasks_FieldsAnyCase(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Buy milk"})
+
+	objects := decodeObjects(t, doList(t, router, "?fields=dueDate,createdAt"))
+
+	if _, ok := objects[0]["due_date"]; !ok || objects[0]["CreatedAt"] == nil || len(objects[0]) != 2 {
+		t.Errorf("expected only due_date and CreatedAt, got %v", objects[0])
+	}
+}
+
+func TestListTasks_UnknownField(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+
+	w := doList(t, router, "?fields=id,password")
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestGetTask_FieldsWithJSONCase: projection is applied before keys are renamed
+func TestGetTask_FieldsWithJSONCase(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	handler.cfg.JSONCase = CamelCase
+	router.GET("/todos/:id", handler.GetTask)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Buy milk"})
+
+	req := httptest.NewRequest(http.MethodGet, "/todos/1?fields=id,due_date", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if _, ok := response["dueDate"]; !ok || response["id"] == nil || len(response) != 2 {
+		t.Errorf("expected only id and dueDate, got %v", response)
+	}
+}
+
+// TestGetTask_FieldsJSONAPI: JSON:API keeps the resource id and trims attributes
+func TestGetTask_FieldsJSONAPI(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos/:id", handler.GetTask)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Buy milk"})
+
+	req := httptest.NewRequest(http.MethodGet, "/todos/1?fields=text", nil)
+	req.Header.Set("Accept", jsonAPIMediaType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var doc struct {
+		Data jsonAPIResource `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if doc.Data.ID != "1" {
+		t.Errorf("expected id '1', got %q", doc.Data.ID)
+	}
+	if len(doc.Data.Attributes) != 1 || doc.Data.Attributes["text"] != "Buy milk" {
+		t.Errorf("expected only the text attribute, got %v", doc.Data.Attributes)
+	}
+}
@@ -0,0 +1,137 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Values of Filter.Match.
+const (
+	MatchAll = "all"
+	MatchAny = "any"
+)
+
+// Filter selects todos for listing and bulk operations. Nil fields are
+// ignored; the rest are combined with AND, or with OR when Match is
+// MatchAny.
+type Filter struct {
+	Completed  *bool   `json:"completed"`
+	Priority   *string `json:"priority"`
+	HasDueDate *bool   `json:"has_due_date"`
+	Overdue    *bool   `json:"overdue"`
+	Match      string  `json:"match"`
+}
+
+func (f Filter) isEmpty() bool {
+	return f.Completed == nil && f.Priority == nil && f.HasDueDate == nil && f.Overdue == nil
+}
+
+var (
+	errInvalidPriority = fmt.Errorf("priority must be one of %s, %s, %s", PriorityLow, PriorityMedium, PriorityHigh)
+	errInvalidMatch    = fmt.Errorf("match must be %s or %s", MatchAll, MatchAny)
+)
+
+func (f Filter) validate() error {
+	if f.Priority != nil && !validPriority(*f.Priority) {
+		return errInvalidPriority
+	}
+	if f.Match != "" && f.Match != MatchAll && f.Match != MatchAny {
+		return errInvalidMatch
+	}
+	return nil
+}
+
+func validPriority(p string) bool {
+	return p == PriorityLow || p == PriorityMedium || p == PriorityHigh
+}
+
+type condition struct {
+	query string
+	args  []any
+}
+
+func (f Filter) conditions() []condition {
+	var conds []condition
+	if f.Completed != nil {
+		conds = append(conds, condition{"completed = ?", []any{*f.Completed}})
+	}
+	if f.Priority != nil {
+		conds = append(conds, condition{"priority = ?", []any{*f.Priority}})
+	}
+	if f.HasDueDate != nil {
+		if *f.HasDueDate {
+			conds = append(conds, condition{"due_date IS NOT NULL", nil})
+		} else {
+			conds = append(conds, condition{"due_date IS NULL", nil})
+		}
+	}
+	if f.Overdue != nil {
+		overdue := "completed = ? AND due_date IS NOT NULL AND due_date < ?"
+		if !*f.Overdue {
+			overdue = "NOT (" + overdue + ")"
+		}
+		conds = append(conds, condition{overdue, []any{false, time.Now().UTC()}})
+	}
+	return conds
+}
+
+// apply narrows q to the todos matching f. With MatchAny the conditions are
+// grouped, so they are ORed with each other but still ANDed with whatever q
+// already selects, such as the owner.
+func (f Filter) apply(q *gorm.DB) *gorm.DB {
+	conds := f.conditions()
+	if f.Match != MatchAny {
+		for _, cond := range conds {
+			q = q.Where(cond.query, cond.args...)
+		}
+		return q
+	}
+	if len(conds) == 0 {
+		return q
+	}
+	group := q.Session(&gorm.Session{NewDB: true})
+	for i, cond := range conds {
+		if i == 0 {
+			group = group.Where(cond.query, cond.args...)
+		} else {
+			group = group.Or(cond.query, cond.args...)
+		}
+	}
+	return q.Where(group)
+}
+
+// filterFromQuery reads a filter from the list query parameters.
+//
+//	completed    - true or false
+//	priority     - low, medium or high
+//	has_due_date - true for todos with a due date, false for those without
+//	overdue      - true for incomplete todos whose due date has passed
+//	match        - all (default) to require every filter, any to require one
+func filterFromQuery(c *gin.Context) (Filter, error) {
+	var f Filter
+	for name, dst := range map[string]**bool{
+		"completed":    &f.Completed,
+		"has_due_date": &f.HasDueDate,
+		"overdue":      &f.Overdue,
+	} {
+		v, ok := c.GetQuery(name)
+		if !ok {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Filter{}, errors.New(name + " must be true or false")
+		}
+		*dst = &b
+	}
+	if v, ok := c.GetQuery("priority"); ok {
+		f.Priority = &v
+	}
+	f.Match = c.Query("match")
+	return f, f.validate()
+}
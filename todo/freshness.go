@@ -0,0 +1,66 @@
+package todo
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+)
+
+type freshnessRequest struct {
+	IDs []flexID `json:"ids" binding:"required"`
+}
+
+// freshness is what a client needs to tell whether its cached copy of a
+// todo is still current.
+type freshness struct {
+	// UpdatedAt is nil for ids the caller has no todo under, such as purged
+	// or transferred todos.
+	UpdatedAt *time.Time `json:"updated_at"`
+	Deleted   bool       `json:"deleted"`
+}
+
+// Freshness reports, for each requested id, when the caller's todo was last
+// updated and whether it was deleted, keyed by id, from one query. Clients
+// compare it with their cache to refetch only what changed. Ids the caller
+// has no todo under are reported as deleted, so the client drops them.
+func (t *TodoHandler) Freshness(c *gin.Context) {
+	q, _, ok := t.owned(c)
+	if !ok {
+		return
+	}
+
+	var req freshnessRequest
+	if !t.bindJSON(c, &req) {
+		return
+	}
+	if !t.checkBulkSize(c, len(req.IDs)) {
+		return
+	}
+
+	var rows []Todo
+	err := t.retry(c, func() error {
+		rows = nil
+		return q.Unscoped().Select("id", "updated_at", "deleted_at").Where("id IN ?", req.IDs).Find(&rows).Error
+	})
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+
+	found := make(map[uint]freshness, len(rows))
+	for _, row := range rows {
+		found[row.ID] = freshness{UpdatedAt: &row.UpdatedAt, Deleted: row.DeletedAt.Valid}
+	}
+	resp := make(map[string]freshness, len(req.IDs))
+	for _, id := range req.IDs {
+		f, ok := found[uint(id)]
+		if !ok {
+			f.Deleted = true
+		}
+		resp[strconv.FormatUint(uint64(id), 10)] = f
+	}
+	t.respond(c, http.StatusOK, resp)
+}
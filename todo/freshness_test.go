@@ -0,0 +1,61 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// TestFreshness_MixedIDs: live, deleted, unknown and other users' ids are each reported from one request
+func TestFreshness_MixedIDs(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos/status", handler.Freshness)
+
+	live := &Todo{UserID: testUserID, Title: "live"}
+	gone := &Todo{UserID: testUserID, Title: "gone"}
+	theirs := &Todo{UserID: testUserID + 1, Title: "theirs"}
+	handler.db.Create(live)
+	handler.db.Create(gone)
+	handler.db.Create(theirs)
+	handler.db.Delete(gone)
+	handler.db.First(live, live.ID)
+
+	w := doJSON(router, http.MethodPost, "/todos/status", `{"ids": [1, "2", 3, 99]}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp map[string]freshness
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(resp) != 4 {
+		t.Fatalf("expected 4 entries, got %v", resp)
+	}
+	if got := resp["1"]; got.Deleted || got.UpdatedAt == nil || !got.UpdatedAt.Equal(live.UpdatedAt) {
+		t.Errorf("expected todo 1 live and updated at %v, got %+v", live.UpdatedAt, got)
+	}
+	if got := resp["2"]; !got.Deleted || got.UpdatedAt == nil {
+		t.Errorf("expected todo 2 deleted with its updated_at, got %+v", got)
+	}
+	for _, id := range []string{"3", "99"} {
+		if got := resp[id]; !got.Deleted || got.UpdatedAt != nil {
+			t.Errorf("expected todo %s reported deleted without updated_at, got %+v", id, got)
+		}
+	}
+}
+
+// TestFreshness_Invalid: the id list must be present and hold valid ids
+func TestFreshness_Invalid(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos/status", handler.Freshness)
+
+	for _, body := range []string{`{}`, `{"ids": []}`} {
+		if w := doJSON(router, http.MethodPost, "/todos/status", body); w.Code == http.StatusOK {
+			t.Errorf("%.30s: expected an error, got %d", body, w.Code)
+		}
+	}
+	if w := doJSON(router, http.MethodPost, "/todos/status", `{"ids": ["x"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for a bad id, got %d", http.StatusBadRequest, w.Code)
+	}
+}
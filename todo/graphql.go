@@ -0,0 +1,293 @@
+package todo
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/auth"
+	"gorm.io/gorm"
+)
+
+// graphQLRequest is the body of POST /graphql.
+type graphQLRequest struct {
+	Query         string         `json:"query" binding:"required"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// GraphQL runs a GraphQL query or mutation over the caller's todos. The
+// response is always 200 with the spec's {"data", "errors"} body; errors
+// carry their apperr code in extensions.code.
+func (t *TodoHandler) GraphQL(c *gin.Context) {
+	if _, _, ok := t.owned(c); !ok {
+		return
+	}
+	var req graphQLRequest
+	if !t.bindJSON(c, &req) {
+		return
+	}
+	schema, err := t.graphQLSchema()
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        c,
+	})
+	c.JSON(http.StatusOK, result)
+}
+
+// graphQLError carries an apperr code to the errors of a GraphQL response.
+type graphQLError struct {
+	err *apperr.Error
+}
+
+func (e graphQLError) Error() string {
+	return e.err.Error()
+}
+
+func (e graphQLError) Extensions() map[string]any {
+	return map[string]any{"code": e.err.Code}
+}
+
+// resolver adapts a resolver that needs the request and its user. Errors
+// are reported with their apperr code; a missing todo is not_found.
+func resolver(fn func(c *gin.Context, userID uint, args map[string]any) (any, error)) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		c, _ := p.Context.(*gin.Context)
+		userID, ok := auth.UserID(c)
+		if !ok {
+			return nil, graphQLError{apperr.ErrUnauthorized}
+		}
+		v, err := fn(c, userID, p.Args)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errTodoNotFound
+		}
+		if err != nil {
+			return nil, graphQLError{apperr.From(err)}
+		}
+		return v, nil
+	}
+}
+
+// todoField resolves a field of a Todo.
+func todoField(typ graphql.Output, get func(Todo) any) *graphql.Field {
+	return &graphql.Field{Type: typ, Resolve: func(p graphql.ResolveParams) (any, error) {
+		return get(p.Source.(Todo)), nil
+	}}
+}
+
+var todoGraphQLType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Todo",
+	Fields: graphql.Fields{
+		"id":                todoField(graphql.NewNonNull(graphql.ID), func(t Todo) any { return strconv.FormatUint(uint64(t.ID), 10) }),
+		"text":              todoField(graphql.NewNonNull(graphql.String), func(t Todo) any { return t.Title }),
+		"due_date":          todoField(graphql.DateTime, func(t Todo) any { return t.DueDate }),
+		"completed":         todoField(graphql.NewNonNull(graphql.Boolean), func(t Todo) any { return t.Completed }),
+		"completed_at":      todoField(graphql.DateTime, func(t Todo) any { return t.CompletedAt }),
+		"status":            todoField(graphql.NewNonNull(graphql.String), func(t Todo) any { return t.Status }),
+		"priority":          todoField(graphql.NewNonNull(graphql.String), func(t Todo) any { return t.Priority }),
+		"estimated_minutes": todoField(graphql.NewNonNull(graphql.Int), func(t Todo) any { return t.EstimatedMinutes }),
+		"actual_minutes":    todoField(graphql.NewNonNull(graphql.Int), func(t Todo) any { return t.ActualMinutes }),
+		"created_at":        todoField(graphql.NewNonNull(graphql.DateTime), func(t Todo) any { return t.CreatedAt }),
+		"updated_at":        todoField(graphql.NewNonNull(graphql.DateTime), func(t Todo) any { return t.UpdatedAt }),
+	},
+})
+
+// todoGraphQLArgs are the writable fields of a todo, as mutation
+// arguments.
+var todoGraphQLArgs = graphql.FieldConfigArgument{
+	"text":              {Type: graphql.String},
+	"due_date":          {Type: graphql.DateTime},
+	"priority":          {Type: graphql.String},
+	"completed":         {Type: graphql.Boolean},
+	"estimated_minutes": {Type: graphql.Int},
+}
+
+// graphQLSchema returns the GraphQL schema, built on first use.
+func (t *TodoHandler) graphQLSchema() (graphql.Schema, error) {
+	t.schemaOnce.Do(func() {
+		t.schema, t.schemaErr = t.newGraphQLSchema()
+	})
+	return t.schema, t.schemaErr
+}
+
+func (t *TodoHandler) newGraphQLSchema() (graphql.Schema, error) {
+	id := graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}}
+	withID := graphql.FieldConfigArgument{"id": id["id"]}
+	for name, arg := range todoGraphQLArgs {
+		withID[name] = arg
+	}
+	create := graphql.FieldConfigArgument{}
+	for name, arg := range todoGraphQLArgs {
+		create[name] = arg
+	}
+	create["text"] = &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"todos": {
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(todoGraphQLType))),
+				Args: graphql.FieldConfigArgument{
+					"completed":    {Type: graphql.Boolean},
+					"priority":     {Type: graphql.String},
+					"has_due_date": {Type: graphql.Boolean},
+					"overdue":      {Type: graphql.Boolean},
+					"match":        {Type: graphql.String},
+					"sort":         {Type: graphql.String},
+					"page":         {Type: graphql.Int},
+					"limit":        {Type: graphql.Int},
+				},
+				Resolve: resolver(t.resolveTodos),
+			},
+			"todo": {
+				Type:    todoGraphQLType,
+				Args:    id,
+				Resolve: resolver(t.resolveTodo),
+			},
+		},
+	})
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createTodo": {
+				Type:    graphql.NewNonNull(todoGraphQLType),
+				Args:    create,
+				Resolve: resolver(t.resolveCreate),
+			},
+			"updateTodo": {
+				Type:    graphql.NewNonNull(todoGraphQLType),
+				Args:    withID,
+				Resolve: resolver(t.resolveUpdate),
+			},
+			"completeTodo": {
+				Type: graphql.NewNonNull(todoGraphQLType),
+				Args: id,
+				Resolve: resolver(func(c *gin.Context, userID uint, args map[string]any) (any, error) {
+					return t.resolveUpdate(c, userID, map[string]any{"id": args["id"], "completed": true})
+				}),
+			},
+			"deleteTodo": {
+				Type:    graphql.NewNonNull(graphql.Boolean),
+				Args:    id,
+				Resolve: resolver(t.resolveDelete),
+			},
+		},
+	})
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// graphQLID reads the id argument.
+func graphQLID(args map[string]any) (uint, error) {
+	s, _ := args["id"].(string)
+	id, err := strconv.ParseUint(s, 10, strconv.IntSize)
+	if err != nil || id == 0 {
+		return 0, apperr.ErrBadRequest.With("invalid id")
+	}
+	return uint(id), nil
+}
+
+// resolveTodos lists the caller's todos like GET /todos, with its filters,
+// sort orders and paging as arguments.
+func (t *TodoHandler) resolveTodos(c *gin.Context, userID uint, args map[string]any) (any, error) {
+	var q ListQuery
+	for name, dst := range map[string]**bool{
+		"completed":    &q.Filter.Completed,
+		"has_due_date": &q.Filter.HasDueDate,
+		"overdue":      &q.Filter.Overdue,
+	} {
+		if b, ok := args[name].(bool); ok {
+			*dst = &b
+		}
+	}
+	if p, ok := args["priority"].(string); ok {
+		q.Filter.Priority = &p
+	}
+	q.Filter.Match, _ = args["match"].(string)
+	q.Sort, _ = args["sort"].(string)
+	q.Page.Number, _ = args["page"].(int)
+	q.Page.Limit, _ = args["limit"].(int)
+	return t.List(c.Request.Context(), userID, q)
+}
+
+// resolveTodo returns one of the caller's todos, or an error if there is
+// no such todo.
+func (t *TodoHandler) resolveTodo(c *gin.Context, userID uint, args map[string]any) (any, error) {
+	id, err := graphQLID(args)
+	if err != nil {
+		return nil, err
+	}
+	return t.Get(c.Request.Context(), userID, id, false)
+}
+
+// resolveCreate creates a todo as POST /todos does.
+func (t *TodoHandler) resolveCreate(c *gin.Context, userID uint, args map[string]any) (any, error) {
+	ch := graphQLChanges(args)
+	var todo Todo
+	if ch.Title != nil {
+		todo.Title = *ch.Title
+	}
+	todo.DueDate = ch.DueDate
+	if ch.Priority != nil {
+		todo.Priority = *ch.Priority
+	}
+	if ch.Completed != nil {
+		todo.Completed = *ch.Completed
+	}
+	if ch.EstimatedMinutes != nil {
+		todo.EstimatedMinutes = *ch.EstimatedMinutes
+	}
+	return t.Create(c.Request.Context(), userID, todo)
+}
+
+// resolveUpdate changes the given fields of one of the caller's todos and
+// leaves the rest as they are.
+func (t *TodoHandler) resolveUpdate(c *gin.Context, userID uint, args map[string]any) (any, error) {
+	id, err := graphQLID(args)
+	if err != nil {
+		return nil, err
+	}
+	return t.Update(c.Request.Context(), userID, id, graphQLChanges(args))
+}
+
+// resolveDelete moves one of the caller's todos to the trash as DELETE
+// /todos/:id does.
+func (t *TodoHandler) resolveDelete(c *gin.Context, userID uint, args map[string]any) (any, error) {
+	id, err := graphQLID(args)
+	if err != nil {
+		return nil, err
+	}
+	return true, t.Delete(c.Request.Context(), userID, id, "")
+}
+
+// graphQLChanges reads the todo fields given as mutation arguments.
+func graphQLChanges(args map[string]any) TodoChanges {
+	var ch TodoChanges
+	if v, ok := args["text"].(string); ok {
+		ch.Title = &v
+	}
+	if v, ok := args["due_date"].(time.Time); ok {
+		ch.DueDate = &v
+	}
+	if v, ok := args["priority"].(string); ok {
+		ch.Priority = &v
+	}
+	if v, ok := args["completed"].(bool); ok {
+		ch.Completed = &v
+	}
+	if v, ok := args["estimated_minutes"].(int); ok {
+		ch.EstimatedMinutes = &v
+	}
+	return ch
+}
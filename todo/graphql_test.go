@@ -0,0 +1,151 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/audit"
+)
+
+type graphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func doGraphQL(t *testing.T, router *gin.Engine, query string, variables map[string]any) graphQLResponse {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+	w := doJSON(router, http.MethodPost, "/graphql", string(body))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp graphQLResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return resp
+}
+
+func setupGraphQL(t *testing.T) (*TodoHandler, *gin.Engine) {
+	t.Helper()
+	handler, router := setupTestHandler(t)
+	router.POST("/graphql", handler.GraphQL)
+	return handler, router
+}
+
+// TestGraphQL_Query: todos are listed with REST-style filters and paging, only for the caller
+func TestGraphQL_Query(t *testing.T) {
+	handler, router := setupGraphQL(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Low one", Priority: PriorityLow})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "High one", Priority: PriorityHigh})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Low two", Priority: PriorityLow})
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "Not mine", Priority: PriorityLow})
+
+	resp := doGraphQL(t, router, `query($p: String) { todos(priority: $p, sort: "-id", limit: 5) { id text priority } }`, map[string]any{"p": "low"})
+
+	if len(resp.Errors) != 0 {
+		t.Fatalf("unexpected errors: %+v", resp.Errors)
+	}
+	var todos []struct{ ID, Text, Priority string }
+	if err := json.Unmarshal(resp.Data["todos"], &todos); err != nil {
+		t.Fatalf("failed to unmarshal todos: %v", err)
+	}
+	if len(todos) != 2 || todos[0].ID != "3" || todos[0].Text != "Low two" || todos[1].ID != "1" {
+		t.Errorf("expected the caller's low todos newest first, got %+v", todos)
+	}
+
+	resp = doGraphQL(t, router, `{ todos(priority: "urgent") { id } }`, nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "bad_request" {
+		t.Errorf("expected a bad_request error for an unknown priority, got %+v", resp.Errors)
+	}
+}
+
+// TestGraphQL_Mutations: todos are created, updated, completed and deleted with audit entries
+func TestGraphQL_Mutations(t *testing.T) {
+	handler, router := setupGraphQL(t)
+
+	resp := doGraphQL(t, router, `mutation { createTodo(text: "  Write   docs ", estimated_minutes: 30) { id text priority completed } }`, nil)
+	var created struct {
+		ID        string
+		Text      string
+		Priority  string
+		Completed bool
+	}
+	if err := json.Unmarshal(resp.Data["createTodo"], &created); err != nil || len(resp.Errors) != 0 {
+		t.Fatalf("unexpected response: %+v (%v)", resp, err)
+	}
+	if created.ID != "1" || created.Text != "Write docs" || created.Priority != PriorityMedium || created.Completed {
+		t.Errorf("expected a cleaned-up open todo with the default priority, got %+v", created)
+	}
+
+	resp = doGraphQL(t, router, `mutation { updateTodo(id: "1", priority: "high") { id } }`, nil)
+	if len(resp.Errors) != 0 {
+		t.Fatalf("unexpected errors: %+v", resp.Errors)
+	}
+	resp = doGraphQL(t, router, `mutation { completeTodo(id: "1") { completed completed_at status } }`, nil)
+	var completed struct {
+		Completed   bool    `json:"completed"`
+		CompletedAt *string `json:"completed_at"`
+		Status      string  `json:"status"`
+	}
+	if err := json.Unmarshal(resp.Data["completeTodo"], &completed); err != nil || len(resp.Errors) != 0 {
+		t.Fatalf("unexpected response: %+v (%v)", resp, err)
+	}
+	if !completed.Completed || completed.CompletedAt == nil || completed.Status != StatusDone {
+		t.Errorf("expected a done todo with a completion time, got %+v", completed)
+	}
+
+	var todo Todo
+	handler.db.First(&todo, 1)
+	if todo.Title != "Write docs" || todo.Priority != PriorityHigh || todo.EstimatedMinutes != 30 || !todo.Completed {
+		t.Errorf("expected the update to keep the other fields, got %+v", todo)
+	}
+
+	resp = doGraphQL(t, router, `mutation { deleteTodo(id: "1") }`, nil)
+	if string(resp.Data["deleteTodo"]) != "true" {
+		t.Fatalf("expected the todo to be deleted, got %+v", resp)
+	}
+	var actions []string
+	handler.db.Model(&audit.Log{}).Order("id").Pluck("action", &actions)
+	want := []string{audit.ActionCreate, audit.ActionUpdate, audit.ActionUpdate, audit.ActionDelete}
+	if len(actions) != len(want) {
+		t.Fatalf("expected audit actions %v, got %v", want, actions)
+	}
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Errorf("expected audit actions %v, got %v", want, actions)
+			break
+		}
+	}
+}
+
+// TestGraphQL_Errors: invalid input and other users' todos are reported with their codes
+func TestGraphQL_Errors(t *testing.T) {
+	handler, router := setupGraphQL(t)
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "Not mine"})
+
+	for query, code := range map[string]string{
+		`{ todo(id: "1") { text } }`:                                    "not_found",
+		`mutation { updateTodo(id: "1", text: "Mine") { id } }`:         "not_found",
+		`mutation { createTodo(text: "   ") { id } }`:                   "validation_failed",
+		`mutation { createTodo(text: "x", priority: "urgent") { id } }`: "validation_failed",
+	} {
+		resp := doGraphQL(t, router, query, nil)
+		if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != code {
+			t.Errorf("%s: expected a %s error, got %+v", query, code, resp.Errors)
+		}
+	}
+	var count int64
+	handler.db.Model(&Todo{}).Where("user_id = ?", testUserID).Count(&count)
+	if count != 0 {
+		t.Errorf("expected no todos for the caller, got %d", count)
+	}
+	if w := doJSON(router, http.MethodPost, "/graphql", `{}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d without a query, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
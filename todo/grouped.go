@@ -0,0 +1,65 @@
+package todo
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+)
+
+// groupings maps each ?by= value to the groups it always returns, so empty
+// columns still show up in a kanban view, and to the key of a todo.
+var groupings = map[string]struct {
+	groups []string
+	key    func(Todo) string
+}{
+	"priority": {
+		groups: []string{PriorityHigh, PriorityMedium, PriorityLow},
+		key:    func(t Todo) string { return t.Priority },
+	},
+	"completed": {
+		groups: []string{"false", "true"},
+		key:    func(t Todo) string { return strconv.FormatBool(t.Completed) },
+	},
+}
+
+// ListGrouped returns the caller's todos bucketed by ?by=priority or
+// ?by=completed, each bucket ordered by id. The list filters apply. It runs
+// a single query and groups the rows in Go.
+func (t *TodoHandler) ListGrouped(c *gin.Context) {
+	q, _, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	grouping, ok := groupings[c.Query("by")]
+	if !ok {
+		apperr.Write(c, apperr.ErrBadRequest.With(fmt.Sprintf("by must be priority or completed, got %q", c.Query("by"))))
+		return
+	}
+	f, err := filterFromQuery(c)
+	if err != nil {
+		apperr.Write(c, apperr.ErrBadRequest.Wrap(err))
+		return
+	}
+
+	var todos []Todo
+	err = t.retry(c, func() error {
+		return f.apply(q).Order("id").Find(&todos).Error
+	})
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+
+	groups := make(map[string][]Todo, len(grouping.groups))
+	for _, name := range grouping.groups {
+		groups[name] = []Todo{}
+	}
+	for _, todo := range todos {
+		key := grouping.key(todo)
+		groups[key] = append(groups[key], todo)
+	}
+	t.respond(c, http.StatusOK, groups)
+}
@@ -0,0 +1,84 @@
+package todo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupGroupedHandler(t *testing.T) (*TodoHandler, *gin.Engine) {
+	t.Helper()
+	handler, router := setupTestHandler(t)
+	router.GET("/todos/grouped", handler.ListGrouped)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "a", Priority: PriorityHigh})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "b", Priority: PriorityLow, Completed: true})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "c", Priority: PriorityHigh, Completed: true})
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "someone else's", Priority: PriorityMedium})
+	return handler, router
+}
+
+func decodeGroups(t *testing.T, router *gin.Engine, query string) map[string][]Todo {
+	t.Helper()
+	w := doList(t, router, "/grouped"+query)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var groups map[string][]Todo
+	if err := json.Unmarshal(w.Body.Bytes(), &groups); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return groups
+}
+
+func titles(todos []Todo) []string {
+	out := []string{}
+	for _, todo := range todos {
+		out = append(out, todo.Title)
+	}
+	return out
+}
+
+// TestListGrouped_ByPriority: every priority is present, empty ones included, with only the caller's todos
+func TestListGrouped_ByPriority(t *testing.T) {
+	_, router := setupGroupedHandler(t)
+
+	groups := decodeGroups(t, router, "?by=priority")
+
+	want := map[string]string{PriorityHigh: "[a c]", PriorityMedium: "[]", PriorityLow: "[b]"}
+	if len(groups) != len(want) {
+		t.Fatalf("expected %d groups, got %v", len(want), groups)
+	}
+	for name, titlesWant := range want {
+		todos, ok := groups[name]
+		if got := fmt.Sprint(titles(todos)); !ok || got != titlesWant {
+			t.Errorf("%s: expected %s, got %s", name, titlesWant, got)
+		}
+	}
+}
+
+// TestListGrouped_ByCompletedWithFilter: list filters narrow the todos before grouping
+func TestListGrouped_ByCompletedWithFilter(t *testing.T) {
+	_, router := setupGroupedHandler(t)
+
+	groups := decodeGroups(t, router, "?by=completed&priority=high")
+
+	if got := fmt.Sprint(titles(groups["false"])); got != "[a]" {
+		t.Errorf("false: expected [a], got %s", got)
+	}
+	if got := fmt.Sprint(titles(groups["true"])); got != "[c]" {
+		t.Errorf("true: expected [c], got %s", got)
+	}
+}
+
+func TestListGrouped_InvalidRequests(t *testing.T) {
+	_, router := setupGroupedHandler(t)
+
+	for _, query := range []string{"", "?by=tag", "?by=priority&completed=maybe"} {
+		if w := doList(t, router, "/grouped"+query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
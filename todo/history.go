@@ -0,0 +1,48 @@
+package todo
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/audit"
+	"gorm.io/gorm"
+)
+
+// History returns the audit entries of one of the caller's todos, oldest
+// first and paginated: its creation, each update with the fields it
+// changed, and its deletion. Deleted todos keep their history until they
+// are purged.
+func (t *TodoHandler) History(c *gin.Context) {
+	q, _, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	p, ok := t.page(c)
+	if !ok {
+		return
+	}
+
+	entries := []audit.Log{}
+	err := t.retry(c, func() error {
+		var todo Todo
+		if err := q.Unscoped().Select("id").First(&todo, id).Error; err != nil {
+			return err
+		}
+		return t.conn(c).Where("todo_id = ?", id).Order("id").Limit(p.Limit).Offset(p.Offset()).Find(&entries).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apperr.Write(c, errTodoNotFound)
+		return
+	}
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	t.respond(c, http.StatusOK, entries)
+}
@@ -0,0 +1,83 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pradist/todoapi/audit"
+)
+
+// TestHistory_Timeline: a todo's creation, edits and deletion are listed oldest first with their changes
+func TestHistory_Timeline(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+	router.PUT("/todos/:id", handler.PutTask)
+	router.DELETE("/todos/:id", handler.DeleteTask)
+	router.GET("/todos/:id/history", handler.History)
+
+	for _, step := range []struct{ method, path, body string }{
+		{http.MethodPost, "/todos", `{"text": "Draft"}`},
+		{http.MethodPost, "/todos", `{"text": "Other"}`},
+		{http.MethodPut, "/todos/1", `{"text": "Final"}`},
+		{http.MethodPut, "/todos/1", `{"text": "Final", "completed": true}`},
+		{http.MethodDelete, "/todos/1", ""},
+	} {
+		if w := doJSON(router, step.method, step.path, step.body); w.Code >= http.StatusBadRequest {
+			t.Fatalf("%s %s: got %d: %s", step.method, step.path, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos/1/history", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var entries []audit.Log
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	wantActions := []string{audit.ActionCreate, audit.ActionUpdate, audit.ActionUpdate, audit.ActionDelete}
+	if len(entries) != len(wantActions) {
+		t.Fatalf("expected %d entries, got %+v", len(wantActions), entries)
+	}
+	for i, e := range entries {
+		if e.Action != wantActions[i] || e.TodoID != 1 {
+			t.Errorf("entry %d: expected %q of todo 1, got %q of todo %d", i, wantActions[i], e.Action, e.TodoID)
+		}
+	}
+	var retitled, completed map[string]audit.Change
+	json.Unmarshal(entries[1].Changes, &retitled)
+	json.Unmarshal(entries[2].Changes, &completed)
+	if c := retitled["text"]; c.Old != "Draft" || c.New != "Final" {
+		t.Errorf("expected the title change, got %s", entries[1].Changes)
+	}
+	if c := completed["completed"]; c.Old != false || c.New != true {
+		t.Errorf("expected the completion, got %s", entries[2].Changes)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos/1/history?page=2&limit=3", nil))
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != audit.ActionDelete {
+		t.Errorf("expected only the deletion on page 2, got %+v", entries)
+	}
+}
+
+// TestHistory_NotFound: todos that don't exist or belong to someone else are 404
+func TestHistory_NotFound(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos/:id/history", handler.History)
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "Not mine"})
+
+	for _, path := range []string{"/todos/1/history", "/todos/2/history"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusNotFound, w.Code)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"gorm.io/gorm"
+)
+
+const calendarMediaType = "text/calendar; charset=utf-8"
+
+// icsTime is the UTC DATE-TIME form of RFC 5545.
+const icsTime = "20060102T150405Z"
+
+// icsPriority maps priorities onto the 1 (highest) to 9 (lowest) scale of
+// RFC 5545.
+var icsPriority = map[string]int{PriorityHigh: 1, PriorityMedium: 5, PriorityLow: 9}
+
+// ExportICS returns one of the caller's todos as an iCalendar VEVENT at its
+// due date, for adding to a calendar. Todos without a due date are 400.
+func (t *TodoHandler) ExportICS(c *gin.Context) {
+	q, _, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var todo Todo
+	err := t.retry(c, func() error {
+		return q.First(&todo, id).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperr.Write(c, errTodoNotFound)
+			return
+		}
+		apperr.Write(c, err)
+		return
+	}
+	if todo.DueDate == nil {
+		apperr.Write(c, apperr.ErrBadRequest.With("todo has no due date"))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="todo-%d.ics"`, todo.ID))
+	c.Data(http.StatusOK, calendarMediaType, []byte(icsEvent(todo, time.Now())))
+}
+
+// icsEvent renders todo as a VCALENDAR holding a single VEVENT stamped at
+// now. The UID stays the same across exports, so importing the todo again
+// updates the event instead of adding a copy.
+func icsEvent(todo Todo, now time.Time) string {
+	var b strings.Builder
+	for _, line := range []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//pradist//todoapi//EN",
+		"BEGIN:VEVENT",
+		fmt.Sprintf("UID:todo-%d@todoapi", todo.ID),
+		"DTSTAMP:" + now.UTC().Format(icsTime),
+		"DTSTART:" + todo.DueDate.UTC().Format(icsTime),
+		"SUMMARY:" + icsText(todo.Title),
+		fmt.Sprintf("PRIORITY:%d", icsPriority[todo.Priority]),
+		"LAST-MODIFIED:" + todo.UpdatedAt.UTC().Format(icsTime),
+		"END:VEVENT",
+		"END:VCALENDAR",
+	} {
+		b.WriteString(icsFold(line))
+	}
+	return b.String()
+}
+
+// icsText escapes a TEXT value.
+var icsText = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace
+
+// icsFold ends line with CRLF, folding it so no physical line exceeds 75
+// octets. Continuations start with a space and never split a UTF-8
+// character.
+func icsFold(line string) string {
+	var b strings.Builder
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	b.WriteString(line + "\r\n")
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package todo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExportICS_Event: a todo with a due date is exported as a single VEVENT at that time
+func TestExportICS_Event(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos/:id/ics", handler.ExportICS)
+	due := time.Date(2026, 3, 1, 9, 30, 0, 0, time.FixedZone("ICT", 7*60*60))
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Call Bob, then; file", DueDate: &due, Priority: PriorityHigh})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos/1/ics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Errorf("expected a text/calendar content type, got %q", ct)
+	}
+	body := w.Body.String()
+	if !strings.HasSuffix(body, "\r\n") || strings.Contains(strings.ReplaceAll(body, "\r\n", ""), "\n") {
+		t.Errorf("expected every line to end with CRLF, got %q", body)
+	}
+	lines := strings.Split(strings.TrimSuffix(body, "\r\n"), "\r\n")
+	want := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//pradist//todoapi//EN",
+		"BEGIN:VEVENT",
+		"UID:todo-1@todoapi",
+		"DTSTAMP:",
+		"DTSTART:20260301T023000Z",
+		`SUMMARY:Call Bob\, then\; file`,
+		"PRIORITY:1",
+		"LAST-MODIFIED:",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %q", len(want), lines)
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d: expected %q, got %q", i+1, prefix, lines[i])
+		}
+	}
+}
+
+// TestExportICS_NoDueDate: a todo without a due date can't be exported
+func TestExportICS_NoDueDate(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos/:id/ics", handler.ExportICS)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Someday"})
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "Not mine"})
+
+	for path, want := range map[string]int{"/todos/1/ics": http.StatusBadRequest, "/todos/2/ics": http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Errorf("%s: expected status %d, got %d", path, want, w.Code)
+		}
+	}
+}
+
+// TestICSFold: long lines are folded at 75 octets without splitting a character
+func TestICSFold(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("é", 80)
+
+	folded := icsFold(line)
+
+	parts := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n ")
+	for i, part := range parts {
+		if len(part) > 75 || !strings.HasPrefix(line, strings.Join(parts[:i+1], "")) {
+			t.Fatalf("bad fold %d: %q", i, part)
+		}
+	}
+	if strings.Join(parts, "") != line {
+		t.Errorf("unfolding gave %q", strings.Join(parts, ""))
+	}
+}
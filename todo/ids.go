@@ -0,0 +1,64 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// flexID is an id decoded from either a JSON number or a numeric string,
+// so clients that receive ids as strings can send them back unchanged.
+type flexID uint
+
+func (id *flexID) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		s, _ = strconv.Unquote(s)
+	}
+	n, err := strconv.ParseUint(s, 10, strconv.IntSize)
+	if err != nil {
+		return fmt.Errorf("id must be a non-negative integer or a string holding one, got %s", data)
+	}
+	*id = flexID(n)
+	return nil
+}
+
+type plainTodo Todo
+
+// todoWire is the decoded form of a Todo, whose ID may be a number or a
+// string and whose due date may be a flexTime. Decoding into it fills in
+// the todo it wraps, apart from those two; apply copies them over.
+type todoWire struct {
+	*plainTodo
+	ID      flexID    `json:"ID"`
+	DueDate *flexTime `json:"due_date"`
+}
+
+func (t *Todo) wire() *todoWire {
+	w := &todoWire{plainTodo: (*plainTodo)(t), ID: flexID(t.ID)}
+	if t.DueDate != nil {
+		w.DueDate = &flexTime{*t.DueDate}
+	}
+	return w
+}
+
+// apply copies the fields decoded by w onto the todo it wraps.
+func (w *todoWire) apply() {
+	w.plainTodo.ID = uint(w.ID)
+	w.plainTodo.DueDate = w.DueDate.ptr()
+}
+
+// UnmarshalJSON decodes a todo whose ID may be a number or a string and
+// whose due date may be RFC 3339 or epoch milliseconds.
+func (t *Todo) UnmarshalJSON(data []byte) error {
+	w := t.wire()
+	if err := json.Unmarshal(data, w); err != nil {
+		return err
+	}
+	w.apply()
+	return nil
+}
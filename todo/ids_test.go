@@ -0,0 +1,107 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func getWithIDs(t *testing.T, idsAsStrings bool) map[string]any {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	db.Create(&Todo{Title: "Big id", UserID: testUserID, Model: gorm.Model{ID: 1 << 53}})
+	handler := NewTodoHandler(db, Config{IDsAsStrings: idsAsStrings})
+	router := gin.New()
+	router.Use(asUser(testUserID))
+	router.GET("/todos/:id", handler.GetTask)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos/9007199254740992", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response map[string]any
+	dec := json.NewDecoder(w.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return response
+}
+
+// TestIDsAsStrings_Off: ids are JSON numbers by default
+func TestIDsAsStrings_Off(t *testing.T) {
+	response := getWithIDs(t, false)
+	if id, ok := response["ID"].(json.Number); !ok || id.String() != "9007199254740992" {
+		t.Errorf("expected numeric ID, got %#v", response["ID"])
+	}
+}
+
+// TestIDsAsStrings_On: ids are JSON strings, other numbers are untouched
+func TestIDsAsStrings_On(t *testing.T) {
+	response := getWithIDs(t, true)
+	if response["ID"] != "9007199254740992" {
+		t.Errorf("expected string ID, got %#v", response["ID"])
+	}
+	if _, ok := response["user_id"].(json.Number); !ok {
+		t.Errorf("expected numeric user_id, got %#v", response["user_id"])
+	}
+}
+
+// TestIDsAsStrings_SyncConflicts: conflict ids are strings too
+func TestIDsAsStrings_SyncConflicts(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	handler.cfg.IDsAsStrings = true
+	handler.db.Create(&Todo{Title: "Someone else's", UserID: testUserID + 1, Model: gorm.Model{ID: 7}})
+	router.POST("/todos/sync", handler.Sync)
+
+	body := `[{"id": "7", "text": "Mine"}]`
+	req := httptest.NewRequest(http.MethodPost, "/todos/sync", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var result struct {
+		Conflicts []map[string]any `json:"conflicts"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0]["id"] != "7" {
+		t.Errorf("expected one conflict with id \"7\", got %s", w.Body.String())
+	}
+}
+
+// TestFlexID: ids decode from numbers and numeric strings only
+func TestFlexID(t *testing.T) {
+	for in, want := range map[string]flexID{`42`: 42, `"42"`: 42, `null`: 0} {
+		var id flexID
+		if err := json.Unmarshal([]byte(in), &id); err != nil || id != want {
+			t.Errorf("decode %s: got %d, %v; want %d", in, id, err, want)
+		}
+	}
+	for _, in := range []string{`"abc"`, `-1`, `1.5`, `""`, `true`} {
+		var id flexID
+		if err := json.Unmarshal([]byte(in), &id); err == nil {
+			t.Errorf("decode %s: expected an error", in)
+		}
+	}
+}
+
+// TestTodo_UnmarshalStringID: a todo echoed back with a string ID decodes
+func TestTodo_UnmarshalStringID(t *testing.T) {
+	var todo Todo
+	if err := json.Unmarshal([]byte(`{"ID": "12", "text": "Echoed"}`), &todo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if todo.ID != 12 || todo.Title != "Echoed" {
+		t.Errorf("unexpected todo %+v", todo)
+	}
+}
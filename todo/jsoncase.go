@@ -0,0 +1,70 @@
+package todo
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Supported values for Config.JSONCase.
+const (
+	SnakeCase = "snake"
+	CamelCase = "camel"
+)
+
+// splitWords breaks a JSON key such as "CreatedAt", "due_date" or "UserID"
+// into its lower-cased words, keeping acronyms together.
+func splitWords(key string) []string {
+	var words []string
+	for _, part := range strings.Split(key, "_") {
+		runes := []rune(part)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsUpper(cur) && (unicode.IsLower(prev) || (unicode.IsUpper(prev) && nextIsLower)) {
+				words = append(words, strings.ToLower(string(runes[start:i])))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			words = append(words, strings.ToLower(string(runes[start:])))
+		}
+	}
+	return words
+}
+
+func toSnake(key string) string {
+	return strings.Join(splitWords(key), "_")
+}
+
+func toCamel(key string) string {
+	words := splitWords(key)
+	for i := 1; i < len(words); i++ {
+		words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+	}
+	return strings.Join(words, "")
+}
+
+// renameKeys rewrites every object key in a decoded JSON value with fn.
+// Client metadata is left as sent.
+func renameKeys(v any, fn func(string) string) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, inner := range val {
+			if k == "metadata" {
+				out[k] = inner
+				continue
+			}
+			out[fn(k)] = renameKeys(inner, fn)
+		}
+		return out
+	case []any:
+		for i, inner := range val {
+			val[i] = renameKeys(inner, fn)
+		}
+		return val
+	default:
+		return v
+	}
+}
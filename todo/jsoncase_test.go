@@ -0,0 +1,114 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestToSnake(t *testing.T) {
+	tests := map[string]string{
+		"ID":        "id",
+		"CreatedAt": "created_at",
+		"UserID":    "user_id",
+		"due_date":  "due_date",
+		"text":      "text",
+	}
+	for in, want := range tests {
+		if got := toSnake(in); got != want {
+			t.Errorf("toSnake(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestToCamel(t *testing.T) {
+	tests := map[string]string{
+		"ID":        "id",
+		"CreatedAt": "createdAt",
+		"UserID":    "userId",
+		"due_date":  "dueDate",
+		"text":      "text",
+	}
+	for in, want := range tests {
+		if got := toCamel(in); got != want {
+			t.Errorf("toCamel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func createWithCase(t *testing.T, jsonCase string) map[string]any {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	handler := NewTodoHandler(setupTestDB(t), Config{JSONCase: jsonCase})
+	router := gin.New()
+	router.Use(asUser(testUserID))
+	router.POST("/todos", handler.NewTask)
+
+	due := time.Date(2025, 1, 31, 17, 0, 0, 0, time.UTC)
+	metadata := map[string]any{"foo_bar": 1, "nested": map[string]any{"someKey": []any{map[string]any{"inner_key": true}}}}
+	jsonData, _ := json.Marshal(map[string]any{"text": "Naming", "due_date": due, "metadata": metadata})
+	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return response
+}
+
+func assertKeys(t *testing.T, response map[string]any, present, absent []string) {
+	t.Helper()
+	for _, k := range present {
+		if _, ok := response[k]; !ok {
+			t.Errorf("expected key %q in %v", k, response)
+		}
+	}
+	for _, k := range absent {
+		if _, ok := response[k]; ok {
+			t.Errorf("expected no key %q in %v", k, response)
+		}
+	}
+}
+
+// TestJSONCase_Snake: JSON_CASE=snake emits snake_case keys and keeps text
+func TestJSONCase_Snake(t *testing.T) {
+	response := createWithCase(t, SnakeCase)
+	assertKeys(t, response,
+		[]string{"id", "text", "due_date", "created_at", "updated_at", "deleted_at"},
+		[]string{"ID", "CreatedAt", "createdAt"})
+}
+
+// TestJSONCase_Camel: JSON_CASE=camel emits camelCase keys and keeps text
+func TestJSONCase_Camel(t *testing.T) {
+	response := createWithCase(t, CamelCase)
+	assertKeys(t, response,
+		[]string{"id", "text", "dueDate", "createdAt", "updatedAt", "deletedAt"},
+		[]string{"ID", "due_date", "created_at"})
+}
+
+// TestJSONCase_Default: no JSON_CASE keeps the model's declared keys
+func TestJSONCase_Default(t *testing.T) {
+	response := createWithCase(t, "")
+	assertKeys(t, response,
+		[]string{"ID", "text", "due_date", "CreatedAt"},
+		[]string{"id", "created_at"})
+}
+
+// TestJSONCase_MetadataAsSent: renaming keys leaves nested metadata keys as the client sent them
+func TestJSONCase_MetadataAsSent(t *testing.T) {
+	for _, jsonCase := range []string{CamelCase, SnakeCase} {
+		response := createWithCase(t, jsonCase)
+		got, _ := json.Marshal(response["metadata"])
+		if want := `{"foo_bar":1,"nested":{"someKey":[{"inner_key":true}]}}`; string(got) != want {
+			t.Errorf("%s: expected metadata %s, got %s", jsonCase, want, got)
+		}
+	}
+}
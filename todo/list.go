@@ -0,0 +1,123 @@
+package todo
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/settings"
+)
+
+// sortOrders maps each of settings.Sorts to its ORDER BY clause. Ties are
+// broken by id.
+var sortOrders = map[string]string{
+	"id":          "id",
+	"-id":         "id DESC",
+	"due_date":    "due_date",
+	"-due_date":   "due_date DESC",
+	"updated_at":  "updated_at",
+	"-updated_at": "updated_at DESC",
+}
+
+// ListTasks returns a page of the caller's todos, filtered as the query
+// asks. ?sort= and ?limit= default to the caller's stored settings, and
+// otherwise to id order and the configured page size.
+func (t *TodoHandler) ListTasks(c *gin.Context) {
+	_, userID, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	deleted, ok := includeDeleted(c)
+	if !ok {
+		return
+	}
+	since, ok := modifiedSince(c)
+	if !ok {
+		return
+	}
+	var prefs settings.Preferences
+	err := t.retry(c, func() error {
+		var err error
+		prefs, err = settings.Load(t.conn(c), userID)
+		return err
+	})
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	limits := t.cfg.PageLimits
+	if prefs.PageSize > 0 {
+		limits = limits.WithDefault(prefs.PageSize)
+	}
+	p, ok := pageWithin(c, limits)
+	if !ok {
+		return
+	}
+	sort := c.DefaultQuery("sort", cmp.Or(prefs.Sort, "id"))
+	if _, err := orderFor(sort); err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	f, err := filterFromQuery(c)
+	if err != nil {
+		apperr.Write(c, apperr.ErrBadRequest.Wrap(err))
+		return
+	}
+	if !selectFields(c) || !selectZone(c) || !selectTruncate(c) {
+		return
+	}
+
+	// The tag is read before the page, so a write in between leaves the
+	// client with an older tag and a refetch, never a stale 304. Overdue
+	// filters depend on the clock, so their results get no tag.
+	if f.Overdue == nil {
+		var tag string
+		err := t.retry(c, func() error {
+			var err error
+			tag, err = collectionETag(c, t.conn(c), userID, fmt.Sprintf("%s|%d", sort, p.Limit))
+			return err
+		})
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.Header("ETag", tag)
+		if noneMatch(c.GetHeader("If-None-Match"), tag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	todos, err := t.List(c.Request.Context(), userID, ListQuery{
+		Filter:         f,
+		Sort:           sort,
+		Page:           p,
+		IncludeDeleted: deleted,
+		ModifiedSince:  since,
+	})
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	t.respond(c, http.StatusOK, todos)
+}
+
+// modifiedSince reads ?modified_since=<RFC 3339 or epoch ms> for delta sync: only the
+// todos updated or deleted after that time are listed, deleted ones
+// included, so clients can drop them locally. It writes a 400 and returns
+// false if the parameter is not a valid timestamp.
+func modifiedSince(c *gin.Context) (*time.Time, bool) {
+	raw, ok := c.GetQuery("modified_since")
+	if !ok {
+		return nil, true
+	}
+	since, err := parseTime(raw)
+	if err != nil {
+		apperr.Write(c, apperr.ErrBadRequest.With("modified_since must be an RFC 3339 timestamp or epoch milliseconds"))
+		return nil, false
+	}
+	return &since, true
+}
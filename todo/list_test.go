@@ -0,0 +1,314 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/pagination"
+	"github.com/pradist/todoapi/settings"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+func doList(t *testing.T, router *gin.Engine, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/todos"+query, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func decodeTodos(t *testing.T, w *httptest.ResponseRecorder) []Todo {
+	t.Helper()
+	var todos []Todo
+	if err := json.Unmarshal(w.Body.Bytes(), &todos); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return todos
+}
+
+func seedDueDates(t *testing.T, handler *TodoHandler) {
+	t.Helper()
+	due := time.Now().Add(24 * time.Hour)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "scheduled", DueDate: &due})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "unscheduled"})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "also unscheduled"})
+}
+
+func TestListTasks_ReturnsAll(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	seedDueDates(t, handler)
+
+	w := doList(t, router, "")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if todos := decodeTodos(t, w); len(todos) != 3 {
+		t.Errorf("expected 3 todos, got %d", len(todos))
+	}
+}
+
+func TestListTasks_EmptyIsArray(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+
+	w := doList(t, router, "")
+
+	if w.Body.String() != "[]" {
+		t.Errorf("expected empty JSON array, got %s", w.Body.String())
+	}
+}
+
+// TestListTasks_HasDueDate: has_due_date=true/false splits scheduled and unscheduled todos
+func TestListTasks_HasDueDate(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	seedDueDates(t, handler)
+
+	withDue := decodeTodos(t, doList(t, router, "?has_due_date=true"))
+	if len(withDue) != 1 || withDue[0].Title != "scheduled" {
+		t.Errorf("expected only the scheduled todo, got %+v", withDue)
+	}
+
+	withoutDue := decodeTodos(t, doList(t, router, "?has_due_date=false"))
+	if len(withoutDue) != 2 {
+		t.Errorf("expected 2 unscheduled todos, got %d", len(withoutDue))
+	}
+	for _, todo := range withoutDue {
+		if todo.DueDate != nil {
+			t.Errorf("expected no due date, got %v", todo.DueDate)
+		}
+	}
+}
+
+func TestListTasks_HasDueDateInvalid(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+
+	w := doList(t, router, "?has_due_date=maybe")
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestListTasks_FilterWithPaging: filters and paging are applied together
+func TestListTasks_FilterWithPaging(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	seedDueDates(t, handler)
+
+	todos := decodeTodos(t, doList(t, router, "?has_due_date=false&limit=1&page=2"))
+
+	if len(todos) != 1 || todos[0].Title != "also unscheduled" {
+		t.Errorf("expected the second unscheduled todo, got %+v", todos)
+	}
+}
+
+func TestListTasks_InvalidPaging(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+
+	for _, query := range []string{"?page=0", "?page=abc", "?limit=0", "?limit=-5", "?page=1000000"} {
+		w := doList(t, router, query)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestListTasks_ConfiguredPageLimits: PageLimits sets the default size and clamps ?limit=
+func TestListTasks_ConfiguredPageLimits(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	handler.cfg.PageLimits = pagination.Limits{Default: 2, Max: 3}
+	router.GET("/todos", handler.ListTasks)
+	for range 5 {
+		handler.db.Create(&Todo{UserID: testUserID, Title: "todo"})
+	}
+
+	if todos := decodeTodos(t, doList(t, router, "")); len(todos) != 2 {
+		t.Errorf("expected default page of 2, got %d", len(todos))
+	}
+	if todos := decodeTodos(t, doList(t, router, "?limit=50")); len(todos) != 3 {
+		t.Errorf("expected limit clamped to 3, got %d", len(todos))
+	}
+}
+
+func TestListTasks_OnlyOwnTodos(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "mine"})
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "theirs"})
+
+	todos := decodeTodos(t, doList(t, router, ""))
+
+	if len(todos) != 1 || todos[0].Title != "mine" {
+		t.Errorf("expected only the caller's todo, got %+v", todos)
+	}
+}
+
+// TestListTasks_ModifiedSince: only todos changed after the cutoff are returned, deleted ones flagged
+func TestListTasks_ModifiedSince(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	cutoff := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	before, after := cutoff.Add(-time.Minute), cutoff.Add(time.Minute)
+	for _, todo := range []Todo{
+		{UserID: testUserID, Title: "unchanged", Model: gorm.Model{UpdatedAt: before}},
+		{UserID: testUserID, Title: "updated", Model: gorm.Model{UpdatedAt: after}},
+		{UserID: testUserID, Title: "deleted", Model: gorm.Model{UpdatedAt: before, DeletedAt: gorm.DeletedAt{Time: after, Valid: true}}},
+		{UserID: testUserID, Title: "deleted long ago", Model: gorm.Model{UpdatedAt: before, DeletedAt: gorm.DeletedAt{Time: before, Valid: true}}},
+		{UserID: testUserID + 1, Title: "theirs", Model: gorm.Model{UpdatedAt: after}},
+	} {
+		handler.db.Create(&todo)
+	}
+
+	w := doList(t, router, "?modified_since="+cutoff.Format(time.RFC3339))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	todos := decodeTodos(t, w)
+	if len(todos) != 2 || todos[0].Title != "updated" || todos[1].Title != "deleted" {
+		t.Fatalf("expected the updated and deleted todos, got %+v", todos)
+	}
+	if todos[0].Deleted || !todos[1].Deleted {
+		t.Errorf("expected only the deleted todo flagged, got %v and %v", todos[0].Deleted, todos[1].Deleted)
+	}
+
+	if w := doList(t, router, "?modified_since=yesterday"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an invalid timestamp, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestListTasks_CompletedAndPriority: completed and priority filters are ANDed
+func TestListTasks_CompletedAndPriority(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "open high", Priority: PriorityHigh})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "done high", Priority: PriorityHigh, Completed: true})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "open low", Priority: PriorityLow})
+
+	todos := decodeTodos(t, doList(t, router, "?completed=false&priority=high"))
+
+	if len(todos) != 1 || todos[0].Title != "open high" {
+		t.Errorf("expected only 'open high', got %+v", todos)
+	}
+}
+
+// TestListTasks_MatchAll: by default every filter must match
+func TestListTasks_MatchAll(t *testing.T) {
+	router := setupMatchTodos(t)
+
+	for _, query := range []string{"?completed=true&priority=high", "?completed=true&priority=high&match=all"} {
+		todos := decodeTodos(t, doList(t, router, query))
+		if len(todos) != 1 || todos[0].Title != "done high" {
+			t.Errorf("%s: expected only 'done high', got %+v", query, todos)
+		}
+	}
+}
+
+// TestListTasks_MatchAny: match=any returns todos matching at least one filter, still only the caller's
+func TestListTasks_MatchAny(t *testing.T) {
+	router := setupMatchTodos(t)
+
+	todos := decodeTodos(t, doList(t, router, "?completed=true&priority=high&match=any"))
+
+	var titles []string
+	for _, todo := range todos {
+		titles = append(titles, todo.Title)
+	}
+	if !slices.Equal(titles, []string{"done low", "open high", "done high"}) {
+		t.Errorf("expected done or high todos of the caller, got %v", titles)
+	}
+}
+
+func setupMatchTodos(t *testing.T) *gin.Engine {
+	t.Helper()
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "open low", Priority: PriorityLow})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "done low", Priority: PriorityLow, Completed: true})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "open high", Priority: PriorityHigh})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "done high", Priority: PriorityHigh, Completed: true})
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "someone else's", Priority: PriorityHigh})
+	return router
+}
+
+func TestListTasks_Overdue(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "late", DueDate: &past})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "late but done", DueDate: &past, Completed: true})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "on time", DueDate: &future})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "no deadline"})
+
+	overdue := decodeTodos(t, doList(t, router, "?overdue=true"))
+	if len(overdue) != 1 || overdue[0].Title != "late" {
+		t.Errorf("expected only 'late', got %+v", overdue)
+	}
+
+	notOverdue := decodeTodos(t, doList(t, router, "?overdue=false"))
+	if len(notOverdue) != 3 {
+		t.Errorf("expected 3 todos that are not overdue, got %d", len(notOverdue))
+	}
+}
+
+func TestListTasks_InvalidFilters(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+
+	for _, query := range []string{"?completed=yes", "?priority=urgent", "?overdue=1x", "?match=some"} {
+		w := doList(t, router, query)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestListTasks_Settings: stored sort and page size apply unless the query overrides them
+func TestListTasks_Settings(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	for _, title := range []string{"first", "second", "third"} {
+		handler.db.Create(&Todo{UserID: testUserID, Title: title})
+	}
+	handler.db.Create(&settings.Settings{UserID: testUserID, Data: datatypes.JSON(`{"sort": "-id", "page_size": 2}`)})
+
+	for query, want := range map[string][]string{
+		"":                  {"third", "second"},
+		"?limit=3":          {"third", "second", "first"},
+		"?sort=id":          {"first", "second"},
+		"?sort=id&limit=10": {"first", "second", "third"},
+	} {
+		var titles []string
+		for _, todo := range decodeTodos(t, doList(t, router, query)) {
+			titles = append(titles, todo.Title)
+		}
+		if !slices.Equal(titles, want) {
+			t.Errorf("%q: expected %v, got %v", query, want, titles)
+		}
+	}
+
+	if w := doList(t, router, "?sort=title"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown sort to be %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestSortOrders_CoverSettings: every sort settings accept can be listed
+func TestSortOrders_CoverSettings(t *testing.T) {
+	for _, sort := range settings.Sorts {
+		if _, ok := sortOrders[sort]; !ok {
+			t.Errorf("sort %q has no ORDER BY clause", sort)
+		}
+	}
+}
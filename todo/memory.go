@@ -0,0 +1,198 @@
+package todo
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemoryTodoRepository is a TodoRepository held in memory, for testing
+// TodoService without a database. It keeps no audit log.
+type MemoryTodoRepository struct {
+	mu     sync.Mutex
+	todos  map[uint]Todo
+	nextID uint
+}
+
+func NewMemoryTodoRepository() *MemoryTodoRepository {
+	return &MemoryTodoRepository{todos: map[uint]Todo{}, nextID: 1}
+}
+
+func (r *MemoryTodoRepository) Create(_ context.Context, todo *Todo, quota int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if quota > 0 && r.count(todo.UserID) >= quota {
+		return errQuotaExceeded
+	}
+	now := time.Now()
+	todo.Model = gorm.Model{ID: r.nextID, CreatedAt: now, UpdatedAt: now}
+	r.nextID++
+	r.todos[todo.ID] = *todo
+	return nil
+}
+
+// count returns how many active todos userID holds.
+func (r *MemoryTodoRepository) count(userID uint) int {
+	n := 0
+	for _, todo := range r.todos {
+		if todo.UserID == userID && !todo.DeletedAt.Valid {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *MemoryTodoRepository) FindByID(_ context.Context, userID, id uint, includeDeleted bool) (Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	todo, ok := r.todos[id]
+	if !ok || todo.UserID != userID || (todo.DeletedAt.Valid && !includeDeleted) {
+		return Todo{}, errTodoNotFound
+	}
+	return todo, nil
+}
+
+func (r *MemoryTodoRepository) List(_ context.Context, userID uint, q ListQuery) ([]Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	todos := []Todo{}
+	for _, todo := range r.todos {
+		if todo.UserID != userID || !q.Filter.matches(todo, now) {
+			continue
+		}
+		if q.ModifiedSince != nil {
+			since := *q.ModifiedSince
+			if !todo.UpdatedAt.After(since) && !(todo.DeletedAt.Valid && todo.DeletedAt.Time.After(since)) {
+				continue
+			}
+			todo.Deleted = todo.DeletedAt.Valid
+		} else if todo.DeletedAt.Valid && !q.IncludeDeleted {
+			continue
+		}
+		todos = append(todos, todo)
+	}
+
+	field, desc := strings.CutPrefix(q.Sort, "-")
+	slices.SortFunc(todos, func(a, b Todo) int {
+		var c int
+		switch field {
+		case "due_date":
+			c = compareTimes(a.DueDate, b.DueDate)
+		case "updated_at":
+			c = a.UpdatedAt.Compare(b.UpdatedAt)
+		}
+		if desc {
+			c = -c
+		}
+		return cmp.Or(c, cmp.Compare(a.ID, b.ID))
+	})
+	if field == "id" && desc {
+		slices.Reverse(todos)
+	}
+
+	start := min(q.Page.Offset(), len(todos))
+	end := min(start+q.Page.Limit, len(todos))
+	return todos[start:end], nil
+}
+
+// compareTimes orders optional times with nil first, as SQLite orders
+// NULLs.
+func compareTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return a.Compare(*b)
+}
+
+// matches reports whether todo is selected by f at time now, as apply
+// selects rows.
+func (f Filter) matches(todo Todo, now time.Time) bool {
+	var results []bool
+	if f.Completed != nil {
+		results = append(results, todo.Completed == *f.Completed)
+	}
+	if f.Priority != nil {
+		results = append(results, todo.Priority == *f.Priority)
+	}
+	if f.HasDueDate != nil {
+		results = append(results, (todo.DueDate != nil) == *f.HasDueDate)
+	}
+	if f.Overdue != nil {
+		overdue := !todo.Completed && todo.DueDate != nil && todo.DueDate.Before(now)
+		results = append(results, overdue == *f.Overdue)
+	}
+	if f.Match == MatchAny && len(results) > 0 {
+		return slices.Contains(results, true)
+	}
+	return !slices.Contains(results, false)
+}
+
+func (r *MemoryTodoRepository) Update(_ context.Context, userID, id uint, apply func(todo *Todo) error) (Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	todo, ok := r.todos[id]
+	if !ok || todo.UserID != userID || todo.DeletedAt.Valid {
+		return Todo{}, errTodoNotFound
+	}
+	if err := apply(&todo); err != nil {
+		return Todo{}, err
+	}
+	todo.UpdatedAt = time.Now()
+	r.todos[id] = todo
+	return todo, nil
+}
+
+func (r *MemoryTodoRepository) Put(_ context.Context, id uint, quota int, build func(existing *Todo) (Todo, error)) (Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	existing, ok := r.todos[id]
+	if !ok {
+		todo, err := build(nil)
+		if err != nil {
+			return Todo{}, err
+		}
+		if quota > 0 && r.count(todo.UserID) >= quota {
+			return Todo{}, errQuotaExceeded
+		}
+		todo.Model = gorm.Model{ID: id, CreatedAt: now, UpdatedAt: now}
+		r.nextID = max(r.nextID, id+1)
+		r.todos[id] = todo
+		return todo, nil
+	}
+	todo, err := build(&existing)
+	if err != nil {
+		return Todo{}, err
+	}
+	todo.Model = existing.Model
+	todo.UpdatedAt = now
+	r.todos[id] = todo
+	return todo, nil
+}
+
+func (r *MemoryTodoRepository) Delete(_ context.Context, userID, id uint, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	todo, ok := r.todos[id]
+	if !ok || todo.UserID != userID {
+		return errTodoNotFound
+	}
+	if todo.DeletedAt.Valid {
+		return nil
+	}
+	todo.DeleteReason = reason
+	todo.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.todos[id] = todo
+	return nil
+}
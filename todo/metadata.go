@@ -0,0 +1,35 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/datatypes"
+)
+
+// MaxMetadataSize is the largest metadata accepted, in bytes of compact
+// JSON.
+const MaxMetadataSize = 4096
+
+var errMetadataObject = errors.New("metadata must be a JSON object")
+
+// cleanMetadata compacts a todo's metadata and checks that it is an object
+// within MaxMetadataSize. Null clears it.
+func cleanMetadata(meta *datatypes.JSON) error {
+	raw := bytes.TrimSpace(*meta)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		*meta = nil
+		return nil
+	}
+	var compact bytes.Buffer
+	if raw[0] != '{' || json.Compact(&compact, raw) != nil {
+		return errMetadataObject
+	}
+	if compact.Len() > MaxMetadataSize {
+		return fmt.Errorf("metadata must be at most %d bytes", MaxMetadataSize)
+	}
+	*meta = compact.Bytes()
+	return nil
+}
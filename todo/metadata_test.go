@@ -0,0 +1,96 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func doJSON(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func metadataOf(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var response struct {
+		Metadata json.RawMessage `json:"metadata"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return string(response.Metadata)
+}
+
+// TestMetadata_RoundTrip: nested metadata is stored as given and returned on read
+func TestMetadata_RoundTrip(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+	router.GET("/todos/:id", handler.GetTask)
+	router.PUT("/todos/:id", handler.PutTask)
+
+	meta := `{"project": {"name": "apollo", "tags": ["a", "b"], "stage": 3}, "pinned": true}`
+	w := doJSON(router, http.MethodPost, "/todos", `{"text": "With metadata", "metadata": `+meta+`}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	want := `{"project":{"name":"apollo","tags":["a","b"],"stage":3},"pinned":true}`
+	if got := metadataOf(t, w); got != want {
+		t.Errorf("expected %s from create, got %s", want, got)
+	}
+	w = doJSON(router, http.MethodGet, "/todos/1", "")
+	if got := metadataOf(t, w); got != want {
+		t.Errorf("expected %s from get, got %s", want, got)
+	}
+
+	w = doJSON(router, http.MethodPut, "/todos/1", `{"text": "Replaced"}`)
+	if got := metadataOf(t, w); got != "null" {
+		t.Errorf("expected PUT without metadata to clear it, got %s", got)
+	}
+}
+
+// TestMetadata_Invalid: metadata must be an object within the size limit
+func TestMetadata_Invalid(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+	router.POST("/todos/sync", handler.Sync)
+
+	big := `{"blob": "` + strings.Repeat("x", MaxMetadataSize) + `"}`
+	for _, meta := range []string{`[1, 2]`, `"text"`, `42`, big} {
+		w := doJSON(router, http.MethodPost, "/todos", `{"text": "Bad", "metadata": `+meta+`}`)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("metadata %.20s: expected status %d, got %d", meta, http.StatusUnprocessableEntity, w.Code)
+		}
+	}
+
+	w := doJSON(router, http.MethodPost, "/todos/sync", `[{"id": 1, "text": "Bad", "metadata": [1]}]`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("sync: expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+// TestMetadata_Sync: synced todos keep their metadata
+func TestMetadata_Sync(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos/sync", handler.Sync)
+
+	w := doJSON(router, http.MethodPost, "/todos/sync", `[{"id": 5, "text": "Offline", "metadata": {"device": "phone"}}]`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var stored Todo
+	handler.db.First(&stored, 5)
+	if string(stored.Metadata) != `{"device":"phone"}` {
+		t.Errorf("expected the metadata to be stored, got %s", stored.Metadata)
+	}
+}
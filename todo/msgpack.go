@@ -0,0 +1,64 @@
+package todo
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/response"
+	"github.com/ugorji/go/codec"
+)
+
+const msgpackMediaType = "application/msgpack"
+
+// msgpackHandle writes the current MessagePack spec, with distinct str and
+// bin types.
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+func wantsMsgpack(c *gin.Context) bool {
+	return accepts(c, msgpackMediaType, "application/x-msgpack")
+}
+
+// toMsgpack encodes the JSON body of a response as MessagePack. The body
+// goes through its JSON form first, so field names, timestamps and every
+// rewrite respond applies come out as they would in JSON; only numbers
+// change, becoming MessagePack integers or floats.
+func toMsgpack(body any) ([]byte, error) {
+	generic, err := response.Generic(body)
+	if err != nil {
+		return nil, err
+	}
+	var out []byte
+	err = codec.NewEncoderBytes(&out, msgpackHandle).Encode(msgpackNumbers(generic))
+	return out, err
+}
+
+// msgpackNumbers replaces the json.Numbers in a decoded JSON value with
+// int64, uint64 or float64, whichever holds them exactly.
+func msgpackNumbers(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, inner := range val {
+			val[k] = msgpackNumbers(inner)
+		}
+		return val
+	case []any:
+		for i, inner := range val {
+			val[i] = msgpackNumbers(inner)
+		}
+		return val
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		if n, err := strconv.ParseUint(val.String(), 10, 64); err == nil {
+			return n
+		}
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+		return val.String()
+	default:
+		return v
+	}
+}
@@ -0,0 +1,85 @@
+package todo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ugorji/go/codec"
+)
+
+func decodeMsgpack(t *testing.T, w *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	h := new(codec.MsgpackHandle)
+	h.RawToString = true
+	h.MapType = reflect.TypeOf(map[string]any(nil))
+	if err := codec.NewDecoderBytes(w.Body.Bytes(), h).Decode(v); err != nil {
+		t.Fatalf("failed to decode MessagePack: %v", err)
+	}
+}
+
+// TestRespond_Msgpack: Accept: application/msgpack returns the created todo as MessagePack with its JSON fields
+func TestRespond_Msgpack(t *testing.T) {
+	w := doCreateWithAccept(t, "application/msgpack")
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != msgpackMediaType {
+		t.Errorf("expected Content-Type %q, got %q", msgpackMediaType, ct)
+	}
+	var todo map[string]any
+	decodeMsgpack(t, w, &todo)
+	if todo["ID"] != int64(1) || todo["text"] != "Read the spec" || todo["priority"] != PriorityMedium || todo["completed"] != false {
+		t.Errorf("unexpected todo: %v", todo)
+	}
+	if _, ok := todo["CreatedAt"].(string); !ok {
+		t.Errorf("expected CreatedAt as in JSON, got %T", todo["CreatedAt"])
+	}
+}
+
+// TestRespond_MsgpackList: lists and their envelope are encoded like JSON
+func TestRespond_MsgpackList(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	handler.cfg.ResponseEnvelope = true
+	router.GET("/todos", handler.ListTasks)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "a"})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "b"})
+
+	req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+	req.Header.Set("Accept", "application/msgpack")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var doc struct {
+		Data []map[string]any `codec:"data"`
+		Meta map[string]any   `codec:"meta"`
+	}
+	decodeMsgpack(t, w, &doc)
+	if len(doc.Data) != 2 || doc.Data[1]["text"] != "b" || doc.Meta["count"] != int64(2) {
+		t.Errorf("expected todos a and b with their meta, got %+v", doc)
+	}
+}
+
+// TestRespond_MsgpackFallback: errors and other media types stay JSON
+func TestRespond_MsgpackFallback(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos/:id", handler.GetTask)
+
+	req := httptest.NewRequest(http.MethodGet, "/todos/1", nil)
+	req.Header.Set("Accept", "application/msgpack")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != jsonMediaType {
+		t.Errorf("expected errors as JSON, got %q", ct)
+	}
+
+	if w := doCreateWithAccept(t, "application/vnd.api+json, application/msgpack"); w.Header().Get("Content-Type") != jsonAPIMediaType {
+		t.Errorf("expected JSON:API to keep its own format, got %q", w.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,26 @@
+package todo
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/pagination"
+	"github.com/pradist/todoapi/response"
+)
+
+// page reads ?page= and ?limit= within the configured limits and stores the
+// page for the envelope's meta. It writes a 400 and returns false if they are
+// invalid.
+func (t *TodoHandler) page(c *gin.Context) (pagination.Page, bool) {
+	return pageWithin(c, t.cfg.PageLimits)
+}
+
+// pageWithin is page with the given limits.
+func pageWithin(c *gin.Context, limits pagination.Limits) (pagination.Page, bool) {
+	p, err := limits.FromQuery(c)
+	if err != nil {
+		apperr.Write(c, apperr.ErrBadRequest.Wrap(err))
+		return pagination.Page{}, false
+	}
+	response.SetPage(c, p)
+	return p, true
+}
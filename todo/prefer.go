@@ -0,0 +1,50 @@
+package todo
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Values of the return preference (RFC 7240).
+const (
+	ReturnMinimal        = "minimal"
+	ReturnRepresentation = "representation"
+)
+
+// preferredReturn returns the value of the return preference in the
+// request's Prefer headers, lower-cased, or "" if there is none. Names are
+// matched case-insensitively and the value may be quoted; parameters after
+// ";" are ignored. The first occurrence wins.
+func preferredReturn(c *gin.Context) string {
+	for _, header := range c.Request.Header.Values("Prefer") {
+		for _, pref := range strings.Split(header, ",") {
+			pref, _, _ = strings.Cut(pref, ";")
+			name, value, _ := strings.Cut(pref, "=")
+			if strings.EqualFold(strings.TrimSpace(name), "return") {
+				return strings.ToLower(strings.Trim(strings.TrimSpace(value), `"`))
+			}
+		}
+	}
+	return ""
+}
+
+// respondCreated answers a create with 201, the new todo's Location and
+// ETag, and the todo itself unless the client sent Prefer: return=minimal,
+// in which case the body is left empty. Honoured preferences are echoed in
+// Preference-Applied.
+func (t *TodoHandler) respondCreated(c *gin.Context, todo Todo) {
+	c.Header("Location", "/todos/"+strconv.FormatUint(uint64(todo.ID), 10))
+	setETag(c, todo)
+	switch preferredReturn(c) {
+	case ReturnMinimal:
+		c.Header("Preference-Applied", "return="+ReturnMinimal)
+		c.Status(http.StatusCreated)
+		return
+	case ReturnRepresentation:
+		c.Header("Preference-Applied", "return="+ReturnRepresentation)
+	}
+	t.respond(c, http.StatusCreated, todo)
+}
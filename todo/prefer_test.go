@@ -0,0 +1,69 @@
+package todo
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func doPrefer(router *gin.Engine, prefer string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBufferString(`{"text": "Preferred"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestNewTask_PreferMinimal: Prefer: return=minimal answers 201 with a Location and no body
+func TestNewTask_PreferMinimal(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+
+	for _, prefer := range []string{"return=minimal", `respond-async, RETURN="minimal"; x=1`} {
+		w := doPrefer(router, prefer)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("%s: expected status %d, got %d", prefer, http.StatusCreated, w.Code)
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("%s: expected an empty body, got %s", prefer, w.Body.String())
+		}
+		if w.Header().Get("Location") == "" || w.Header().Get("Preference-Applied") != "return=minimal" {
+			t.Errorf("%s: expected Location and Preference-Applied headers, got %v", prefer, w.Header())
+		}
+	}
+	var count int64
+	handler.db.Model(&Todo{}).Count(&count)
+	if count != 2 {
+		t.Errorf("expected 2 todos saved, got %d", count)
+	}
+}
+
+// TestNewTask_PreferRepresentation: return=representation, like no preference, answers with the todo
+func TestNewTask_PreferRepresentation(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+
+	for prefer, applied := range map[string]string{"return=representation": "return=representation", "": ""} {
+		w := doPrefer(router, prefer)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("%q: expected status %d, got %d", prefer, http.StatusCreated, w.Code)
+		}
+		if !bytes.Contains(w.Body.Bytes(), []byte(`"text":"Preferred"`)) {
+			t.Errorf("%q: expected the todo in the body, got %s", prefer, w.Body.String())
+		}
+		if got := w.Header().Get("Location"); got == "" {
+			t.Errorf("%q: expected a Location header", prefer)
+		}
+		if got := w.Header().Get("Preference-Applied"); got != applied {
+			t.Errorf("%q: expected Preference-Applied %q, got %q", prefer, applied, got)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package todo
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/audit"
+	"gorm.io/gorm"
+)
+
+// purgeBatchSize is how many todos Purge reads and deletes at a time.
+const purgeBatchSize = 500
+
+// Purge permanently deletes every user's trashed todos. A non-zero before
+// limits it to todos deleted before that time. Each todo gets a purge
+// audit entry, attributed to its owner, in the same transaction. It returns
+// how many todos were removed.
+func Purge(db *gorm.DB, before time.Time) (int64, error) {
+	var purged int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		purged = 0
+		q := tx.Unscoped().Where("deleted_at IS NOT NULL")
+		if !before.IsZero() {
+			q = q.Where("deleted_at < ?", before.UTC())
+		}
+		var batch []Todo
+		return q.FindInBatches(&batch, purgeBatchSize, func(*gorm.DB, int) error {
+			ids := make([]uint, len(batch))
+			for i, todo := range batch {
+				ids[i] = todo.ID
+				if err := audit.Record(tx, audit.ActionPurge, todo.ID, todo.UserID, todo, nil); err != nil {
+					return err
+				}
+			}
+			res := tx.Unscoped().Delete(&Todo{}, ids)
+			purged += res.RowsAffected
+			return res.Error
+		}).Error
+	})
+	return purged, err
+}
+
+// PurgeTrash empties the trash of every user, or with ?before= (RFC 3339 or epoch ms)
+// only the todos deleted before then. It responds with the number of todos
+// removed. The route must be restricted to admins.
+func (t *TodoHandler) PurgeTrash(c *gin.Context) {
+	var before time.Time
+	if v := c.Query("before"); v != "" {
+		var err error
+		if before, err = parseTime(v); err != nil {
+			apperr.Write(c, apperr.ErrBadRequest.With("before must be an RFC 3339 time or epoch milliseconds"))
+			return
+		}
+	}
+
+	var purged int64
+	err := t.retry(c, func() error {
+		var err error
+		purged, err = Purge(t.conn(c), before)
+		return err
+	})
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	t.respond(c, http.StatusOK, gin.H{"purged": purged})
+}
@@ -0,0 +1,117 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/audit"
+)
+
+func setupPurgeHandler(t *testing.T) (*TodoHandler, *gin.Engine) {
+	t.Helper()
+	handler, router := setupTestHandler(t)
+	router.DELETE("/todos/trash", handler.PurgeTrash)
+	return handler, router
+}
+
+func doPurge(router *gin.Engine, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodDelete, "/todos/trash"+query, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// setupTrash creates one active todo and two trashed ones for different
+// users, deleted a day and an hour ago.
+func setupTrash(t *testing.T, handler *TodoHandler) (old, recent Todo) {
+	t.Helper()
+	old = Todo{UserID: testUserID, Title: "deleted yesterday"}
+	recent = Todo{UserID: testUserID + 1, Title: "deleted an hour ago"}
+	handler.db.Create(&Todo{UserID: testUserID, Title: "active"})
+	handler.db.Create(&old)
+	handler.db.Create(&recent)
+	handler.db.Delete(&old)
+	handler.db.Delete(&recent)
+	handler.db.Unscoped().Model(&old).Update("deleted_at", time.Now().Add(-24*time.Hour))
+	handler.db.Unscoped().Model(&recent).Update("deleted_at", time.Now().Add(-time.Hour))
+	return old, recent
+}
+
+func purgedCount(t *testing.T, w *httptest.ResponseRecorder) int64 {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp struct {
+		Purged int64 `json:"purged"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return resp.Purged
+}
+
+// TestPurgeTrash_All: every user's trash is emptied and live todos are kept
+func TestPurgeTrash_All(t *testing.T) {
+	handler, router := setupPurgeHandler(t)
+	setupTrash(t, handler)
+
+	if n := purgedCount(t, doPurge(router, "")); n != 2 {
+		t.Errorf("expected 2 todos purged, got %d", n)
+	}
+	var count int64
+	handler.db.Unscoped().Model(&Todo{}).Count(&count)
+	if count != 1 {
+		t.Errorf("expected only the active todo left, found %d rows", count)
+	}
+}
+
+// TestPurgeTrash_Before: ?before= keeps todos deleted after the cutoff
+func TestPurgeTrash_Before(t *testing.T) {
+	handler, router := setupPurgeHandler(t)
+	_, recent := setupTrash(t, handler)
+	cutoff := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+
+	if n := purgedCount(t, doPurge(router, "?before="+cutoff)); n != 1 {
+		t.Errorf("expected 1 todo purged, got %d", n)
+	}
+	if err := handler.db.Unscoped().First(&Todo{}, recent.ID).Error; err != nil {
+		t.Errorf("expected the recently deleted todo to remain: %v", err)
+	}
+}
+
+func TestPurgeTrash_InvalidBefore(t *testing.T) {
+	handler, router := setupPurgeHandler(t)
+	setupTrash(t, handler)
+
+	if w := doPurge(router, "?before=yesterday"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestPurgeTrash_RecordsAudit: each purged todo gets a purge audit entry attributed to its owner
+func TestPurgeTrash_RecordsAudit(t *testing.T) {
+	handler, router := setupPurgeHandler(t)
+	old, _ := setupTrash(t, handler)
+	cutoff := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+
+	purgedCount(t, doPurge(router, "?before="+cutoff))
+
+	var entries []audit.Log
+	handler.db.Find(&entries)
+	if len(entries) != 1 {
+		t.Fatalf("expected one audit entry, got %+v", entries)
+	}
+	e := entries[0]
+	if e.Action != audit.ActionPurge || e.TodoID != old.ID || e.UserID != old.UserID {
+		t.Errorf("expected a purge of todo %d by user %d, got %+v", old.ID, old.UserID, e)
+	}
+	var changes map[string]audit.Change
+	if err := json.Unmarshal(e.Changes, &changes); err != nil || changes["text"].Old != old.Title {
+		t.Errorf("expected the purged todo's fields in the entry, got %s", e.Changes)
+	}
+}
@@ -0,0 +1,57 @@
+package todo
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+)
+
+// Bounds of ?days= on GET /todos/recent-completed.
+const (
+	defaultRecentDays = 7
+	maxRecentDays     = 365
+)
+
+// ListRecentCompleted returns the caller's todos completed within the last
+// ?days= days (default 7), most recently completed first.
+func (t *TodoHandler) ListRecentCompleted(c *gin.Context) {
+	q, _, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	days := defaultRecentDays
+	if v, ok := c.GetQuery("days"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxRecentDays {
+			apperr.Write(c, apperr.ErrBadRequest.With(fmt.Sprintf("days must be an integer from 1 to %d, got %q", maxRecentDays, v)))
+			return
+		}
+		days = n
+	}
+	p, ok := t.page(c)
+	if !ok {
+		return
+	}
+	if !selectFields(c) || !selectZone(c) || !selectTruncate(c) {
+		return
+	}
+
+	// Completion times are stored in UTC, and SQLite compares them as text.
+	since := time.Now().AddDate(0, 0, -days).UTC()
+
+	todos := []Todo{}
+	err := t.retry(c, func() error {
+		return q.Where("completed = ? AND completed_at >= ?", true, since).
+			Order("completed_at DESC").Order("id DESC").
+			Limit(p.Limit).Offset(p.Offset()).Find(&todos).Error
+	})
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	t.respond(c, http.StatusOK, todos)
+}
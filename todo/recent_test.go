@@ -0,0 +1,56 @@
+package todo
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestListRecentCompleted: only the caller's todos completed within the window, most recent first
+func TestListRecentCompleted(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos/recent-completed", handler.ListRecentCompleted)
+
+	ago := func(d time.Duration) *time.Time {
+		at := time.Now().Add(-d).UTC()
+		return &at
+	}
+	day := 24 * time.Hour
+	handler.db.Create(&Todo{UserID: testUserID, Title: "last week", Completed: true, CompletedAt: ago(6 * day)})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "an hour ago", Completed: true, CompletedAt: ago(time.Hour)})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "last month", Completed: true, CompletedAt: ago(30 * day)})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "yesterday", Completed: true, CompletedAt: ago(day)})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "open"})
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "someone else's", Completed: true, CompletedAt: ago(time.Hour)})
+
+	for query, want := range map[string][]string{
+		"":         {"an hour ago", "yesterday", "last week"},
+		"?days=2":  {"an hour ago", "yesterday"},
+		"?days=31": {"an hour ago", "yesterday", "last week", "last month"},
+	} {
+		w := doList(t, router, "/recent-completed"+query)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d", query, http.StatusOK, w.Code)
+		}
+		var titles []string
+		for _, todo := range decodeTodos(t, w) {
+			titles = append(titles, todo.Title)
+		}
+		if !slices.Equal(titles, want) {
+			t.Errorf("%s: expected %v, got %v", query, want, titles)
+		}
+	}
+}
+
+// TestListRecentCompleted_InvalidDays: days must be a positive integer up to a year
+func TestListRecentCompleted_InvalidDays(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos/recent-completed", handler.ListRecentCompleted)
+
+	for _, days := range []string{"0", "-1", "366", "week"} {
+		if w := doList(t, router, "/recent-completed?days="+days); w.Code != http.StatusBadRequest {
+			t.Errorf("days=%s: expected status %d, got %d", days, http.StatusBadRequest, w.Code)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package todo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/pradist/todoapi/audit"
+	"github.com/pradist/todoapi/dbretry"
+	"gorm.io/gorm"
+)
+
+// TodoRepository stores todos for TodoService. Every method is scoped to
+// one owner; a todo of another user, or one that doesn't exist, is
+// errTodoNotFound.
+type TodoRepository interface {
+	// Create assigns todo an id and saves it. quota, when positive, is the
+	// most active todos the owner may hold; reaching it is
+	// errQuotaExceeded, checked atomically with the insert.
+	Create(ctx context.Context, todo *Todo, quota int) error
+	// FindByID returns todo id of userID. Deleted todos are only found
+	// with includeDeleted.
+	FindByID(ctx context.Context, userID, id uint, includeDeleted bool) (Todo, error)
+	// List returns the todos of userID that q selects. q.Sort is one of
+	// settings.Sorts and q.Page has been resolved against the page limits.
+	List(ctx context.Context, userID uint, q ListQuery) ([]Todo, error)
+	// Update loads todo id of userID, lets apply change it and saves the
+	// result, atomically. An error from apply is returned and nothing is
+	// saved.
+	Update(ctx context.Context, userID, id uint, apply func(todo *Todo) error) (Todo, error)
+	// Put stores the todo build returns under id, atomically. build is
+	// given the todo already stored under id, of any owner and deleted or
+	// not, or nil if there is none; an error from it is returned and
+	// nothing is saved. A todo new to id is created within quota, as by
+	// Create; otherwise it replaces the stored one.
+	Put(ctx context.Context, id uint, quota int, build func(existing *Todo) (Todo, error)) (Todo, error)
+	// Delete moves todo id of userID to the trash, storing reason with it.
+	// A todo already in the trash is left as it is.
+	Delete(ctx context.Context, userID, id uint, reason string) error
+}
+
+// GormTodoRepository is the TodoRepository of the API, backed by the
+// database. Its writes are recorded in the audit log and transient
+// failures are retried under Config.Retry.
+type GormTodoRepository struct {
+	db  *gorm.DB
+	cfg Config
+}
+
+func NewGormTodoRepository(db *gorm.DB, cfg Config) *GormTodoRepository {
+	return &GormTodoRepository{db: db, cfg: cfg}
+}
+
+// conn returns the repository's database bound to ctx, so queries are
+// cancelled with it.
+func (r *GormTodoRepository) conn(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx)
+}
+
+// retry runs fn again while it fails for a transient reason. fn must be
+// safe to repeat.
+func (r *GormTodoRepository) retry(ctx context.Context, fn func() error) error {
+	return dbretry.Do(ctx, r.cfg.Retry, fn)
+}
+
+// transaction runs fn in a transaction as runTransaction does.
+func (r *GormTodoRepository) transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return runTransaction(ctx, r.db, r.cfg, fn)
+}
+
+// runTransaction runs fn in a transaction of db bound to ctx. The whole
+// transaction is retried under cfg.Retry if it fails for a transient
+// reason, so fn must not depend on state left behind by an earlier, rolled
+// back attempt. With cfg.Audit set, the entries fn records are queued once
+// it commits.
+func runTransaction(ctx context.Context, db *gorm.DB, cfg Config, fn func(tx *gorm.DB) error) error {
+	if cfg.Audit == nil {
+		return dbretry.Do(ctx, cfg.Retry, func() error {
+			return db.WithContext(ctx).Transaction(fn)
+		})
+	}
+	var buf *audit.Buffer
+	err := dbretry.Do(ctx, cfg.Retry, func() error {
+		buf = new(audit.Buffer)
+		return db.WithContext(audit.WithBuffer(ctx, buf)).Transaction(fn)
+	})
+	if err == nil {
+		cfg.Audit.Add(buf)
+	}
+	return err
+}
+
+// checkQuota returns errQuotaExceeded if userID already holds quota active
+// todos. Deleted todos don't count, and a quota of zero is unlimited.
+func checkQuota(tx *gorm.DB, userID uint, quota int) error {
+	if quota <= 0 {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&Todo{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count >= int64(quota) {
+		return errQuotaExceeded
+	}
+	return nil
+}
+
+// notFound reports a missing row as errTodoNotFound.
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errTodoNotFound
+	}
+	return err
+}
+
+func (r *GormTodoRepository) Create(ctx context.Context, todo *Todo, quota int) error {
+	return r.transaction(ctx, func(tx *gorm.DB) error {
+		todo.Model = gorm.Model{}
+		if err := checkQuota(tx, todo.UserID, quota); err != nil {
+			return err
+		}
+		if err := tx.Create(todo).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, audit.ActionCreate, todo.ID, todo.UserID, nil, *todo)
+	})
+}
+
+func (r *GormTodoRepository) FindByID(ctx context.Context, userID, id uint, includeDeleted bool) (Todo, error) {
+	q := r.conn(ctx).Where("user_id = ?", userID)
+	if includeDeleted {
+		q = q.Unscoped()
+	}
+	q = q.Session(&gorm.Session{})
+	var todo Todo
+	err := r.retry(ctx, func() error {
+		return q.First(&todo, id).Error
+	})
+	return todo, notFound(err)
+}
+
+func (r *GormTodoRepository) List(ctx context.Context, userID uint, q ListQuery) ([]Todo, error) {
+	base := r.conn(ctx).Where("user_id = ?", userID)
+	if q.IncludeDeleted {
+		base = base.Unscoped()
+	}
+	if q.ModifiedSince != nil {
+		// Soft deletes leave updated_at alone, so deleted_at is checked
+		// too. SQLite compares timestamps as text, so since goes in UTC
+		// like the stored times.
+		since := q.ModifiedSince.UTC()
+		base = base.Unscoped().Where("updated_at > ? OR deleted_at > ?", since, since)
+	}
+	base = q.Filter.apply(base).Session(&gorm.Session{})
+
+	todos := []Todo{}
+	err := r.retry(ctx, func() error {
+		return base.Order(sortOrders[q.Sort]).Order("id").Limit(q.Page.Limit).Offset(q.Page.Offset()).Find(&todos).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if q.ModifiedSince != nil {
+		for i := range todos {
+			todos[i].Deleted = todos[i].DeletedAt.Valid
+		}
+	}
+	return todos, nil
+}
+
+func (r *GormTodoRepository) Update(ctx context.Context, userID, id uint, apply func(todo *Todo) error) (Todo, error) {
+	var todo Todo
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		todo = Todo{}
+		if err := tx.Where("user_id = ?", userID).First(&todo, id).Error; err != nil {
+			return err
+		}
+		before := todo
+		if err := apply(&todo); err != nil {
+			return err
+		}
+		if err := tx.Save(&todo).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, audit.ActionUpdate, id, userID, before, todo)
+	})
+	if err != nil {
+		return Todo{}, notFound(err)
+	}
+	return todo, nil
+}
+
+func (r *GormTodoRepository) Put(ctx context.Context, id uint, quota int, build func(existing *Todo) (Todo, error)) (Todo, error) {
+	var todo Todo
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		var existing Todo
+		err := tx.Unscoped().First(&existing, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if todo, err = build(nil); err != nil {
+				return err
+			}
+			if err := checkQuota(tx, todo.UserID, quota); err != nil {
+				return err
+			}
+			todo.Model = gorm.Model{ID: id}
+			if err := tx.Create(&todo).Error; err != nil {
+				return err
+			}
+			return audit.Record(tx, audit.ActionCreate, id, todo.UserID, nil, todo)
+		}
+		if err != nil {
+			return err
+		}
+		if todo, err = build(&existing); err != nil {
+			return err
+		}
+		todo.Model = existing.Model
+		if err := tx.Save(&todo).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, audit.ActionUpdate, id, todo.UserID, existing, todo)
+	})
+	if err != nil {
+		return Todo{}, err
+	}
+	return todo, nil
+}
+
+func (r *GormTodoRepository) Delete(ctx context.Context, userID, id uint, reason string) error {
+	err := r.transaction(ctx, func(tx *gorm.DB) error {
+		var todo Todo
+		if err := tx.Unscoped().Where("user_id = ?", userID).First(&todo, id).Error; err != nil {
+			return err
+		}
+		if todo.DeletedAt.Valid {
+			return nil
+		}
+		before := todo
+		todo.DeleteReason = reason
+		if err := tx.Model(&todo).Update("delete_reason", todo.DeleteReason).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&todo).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, audit.ActionDelete, id, userID, before, nil)
+	})
+	return notFound(err)
+}
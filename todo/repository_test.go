@@ -0,0 +1,127 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pradist/todoapi/audit"
+	"github.com/pradist/todoapi/pagination"
+)
+
+// TestGormRepository_Audit: creates, updates and deletes are recorded in the audit log
+func TestGormRepository_Audit(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewGormTodoRepository(db, Config{})
+	ctx := context.Background()
+
+	todo := Todo{UserID: testUserID, Title: "Draft", Priority: PriorityLow}
+	if err := repo.Create(ctx, &todo, 0); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := repo.Update(ctx, testUserID, todo.ID, func(todo *Todo) error {
+		todo.Title = "Final"
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := repo.Delete(ctx, testUserID, todo.ID, "done with it"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	var logs []audit.Log
+	db.Where("todo_id = ?", todo.ID).Order("id").Find(&logs)
+	want := []string{audit.ActionCreate, audit.ActionUpdate, audit.ActionDelete}
+	if len(logs) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), logs)
+	}
+	for i, action := range want {
+		if logs[i].Action != action {
+			t.Errorf("entry %d: expected %s, got %s", i, action, logs[i].Action)
+		}
+	}
+}
+
+// TestGormRepository_UpdateRollsBack: an error from apply leaves the todo unchanged
+func TestGormRepository_UpdateRollsBack(t *testing.T) {
+	repo := NewGormTodoRepository(setupTestDB(t), Config{})
+	ctx := context.Background()
+
+	todo := Todo{UserID: testUserID, Title: "Draft", Priority: PriorityLow}
+	if err := repo.Create(ctx, &todo, 0); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	errStop := errors.New("stop")
+	_, err := repo.Update(ctx, testUserID, todo.ID, func(todo *Todo) error {
+		todo.Title = "Changed"
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("expected apply's error, got %v", err)
+	}
+	got, err := repo.FindByID(ctx, testUserID, todo.ID, false)
+	if err != nil || got.Title != "Draft" {
+		t.Errorf("expected the todo unchanged, got %+v, %v", got, err)
+	}
+}
+
+// TestRepositories_ListAgree: the in-memory repository lists the same todos as the database for every sort and filter
+func TestRepositories_ListAgree(t *testing.T) {
+	ctx := context.Background()
+	repos := map[string]TodoRepository{
+		"gorm":   NewGormTodoRepository(setupTestDB(t), Config{}),
+		"memory": NewMemoryTodoRepository(),
+	}
+	past := time.Now().Add(-48 * time.Hour).UTC()
+	future := time.Now().Add(48 * time.Hour).UTC()
+	for _, repo := range repos {
+		for _, todo := range []Todo{
+			{Title: "A", Priority: PriorityLow, DueDate: &future},
+			{Title: "B", Priority: PriorityHigh, DueDate: &past},
+			{Title: "C", Priority: PriorityLow, Completed: true, DueDate: &past},
+			{Title: "D", Priority: PriorityMedium},
+			{Title: "E", Priority: PriorityLow, DueDate: &future},
+		} {
+			todo.UserID = testUserID
+			if err := repo.Create(ctx, &todo, 0); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+		}
+		if err := repo.Delete(ctx, testUserID, 4, ""); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+	}
+
+	yes, no, low := true, false, PriorityLow
+	page := pagination.Page{Number: 1, Limit: 10}
+	queries := map[string]ListQuery{
+		"-id":            {Sort: "-id", Page: page},
+		"due_date":       {Sort: "due_date", Page: page},
+		"-due_date":      {Sort: "-due_date", Page: page},
+		"low":            {Sort: "id", Page: page, Filter: Filter{Priority: &low}},
+		"overdue":        {Sort: "id", Page: page, Filter: Filter{Overdue: &yes}},
+		"low or overdue": {Sort: "id", Page: page, Filter: Filter{Priority: &low, Overdue: &yes, Match: MatchAny}},
+		"no due date":    {Sort: "id", Page: page, Filter: Filter{HasDueDate: &no}, IncludeDeleted: true},
+		"second page":    {Sort: "id", Page: pagination.Page{Number: 2, Limit: 3}},
+	}
+	for name, q := range queries {
+		titles := map[string][]string{}
+		for kind, repo := range repos {
+			todos, err := repo.List(ctx, testUserID, q)
+			if err != nil {
+				t.Fatalf("%s %s: %v", kind, name, err)
+			}
+			for _, todo := range todos {
+				titles[kind] = append(titles[kind], todo.Title)
+			}
+		}
+		if len(titles["gorm"]) == 0 {
+			t.Errorf("%s: expected some todos", name)
+		}
+		if a, b := titles["gorm"], titles["memory"]; !slices.Equal(a, b) {
+			t.Errorf("%s: database listed %v, memory listed %v", name, a, b)
+		}
+	}
+}
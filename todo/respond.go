@@ -0,0 +1,195 @@
+package todo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/response"
+)
+
+const (
+	jsonMediaType    = "application/json; charset=utf-8"
+	jsonAPIMediaType = "application/vnd.api+json"
+)
+
+type jsonAPIResource struct {
+	Type       string         `json:"type"`
+	ID         string         `json:"id"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// respond writes data as plain JSON, as a JSON:API document when the client
+// sends Accept: application/vnd.api+json, as XML when it sends Accept:
+// application/xml and data is a Todo or []Todo, or as MessagePack when it
+// sends Accept: application/msgpack. Todos are first adjusted for display:
+// times are shown in the zone chosen by selectZone and titles shortened as
+// chosen by selectTruncate. JSON is trimmed to the fields chosen by
+// selectFields, wrapped in an envelope if ResponseEnvelope is set, and has
+// ids as strings if IDsAsStrings is set, both as the response package does
+// for the other handlers. It then has timestamps in the configured
+// TimeFormat and keys rewritten to the configured JSONCase. MessagePack
+// carries the same body as plain JSON and falls back to it if the body can't
+// be encoded. Handlers pass their response data and never build the envelope
+// or rename fields themselves.
+func (t *TodoHandler) respond(c *gin.Context, status int, data any) {
+	data = forDisplay(data, func(todo *Todo) {
+		if loc := selectedZone(c); loc != nil {
+			localize(todo, loc)
+		}
+		if n := selectedTruncate(c); n > 0 {
+			truncate(todo, n)
+		}
+	})
+	if doc, ok := toXML(data); ok && wantsXML(c) && !wantsJSONAPI(c) {
+		out, err := xml.Marshal(doc)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.Data(status, xmlMediaType, append([]byte(xml.Header), out...))
+		return
+	}
+
+	keep := selectedFields(c)
+	body, contentType := data, jsonMediaType
+	if wantsJSONAPI(c) {
+		doc, err := toJSONAPI(data, keep)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		body, contentType = doc, jsonAPIMediaType
+	} else if keep != nil {
+		generic, err := response.Generic(body)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		body = project(generic, keep)
+	}
+	if t.cfg.ResponseEnvelope && contentType == jsonMediaType {
+		body = response.Envelope(c, body, data)
+	}
+
+	if t.cfg.IDsAsStrings {
+		var err error
+		if body, err = response.StringifyIDs(body); err != nil {
+			apperr.Write(c, err)
+			return
+		}
+	}
+
+	if t.cfg.TimeFormat == TimeUnixMillis {
+		generic, err := response.Generic(body)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		body = unixMillis(generic)
+	}
+
+	if rename := t.keyRenamer(); rename != nil {
+		generic, err := response.Generic(body)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		body = renameKeys(generic, rename)
+	}
+
+	if contentType == jsonMediaType && wantsMsgpack(c) {
+		if out, err := toMsgpack(body); err == nil {
+			c.Data(status, msgpackMediaType, out)
+			return
+		}
+	}
+
+	out, err := json.Marshal(body)
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	c.Data(status, contentType, out)
+}
+
+func (t *TodoHandler) keyRenamer() func(string) string {
+	switch t.cfg.JSONCase {
+	case SnakeCase:
+		return toSnake
+	case CamelCase:
+		return toCamel
+	default:
+		return nil
+	}
+}
+
+func wantsJSONAPI(c *gin.Context) bool {
+	return accepts(c, jsonAPIMediaType)
+}
+
+func wantsXML(c *gin.Context) bool {
+	return accepts(c, "application/xml", "text/xml")
+}
+
+// accepts reports whether the Accept header lists one of mediaTypes.
+func accepts(c *gin.Context, mediaTypes ...string) bool {
+	for _, part := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && slices.Contains(mediaTypes, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// toJSONAPI wraps todos in a JSON:API document. A non-nil keep limits the
+// attributes; the resource id is always included.
+func toJSONAPI(data any, keep map[string]bool) (gin.H, error) {
+	switch v := data.(type) {
+	case Todo:
+		res, err := newJSONAPIResource(v, keep)
+		if err != nil {
+			return nil, err
+		}
+		return gin.H{"data": res}, nil
+	case []Todo:
+		resources := make([]jsonAPIResource, 0, len(v))
+		for _, t := range v {
+			res, err := newJSONAPIResource(t, keep)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, res)
+		}
+		return gin.H{"data": resources}, nil
+	default:
+		return gin.H{"data": data}, nil
+	}
+}
+
+func newJSONAPIResource(t Todo, keep map[string]bool) (jsonAPIResource, error) {
+	raw, err := json.Marshal(t)
+	if err != nil {
+		return jsonAPIResource{}, err
+	}
+	var attrs map[string]any
+	if err := json.Unmarshal(raw, &attrs); err != nil {
+		return jsonAPIResource{}, err
+	}
+	delete(attrs, "ID")
+	if keep != nil {
+		project(attrs, keep)
+	}
+
+	return jsonAPIResource{
+		Type:       "todos",
+		ID:         strconv.FormatUint(uint64(t.ID), 10),
+		Attributes: attrs,
+	}, nil
+}
@@ -0,0 +1,130 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func doCreateWithAccept(t *testing.T, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+
+	jsonData, _ := json.Marshal(map[string]any{"text": "Read the spec"})
+	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestRespond_JSONAPI: Accept: application/vnd.api+json wraps the todo in a JSON:API document
+func TestRespond_JSONAPI(t *testing.T) {
+	w := doCreateWithAccept(t, "application/vnd.api+json")
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != jsonAPIMediaType {
+		t.Errorf("expected Content-Type %q, got %q", jsonAPIMediaType, ct)
+	}
+
+	var doc struct {
+		Data struct {
+			Type       string         `json:"type"`
+			ID         string         `json:"id"`
+			Attributes map[string]any `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if doc.Data.Type != "todos" {
+		t.Errorf("expected type 'todos', got %q", doc.Data.Type)
+	}
+	if doc.Data.ID != "1" {
+		t.Errorf("expected id '1', got %q", doc.Data.ID)
+	}
+	if doc.Data.Attributes["text"] != "Read the spec" {
+		t.Errorf("expected text attribute, got %v", doc.Data.Attributes["text"])
+	}
+	if _, exists := doc.Data.Attributes["ID"]; exists {
+		t.Error("expected ID to be lifted out of attributes")
+	}
+}
+
+// TestRespond_PlainJSONByDefault: ordinary Accept headers keep the plain JSON body
+func TestRespond_PlainJSONByDefault(t *testing.T) {
+	for _, accept := range []string{"", "application/json", "*/*"} {
+		t.Run(accept, func(t *testing.T) {
+			w := doCreateWithAccept(t, accept)
+
+			var response map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if _, exists := response["ID"]; !exists {
+				t.Error("expected response to contain ID field")
+			}
+			if _, exists := response["data"]; exists {
+				t.Error("expected no JSON:API envelope")
+			}
+		})
+	}
+}
+
+// TestRespond_XML: Accept: application/xml returns the created todo as XML
+func TestRespond_XML(t *testing.T) {
+	w := doCreateWithAccept(t, "application/xml")
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != xmlMediaType {
+		t.Errorf("expected Content-Type %q, got %q", xmlMediaType, ct)
+	}
+	var doc todoXML
+	if err := xml.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("failed to unmarshal XML: %v\n%s", err, w.Body.String())
+	}
+	if doc.ID != 1 || doc.Title != "Read the spec" || doc.Priority != PriorityMedium {
+		t.Errorf("unexpected todo: %+v", doc)
+	}
+	if doc.DeletedAt != nil {
+		t.Errorf("expected no deleted_at on a live todo, got %v", doc.DeletedAt)
+	}
+}
+
+// TestRespond_XMLList: lists are wrapped in a <todos> element
+func TestRespond_XMLList(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "a"})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "b"})
+
+	req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+	req.Header.Set("Accept", "text/xml")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if !strings.HasPrefix(w.Body.String(), xml.Header+"<todos><todo id=\"1\">") {
+		t.Errorf("expected a <todos> document, got %s", w.Body.String())
+	}
+	var doc todosXML
+	if err := xml.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("failed to unmarshal XML: %v", err)
+	}
+	if len(doc.Todos) != 2 || doc.Todos[1].Title != "b" {
+		t.Errorf("expected todos a and b, got %+v", doc.Todos)
+	}
+}
@@ -0,0 +1,229 @@
+package todo
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/pagination"
+	"github.com/pradist/todoapi/settings"
+)
+
+// TodoService holds the rules for reading and changing todos, apart from
+// any transport: it takes plain values and a context and returns todos and
+// apperr errors. The REST and GraphQL handlers both build on it. Storage
+// is left to its TodoRepository.
+//
+// It covers creating, reading, listing, replacing, updating and deleting
+// one todo, its status and its timer. Bulk updates, sync, transfer, the
+// trash, history, templates, the collection ETag and the reports still
+// query the database from TodoHandler, so they need the GORM repository.
+type TodoService struct {
+	repo TodoRepository
+	cfg  Config
+}
+
+func NewTodoService(repo TodoRepository, cfg Config) *TodoService {
+	return &TodoService{repo: repo, cfg: cfg}
+}
+
+// ListQuery selects a page of todos for List.
+type ListQuery struct {
+	Filter Filter
+	// Sort is one of settings.Sorts. Empty means "id".
+	Sort string
+	// Page is clamped to Config.PageLimits; zero fields mean the first
+	// page of the default size.
+	Page pagination.Page
+	// IncludeDeleted lists todos in the trash as well.
+	IncludeDeleted bool
+	// ModifiedSince, if set, keeps only the todos updated or deleted
+	// after it, deleted ones included and marked Deleted.
+	ModifiedSince *time.Time
+}
+
+// PutConditions are the preconditions of Put.
+type PutConditions struct {
+	// CreateOnly refuses to replace a todo: the id must be free.
+	CreateOnly bool
+	// Match, if set, refuses to create and replaces only a todo it
+	// accepts. Without it, replacing fails if Config.RequireIfMatch is
+	// set.
+	Match func(current Todo) bool
+}
+
+// TodoChanges are the fields Update sets. Nil fields are left as they are.
+type TodoChanges struct {
+	Title            *string
+	DueDate          *time.Time
+	Priority         *string
+	Completed        *bool
+	EstimatedMinutes *int
+}
+
+// Create saves input as a new todo of userID, within their quota. The
+// title is tidied and the default priority filled in before the todo is
+// checked; an invalid one is apperr.ErrValidation.
+func (s *TodoService) Create(ctx context.Context, userID uint, input Todo) (Todo, error) {
+	todo := input
+	s.clean(&todo)
+	if err := s.validate(&todo); err != nil {
+		return Todo{}, err
+	}
+	if err := s.checkDueDate(todo.DueDate, nil); err != nil {
+		return Todo{}, err
+	}
+	todo.UserID = userID
+	if err := stampCompletion(&todo, nil); err != nil {
+		return Todo{}, err
+	}
+	keepTimer(&todo, nil)
+
+	if err := s.repo.Create(ctx, &todo, s.cfg.MaxTodosPerUser); err != nil {
+		return Todo{}, err
+	}
+	return todo, nil
+}
+
+// validate checks the fields of a tidied todo, as request bodies are
+// checked, and compacts its metadata.
+func (s *TodoService) validate(todo *Todo) error {
+	var err error
+	switch {
+	case !validPriority(todo.Priority):
+		err = errInvalidPriority
+	case todo.EstimatedMinutes < 0:
+		err = errors.New("estimated_minutes must not be negative")
+	default:
+		err = cmp.Or(s.checkTitle(todo.Title), cleanMetadata(&todo.Metadata))
+	}
+	if err != nil {
+		return apperr.ErrValidation.Wrap(err)
+	}
+	return nil
+}
+
+// Get returns todo id of userID. Deleted todos are errTodoNotFound unless
+// includeDeleted is set.
+func (s *TodoService) Get(ctx context.Context, userID, id uint, includeDeleted bool) (Todo, error) {
+	return s.repo.FindByID(ctx, userID, id, includeDeleted)
+}
+
+// orderFor returns the ORDER BY clause of sort, one of settings.Sorts.
+func orderFor(sort string) (string, error) {
+	order, ok := sortOrders[sort]
+	if !ok {
+		return "", apperr.ErrBadRequest.With(fmt.Sprintf("sort must be one of %s, got %q", strings.Join(settings.Sorts, ", "), sort))
+	}
+	return order, nil
+}
+
+// List returns the page of userID's todos that q selects.
+func (s *TodoService) List(ctx context.Context, userID uint, q ListQuery) ([]Todo, error) {
+	if err := q.Filter.validate(); err != nil {
+		return nil, apperr.ErrBadRequest.Wrap(err)
+	}
+	q.Sort = cmp.Or(q.Sort, "id")
+	if _, err := orderFor(q.Sort); err != nil {
+		return nil, err
+	}
+	page, err := s.cfg.PageLimits.Page(q.Page.Number, q.Page.Limit)
+	if err != nil {
+		return nil, apperr.ErrBadRequest.Wrap(err)
+	}
+	q.Page = page
+	return s.repo.List(ctx, userID, q)
+}
+
+// Update applies changes to todo id of userID, leaving the other fields as
+// they are.
+func (s *TodoService) Update(ctx context.Context, userID, id uint, changes TodoChanges) (Todo, error) {
+	return s.repo.Update(ctx, userID, id, func(todo *Todo) error {
+		before := *todo
+		if changes.Title != nil {
+			todo.Title = s.cleanTitle(*changes.Title)
+		}
+		if changes.DueDate != nil {
+			todo.DueDate = changes.DueDate
+		}
+		if changes.Priority != nil {
+			todo.Priority = *changes.Priority
+		}
+		if changes.Completed != nil {
+			todo.Completed = *changes.Completed
+		}
+		if changes.EstimatedMinutes != nil {
+			todo.EstimatedMinutes = *changes.EstimatedMinutes
+		}
+		if err := s.validate(todo); err != nil {
+			return err
+		}
+		if err := s.checkDueDate(todo.DueDate, before.DueDate); err != nil {
+			return err
+		}
+		return stampCompletion(todo, &before)
+	})
+}
+
+// Put creates todo id of userID from input, or replaces it if userID
+// already holds it, within the quota and the conditions cond. The input
+// is tidied and checked as by Create. An id held by another user, or by a
+// todo in the trash, can't be claimed. created reports whether the todo
+// is new.
+func (s *TodoService) Put(ctx context.Context, userID, id uint, input Todo, cond PutConditions) (todo Todo, created bool, err error) {
+	s.clean(&input)
+	if err := s.validate(&input); err != nil {
+		return Todo{}, false, err
+	}
+	input.UserID = userID
+	todo, err = s.repo.Put(ctx, id, s.cfg.MaxTodosPerUser, func(existing *Todo) (Todo, error) {
+		todo := input
+		created = existing == nil
+		if created {
+			if cond.Match != nil {
+				return Todo{}, errStale
+			}
+			if err := s.checkDueDate(todo.DueDate, nil); err != nil {
+				return Todo{}, err
+			}
+			if err := stampCompletion(&todo, nil); err != nil {
+				return Todo{}, err
+			}
+			keepTimer(&todo, nil)
+			return todo, nil
+		}
+		switch {
+		case cond.CreateOnly:
+			return Todo{}, errExists
+		case existing.DeletedAt.Valid || existing.UserID != userID:
+			return Todo{}, errIDTaken
+		case cond.Match == nil && s.cfg.RequireIfMatch:
+			return Todo{}, errMatchRequired
+		case cond.Match != nil && !cond.Match(*existing):
+			return Todo{}, errStale
+		}
+		if err := s.checkDueDate(todo.DueDate, existing.DueDate); err != nil {
+			return Todo{}, err
+		}
+		if err := stampCompletion(&todo, existing); err != nil {
+			return Todo{}, err
+		}
+		keepTimer(&todo, existing)
+		return todo, nil
+	})
+	if err != nil {
+		return Todo{}, false, err
+	}
+	return todo, created, nil
+}
+
+// Delete moves todo id of userID to the trash, storing reason with it. A
+// todo already in the trash is left as it is; an id the user never had is
+// errTodoNotFound.
+func (s *TodoService) Delete(ctx context.Context, userID, id uint, reason string) error {
+	return s.repo.Delete(ctx, userID, id, normalizeWhitespace(reason))
+}
@@ -0,0 +1,312 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/pagination"
+)
+
+// newTestService returns a service over an empty in-memory repository.
+func newTestService(cfg Config) (*TodoService, *MemoryTodoRepository) {
+	repo := NewMemoryTodoRepository()
+	return NewTodoService(repo, cfg), repo
+}
+
+// TestService_Create: titles are tidied, the default priority is filled in and completion is stamped
+func TestService_Create(t *testing.T) {
+	s, _ := newTestService(Config{DefaultPriority: PriorityHigh})
+	ctx := context.Background()
+
+	todo, err := s.Create(ctx, testUserID, Todo{Title: "  Buy   milk ", Completed: true})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if todo.ID == 0 || todo.UserID != testUserID {
+		t.Errorf("expected a saved todo of user %d, got id %d user %d", testUserID, todo.ID, todo.UserID)
+	}
+	if todo.Title != "Buy milk" || todo.Priority != PriorityHigh {
+		t.Errorf("expected title %q and priority %q, got %q and %q", "Buy milk", PriorityHigh, todo.Title, todo.Priority)
+	}
+	if todo.CompletedAt == nil {
+		t.Error("expected completed_at to be set for a completed todo")
+	}
+}
+
+// TestService_CreateInvalid: invalid todos are validation errors and nothing is saved
+func TestService_CreateInvalid(t *testing.T) {
+	s, repo := newTestService(Config{})
+	ctx := context.Background()
+
+	for name, todo := range map[string]Todo{
+		"empty title":       {Title: "   "},
+		"bad priority":      {Title: "A", Priority: "urgent"},
+		"negative estimate": {Title: "A", EstimatedMinutes: -1},
+		"metadata array":    {Title: "A", Metadata: []byte(`[1]`)},
+	} {
+		if _, err := s.Create(ctx, testUserID, todo); !errors.Is(err, apperr.ErrValidation) {
+			t.Errorf("%s: expected a validation error, got %v", name, err)
+		}
+	}
+	if len(repo.todos) != 0 {
+		t.Errorf("expected no todos saved, got %d", len(repo.todos))
+	}
+}
+
+// TestService_Quota: creating past MaxTodosPerUser fails
+func TestService_Quota(t *testing.T) {
+	s, _ := newTestService(Config{MaxTodosPerUser: 1})
+	ctx := context.Background()
+
+	if _, err := s.Create(ctx, testUserID, Todo{Title: "First"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := s.Create(ctx, testUserID, Todo{Title: "Second"}); !errors.Is(err, errQuotaExceeded) {
+		t.Errorf("expected the quota error, got %v", err)
+	}
+}
+
+// TestService_Get: todos are found only for their owner, and deleted ones only on request
+func TestService_Get(t *testing.T) {
+	s, _ := newTestService(Config{})
+	ctx := context.Background()
+
+	todo, err := s.Create(ctx, testUserID, Todo{Title: "Mine"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if got, err := s.Get(ctx, testUserID, todo.ID, false); err != nil || got.Title != "Mine" {
+		t.Errorf("expected the todo, got %+v, %v", got, err)
+	}
+	if _, err := s.Get(ctx, testUserID+1, todo.ID, false); !errors.Is(err, errTodoNotFound) {
+		t.Errorf("expected another user to get not found, got %v", err)
+	}
+
+	if err := s.Delete(ctx, testUserID, todo.ID, ""); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Get(ctx, testUserID, todo.ID, false); !errors.Is(err, errTodoNotFound) {
+		t.Errorf("expected a deleted todo to be not found, got %v", err)
+	}
+	if got, err := s.Get(ctx, testUserID, todo.ID, true); err != nil || !got.DeletedAt.Valid {
+		t.Errorf("expected the deleted todo with includeDeleted, got %+v, %v", got, err)
+	}
+}
+
+// TestService_List: filters, sort orders and pages select the caller's todos
+func TestService_List(t *testing.T) {
+	s, _ := newTestService(Config{})
+	ctx := context.Background()
+
+	for _, todo := range []Todo{
+		{Title: "A", Priority: PriorityLow},
+		{Title: "B", Priority: PriorityHigh},
+		{Title: "C", Priority: PriorityLow},
+	} {
+		if _, err := s.Create(ctx, testUserID, todo); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	if _, err := s.Create(ctx, testUserID+1, Todo{Title: "Not mine", Priority: PriorityLow}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	low := PriorityLow
+	todos, err := s.List(ctx, testUserID, ListQuery{Filter: Filter{Priority: &low}, Sort: "-id"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(todos) != 2 || todos[0].Title != "C" || todos[1].Title != "A" {
+		t.Errorf("expected C, A, got %+v", todos)
+	}
+
+	todos, err = s.List(ctx, testUserID, ListQuery{Page: pagination.Page{Number: 2, Limit: 2}})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(todos) != 1 || todos[0].Title != "C" {
+		t.Errorf("expected only C on page 2, got %+v", todos)
+	}
+
+	if _, err := s.List(ctx, testUserID, ListQuery{Sort: "title"}); !errors.Is(err, apperr.ErrBadRequest) {
+		t.Errorf("expected an unknown sort to be a bad request, got %v", err)
+	}
+}
+
+// TestService_ListModifiedSince: only later changes are listed, deletions marked
+func TestService_ListModifiedSince(t *testing.T) {
+	s, _ := newTestService(Config{})
+	ctx := context.Background()
+
+	old, err := s.Create(ctx, testUserID, Todo{Title: "Old"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	since := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	if _, err := s.Create(ctx, testUserID, Todo{Title: "New"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := s.Delete(ctx, testUserID, old.ID, ""); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	todos, err := s.List(ctx, testUserID, ListQuery{ModifiedSince: &since})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(todos) != 2 || !todos[0].Deleted || todos[1].Deleted {
+		t.Errorf("expected Old deleted and New live, got %+v", todos)
+	}
+}
+
+// TestService_Update: only the given fields change, completion is stamped and invalid changes are rejected
+func TestService_Update(t *testing.T) {
+	s, _ := newTestService(Config{})
+	ctx := context.Background()
+
+	todo, err := s.Create(ctx, testUserID, Todo{Title: "Draft", Priority: PriorityLow, EstimatedMinutes: 30})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	title, done := " Final ", true
+	got, err := s.Update(ctx, testUserID, todo.ID, TodoChanges{Title: &title, Completed: &done})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got.Title != "Final" || !got.Completed || got.CompletedAt == nil {
+		t.Errorf("expected a completed todo titled Final, got %+v", got)
+	}
+	if got.Priority != PriorityLow || got.EstimatedMinutes != 30 {
+		t.Errorf("expected other fields kept, got %+v", got)
+	}
+
+	if stored, _ := s.Get(ctx, testUserID, todo.ID, false); stored.Title != "Final" {
+		t.Errorf("expected the change stored, got %+v", stored)
+	}
+
+	bad := "urgent"
+	if _, err := s.Update(ctx, testUserID, todo.ID, TodoChanges{Priority: &bad}); !errors.Is(err, apperr.ErrValidation) {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+	if stored, _ := s.Get(ctx, testUserID, todo.ID, false); stored.Priority != PriorityLow {
+		t.Errorf("expected a rejected change not to be stored, got %+v", stored)
+	}
+	if _, err := s.Update(ctx, testUserID+1, todo.ID, TodoChanges{Title: &title}); !errors.Is(err, errTodoNotFound) {
+		t.Errorf("expected another user to get not found, got %v", err)
+	}
+}
+
+// TestService_Delete: deleting stores the reason, repeats are no-ops and unknown ids are not found
+func TestService_Delete(t *testing.T) {
+	s, _ := newTestService(Config{})
+	ctx := context.Background()
+
+	todo, err := s.Create(ctx, testUserID, Todo{Title: "Old"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := s.Delete(ctx, testUserID, todo.ID, "  no longer  needed "); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := s.Delete(ctx, testUserID, todo.ID, "again"); err != nil {
+		t.Errorf("expected deleting again to succeed, got %v", err)
+	}
+	got, err := s.Get(ctx, testUserID, todo.ID, true)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.DeleteReason != "no longer needed" {
+		t.Errorf("expected the first reason kept, got %q", got.DeleteReason)
+	}
+	if err := s.Delete(ctx, testUserID, 99, ""); !errors.Is(err, errTodoNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+// TestService_Put: an id is created, then replaced by its owner under the conditions, and can't be claimed by anyone else
+func TestService_Put(t *testing.T) {
+	s, _ := newTestService(Config{})
+	ctx := context.Background()
+
+	todo, created, err := s.Put(ctx, testUserID, 7, Todo{Title: " Draft ", Completed: true}, PutConditions{})
+	if err != nil || !created {
+		t.Fatalf("expected todo 7 created, got %v, %v", created, err)
+	}
+	if todo.ID != 7 || todo.Title != "Draft" || todo.CompletedAt == nil {
+		t.Errorf("expected a tidied, completed todo 7, got %+v", todo)
+	}
+
+	stale := PutConditions{Match: func(Todo) bool { return false }}
+	if _, _, err := s.Put(ctx, testUserID, 7, Todo{Title: "Final"}, stale); !errors.Is(err, errStale) {
+		t.Errorf("expected a rejected match to be stale, got %v", err)
+	}
+	if _, _, err := s.Put(ctx, testUserID, 7, Todo{Title: "Final"}, PutConditions{CreateOnly: true}); !errors.Is(err, errExists) {
+		t.Errorf("expected create-only to find the todo, got %v", err)
+	}
+	if _, _, err := s.Put(ctx, testUserID+1, 7, Todo{Title: "Mine"}, PutConditions{}); !errors.Is(err, errIDTaken) {
+		t.Errorf("expected another user's id to be taken, got %v", err)
+	}
+
+	todo, created, err = s.Put(ctx, testUserID, 7, Todo{Title: "Final"}, PutConditions{})
+	if err != nil || created {
+		t.Fatalf("expected todo 7 replaced, got %v, %v", created, err)
+	}
+	if todo.Title != "Final" || todo.Completed || todo.CompletedAt != nil {
+		t.Errorf("expected the todo replaced as a whole, got %+v", todo)
+	}
+
+	if _, _, err := s.Put(ctx, testUserID, 8, Todo{Title: "New"}, stale); !errors.Is(err, errStale) {
+		t.Errorf("expected a match on a missing id to be stale, got %v", err)
+	}
+	if _, _, err := s.Put(ctx, testUserID, 8, Todo{Title: " "}, PutConditions{}); !errors.Is(err, apperr.ErrValidation) {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+}
+
+// TestService_SetStatus: statuses follow the state machine and keep completion in step
+func TestService_SetStatus(t *testing.T) {
+	s, _ := newTestService(Config{})
+	ctx := context.Background()
+	created, _ := s.Create(ctx, testUserID, Todo{Title: "Ship"})
+
+	if _, err := s.SetStatus(ctx, testUserID, created.ID, StatusVerified); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("expected open to verified to be refused, got %v", err)
+	}
+	todo, err := s.SetStatus(ctx, testUserID, created.ID, StatusDone)
+	if err != nil || !todo.Completed || todo.CompletedAt == nil {
+		t.Fatalf("expected the todo to be done and completed, got %+v, %v", todo, err)
+	}
+	same, err := s.SetStatus(ctx, testUserID, created.ID, StatusDone)
+	if err != nil || !same.UpdatedAt.Equal(todo.UpdatedAt) {
+		t.Errorf("expected the current status to change nothing, got %+v, %v", same, err)
+	}
+	if _, err := s.SetStatus(ctx, testUserID+1, created.ID, StatusOpen); !errors.Is(err, errTodoNotFound) {
+		t.Errorf("expected another user's todo not to be found, got %v", err)
+	}
+}
+
+// TestService_UpdateTimer: the change is saved, and an error from it saves nothing
+func TestService_UpdateTimer(t *testing.T) {
+	s, repo := newTestService(Config{})
+	ctx := context.Background()
+	created, _ := s.Create(ctx, testUserID, Todo{Title: "Focus"})
+
+	todo, err := s.UpdateTimer(ctx, testUserID, created.ID, func(todo *Todo, now time.Time) error {
+		todo.TimerStartedAt = &now
+		return nil
+	})
+	if err != nil || todo.TimerStartedAt == nil {
+		t.Fatalf("expected the timer to start, got %+v, %v", todo, err)
+	}
+	_, err = s.UpdateTimer(ctx, testUserID, created.ID, func(todo *Todo, _ time.Time) error {
+		todo.ActualMinutes = 99
+		return errTimerRunning
+	})
+	if !errors.Is(err, errTimerRunning) || repo.todos[created.ID].ActualMinutes != 0 {
+		t.Errorf("expected the failed change not to be saved, got %v and %d minutes", err, repo.todos[created.ID].ActualMinutes)
+	}
+}
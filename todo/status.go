@@ -0,0 +1,101 @@
+package todo
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"gorm.io/gorm"
+)
+
+// Statuses a todo moves through. A todo is completed once it is done;
+// verifying it confirms the work was checked.
+const (
+	StatusOpen     = "open"
+	StatusDone     = "done"
+	StatusVerified = "verified"
+)
+
+// transitions lists the statuses each status may move to. Verification
+// always follows done, and undoing steps back one stage at a time.
+var transitions = map[string][]string{
+	StatusOpen:     {StatusDone},
+	StatusDone:     {StatusOpen, StatusVerified},
+	StatusVerified: {StatusDone},
+}
+
+type statusRequest struct {
+	Status string `json:"status" binding:"required,oneof=open done verified"`
+}
+
+var (
+	errTransition     = apperr.ErrConflict.With("invalid status transition")
+	errReopenVerified = errTransition.With("a verified todo can't be reopened; move it back to done first").WithDetail("allowed", transitions[StatusVerified])
+)
+
+// AdvanceStatus moves one of the caller's todos to the requested status,
+// keeping Completed and CompletedAt in step. Moves the state machine doesn't
+// allow are 409; asking for the current status changes nothing.
+func (t *TodoHandler) AdvanceStatus(c *gin.Context) {
+	_, userID, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var req statusRequest
+	if !t.bindJSON(c, &req) {
+		return
+	}
+
+	todo, err := t.SetStatus(c.Request.Context(), userID, id, req.Status)
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	t.respond(c, http.StatusOK, todo)
+}
+
+// SetStatus moves todo id of userID to status, keeping Completed and
+// CompletedAt in step. A move the state machine doesn't allow is
+// errTransition, detailing the statuses allowed; asking for the current
+// status changes nothing.
+func (s *TodoService) SetStatus(ctx context.Context, userID, id uint, status string) (Todo, error) {
+	current, err := s.repo.FindByID(ctx, userID, id, false)
+	if err != nil || current.Status == status {
+		return current, err
+	}
+	return s.repo.Update(ctx, userID, id, func(todo *Todo) error {
+		if todo.Status == status {
+			return nil
+		}
+		if !slices.Contains(transitions[todo.Status], status) {
+			return errTransition.WithDetail("allowed", transitions[todo.Status])
+		}
+		wasCompleted := todo.Completed
+		todo.Status = status
+		todo.Completed = status != StatusOpen
+		switch {
+		case !todo.Completed:
+			todo.CompletedAt = nil
+		case !wasCompleted:
+			now := time.Now()
+			todo.CompletedAt = &now
+		}
+		return nil
+	})
+}
+
+// MigrateStatus marks completed todos saved before Status existed as done.
+// It only touches rows whose status disagrees with Completed, so it is safe
+// to run on every start.
+func MigrateStatus(db *gorm.DB) error {
+	return db.Model(&Todo{}).Unscoped().
+		Where("completed = ? AND status = ?", true, StatusOpen).
+		UpdateColumn("status", StatusDone).Error
+}
@@ -0,0 +1,164 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupStatusHandler(t *testing.T) (*TodoHandler, *gin.Engine) {
+	t.Helper()
+	handler, router := setupTestHandler(t)
+	router.POST("/todos/:id/status", handler.AdvanceStatus)
+	router.PUT("/todos/:id", handler.PutTask)
+	router.POST("/todos/bulk-update", handler.BulkUpdate)
+	return handler, router
+}
+
+func doStatus(router *gin.Engine, path, status string) *httptest.ResponseRecorder {
+	jsonData, _ := json.Marshal(map[string]string{"status": status})
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func loadTodo(t *testing.T, handler *TodoHandler, id uint) Todo {
+	t.Helper()
+	var todo Todo
+	if err := handler.db.First(&todo, id).Error; err != nil {
+		t.Fatalf("failed to load todo %d: %v", id, err)
+	}
+	return todo
+}
+
+// TestAdvanceStatus_Lifecycle: open -> done -> verified -> done -> open, keeping Completed in step
+func TestAdvanceStatus_Lifecycle(t *testing.T) {
+	handler, router := setupStatusHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Ship it", Status: StatusOpen})
+
+	steps := []struct {
+		status    string
+		completed bool
+	}{
+		{StatusDone, true},
+		{StatusVerified, true},
+		{StatusDone, true},
+		{StatusOpen, false},
+	}
+	for _, step := range steps {
+		if w := doStatus(router, "/todos/1/status", step.status); w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", step.status, http.StatusOK, w.Code, w.Body.String())
+		}
+		todo := loadTodo(t, handler, 1)
+		if todo.Status != step.status || todo.Completed != step.completed || (todo.CompletedAt != nil) != step.completed {
+			t.Errorf("%s: unexpected todo %+v", step.status, todo)
+		}
+	}
+	if actions := auditActions(t, handler); len(actions) != len(steps) {
+		t.Errorf("expected %d audit entries, got %v", len(steps), actions)
+	}
+}
+
+// TestAdvanceStatus_InvalidTransition: open can't jump straight to verified
+func TestAdvanceStatus_InvalidTransition(t *testing.T) {
+	handler, router := setupStatusHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Not started", Status: StatusOpen})
+
+	w := doStatus(router, "/todos/1/status", StatusVerified)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	if got := loadTodo(t, handler, 1).Status; got != StatusOpen {
+		t.Errorf("expected the todo to stay open, got %q", got)
+	}
+}
+
+func TestAdvanceStatus_Errors(t *testing.T) {
+	handler, router := setupStatusHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "Not mine", Status: StatusOpen})
+
+	if w := doStatus(router, "/todos/1/status", StatusDone); w.Code != http.StatusNotFound {
+		t.Errorf("expected %d for another user's todo, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := doStatus(router, "/todos/1/status", "archived"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected %d for an unknown status, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+// TestStatus_FollowsCompleted: PUT and bulk update derive the status and never demote verified todos
+func TestStatus_FollowsCompleted(t *testing.T) {
+	handler, router := setupStatusHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Checked", Completed: true, Status: StatusVerified})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Fresh", Status: StatusOpen})
+
+	jsonData, _ := json.Marshal(map[string]any{"text": "Checked, renamed", "completed": true})
+	req := httptest.NewRequest(http.MethodPut, "/todos/1", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if got := loadTodo(t, handler, 1).Status; got != StatusVerified {
+		t.Errorf("expected PUT to keep the todo verified, got %q", got)
+	}
+
+	doBulkUpdate(t, router, "?all=true", map[string]any{"set": map[string]any{"completed": true}})
+	if got := loadTodo(t, handler, 1).Status; got != StatusVerified {
+		t.Errorf("expected bulk completion to keep the todo verified, got %q", got)
+	}
+	if got := loadTodo(t, handler, 2).Status; got != StatusDone {
+		t.Errorf("expected bulk completion to mark the todo done, got %q", got)
+	}
+
+	doBulkUpdate(t, router, "?all=true", map[string]any{"set": map[string]any{"completed": false}})
+	if got := loadTodo(t, handler, 1).Status; got != StatusVerified {
+		t.Errorf("expected a refused reopening to keep the todo verified, got %q", got)
+	}
+	doStatus(router, "/todos/1/status", StatusDone)
+	doBulkUpdate(t, router, "?all=true", map[string]any{"set": map[string]any{"completed": false}})
+	if got := loadTodo(t, handler, 1).Status; got != StatusOpen {
+		t.Errorf("expected reopening to mark the todo open, got %q", got)
+	}
+}
+
+// TestStatus_ReopenVerified: PUT and bulk update can't reopen a verified todo; it must go back to done first
+func TestStatus_ReopenVerified(t *testing.T) {
+	handler, router := setupStatusHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Checked", Completed: true, Status: StatusVerified})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Done", Completed: true, Status: StatusDone})
+
+	if w := doPut(t, router, "/todos/1", map[string]any{"text": "Checked", "completed": false}); w.Code != http.StatusConflict {
+		t.Errorf("PUT: expected status %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+	w := doBulkUpdate(t, router, "?all=true", map[string]any{"set": map[string]any{"completed": false}})
+	if w.Code != http.StatusConflict {
+		t.Errorf("bulk update: expected status %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+	if got := loadTodo(t, handler, 1); !got.Completed || got.Status != StatusVerified {
+		t.Errorf("expected the verified todo unchanged, got %+v", got)
+	}
+	if got := loadTodo(t, handler, 2); !got.Completed {
+		t.Errorf("expected a refused bulk update to change nothing, got %+v", got)
+	}
+}
+
+// TestMigrateStatus: completed todos from before statuses existed become done
+func TestMigrateStatus(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Old and done", Completed: true})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Old and open"})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Checked", Completed: true, Status: StatusVerified})
+
+	if err := MigrateStatus(handler.db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for id, want := range map[uint]string{1: StatusDone, 2: StatusOpen, 3: StatusVerified} {
+		if got := loadTodo(t, handler, id).Status; got != want {
+			t.Errorf("todo %d: expected %q, got %q", id, want, got)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package todo
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/audit"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// syncItem is a client's copy of a todo. UpdatedAt is the server's
+// updated_at the client last saw, as it was served; it is omitted for todos
+// created offline.
+type syncItem struct {
+	ID        flexID         `json:"id" binding:"required"`
+	UpdatedAt *flexTime      `json:"updated_at"`
+	Title     string         `json:"text"`
+	DueDate   *flexTime      `json:"due_date"`
+	Completed bool           `json:"completed"`
+	Priority  string         `json:"priority" binding:"omitempty,oneof=low medium high"`
+	Metadata  datatypes.JSON `json:"metadata"`
+	// Tracked time isn't synced; it stays as the server has it.
+	EstimatedMinutes int `json:"estimated_minutes" binding:"min=0"`
+	// CreatedAt backdates a todo created by the sync, for imports. It is
+	// only honoured with ?preserve_created_at=true.
+	CreatedAt *flexTime `json:"created_at"`
+}
+
+// Reasons a synced todo was not applied.
+const (
+	ConflictStale   = "stale"
+	ConflictDeleted = "deleted"
+	ConflictTaken   = "taken"
+)
+
+type syncConflict struct {
+	ID     uint   `json:"id"`
+	Reason string `json:"reason"`
+	// Todo is the server's version, when the caller may see it.
+	Todo *Todo `json:"todo,omitempty"`
+}
+
+type syncResult struct {
+	Todos     []Todo         `json:"todos"`
+	Conflicts []syncConflict `json:"conflicts"`
+}
+
+// Sync applies a batch of todos pushed by an offline client, in one
+// transaction. Each todo is created if its id was never used, and replaced
+// if the client's updated_at matches the server's. Anything else is a
+// conflict: the server's version wins and is returned so the client can
+// reconcile. The response lists the server state of every todo that was
+// applied, then the conflicts. Exceeding the quota or setting a past due
+// date rejects the whole batch. With ?preserve_created_at=true, an admin
+// importing old todos may set their created_at.
+func (t *TodoHandler) Sync(c *gin.Context) {
+	_, userID, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	preserve, ok := adminFlag(c, "preserve_created_at")
+	if !ok {
+		return
+	}
+
+	var items []syncItem
+	if !t.bindJSON(c, &items) {
+		return
+	}
+	if !t.checkBulkSize(c, len(items)) {
+		return
+	}
+	seen := make(map[flexID]bool, len(items))
+	for i, item := range items {
+		if seen[item.ID] {
+			invalid(c, fmt.Errorf("todo %d appears more than once", item.ID))
+			return
+		}
+		seen[item.ID] = true
+		if err := cmp.Or(t.checkTitle(t.cleanTitle(item.Title)), cleanMetadata(&items[i].Metadata)); err != nil {
+			invalid(c, fmt.Errorf("todo %d: %w", item.ID, err))
+			return
+		}
+		if !preserve {
+			items[i].CreatedAt = nil
+		} else if err := checkCreatedAt(item.CreatedAt.ptr()); err != nil {
+			invalid(c, fmt.Errorf("todo %d: %w", item.ID, err))
+			return
+		}
+	}
+
+	var result syncResult
+	err := t.transaction(c, func(tx *gorm.DB) error {
+		result = syncResult{Todos: []Todo{}, Conflicts: []syncConflict{}}
+		for _, item := range items {
+			applied, conflict, err := t.syncOne(tx, userID, item)
+			if err != nil {
+				return err
+			}
+			if conflict != nil {
+				result.Conflicts = append(result.Conflicts, *conflict)
+				continue
+			}
+			result.Todos = append(result.Todos, applied)
+		}
+		return nil
+	})
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	t.respond(c, http.StatusOK, result)
+}
+
+// syncOne creates or replaces a single todo, or reports why it can't.
+func (t *TodoHandler) syncOne(tx *gorm.DB, userID uint, item syncItem) (Todo, *syncConflict, error) {
+	input := Todo{
+		Title:            item.Title,
+		DueDate:          item.DueDate.ptr(),
+		Completed:        item.Completed,
+		Priority:         item.Priority,
+		Metadata:         item.Metadata,
+		UserID:           userID,
+		EstimatedMinutes: item.EstimatedMinutes,
+	}
+	t.clean(&input)
+	id := uint(item.ID)
+
+	var existing Todo
+	err := tx.Unscoped().First(&existing, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := t.checkDueDate(input.DueDate, nil); err != nil {
+			return Todo{}, nil, fmt.Errorf("todo %d: %w", id, err)
+		}
+		if err := checkQuota(tx, userID, t.cfg.MaxTodosPerUser); err != nil {
+			return Todo{}, nil, err
+		}
+		input.ID = id
+		if item.CreatedAt != nil {
+			input.CreatedAt = item.CreatedAt.UTC()
+		}
+		if err := stampCompletion(&input, nil); err != nil {
+			return Todo{}, nil, err
+		}
+		keepTimer(&input, nil)
+		if err := tx.Create(&input).Error; err != nil {
+			return Todo{}, nil, err
+		}
+		return input, nil, audit.Record(tx, audit.ActionCreate, input.ID, userID, nil, input)
+	}
+	if err != nil {
+		return Todo{}, nil, err
+	}
+
+	switch {
+	case existing.UserID != userID:
+		return Todo{}, &syncConflict{ID: id, Reason: ConflictTaken}, nil
+	case existing.DeletedAt.Valid:
+		return Todo{}, &syncConflict{ID: id, Reason: ConflictDeleted, Todo: &existing}, nil
+	case item.UpdatedAt == nil || !t.sameTime(item.UpdatedAt.Time, existing.UpdatedAt):
+		return Todo{}, &syncConflict{ID: id, Reason: ConflictStale, Todo: &existing}, nil
+	}
+
+	if err := t.checkDueDate(input.DueDate, existing.DueDate); err != nil {
+		return Todo{}, nil, fmt.Errorf("todo %d: %w", id, err)
+	}
+	input.Model = existing.Model
+	if err := stampCompletion(&input, &existing); err != nil {
+		return Todo{}, nil, fmt.Errorf("todo %d: %w", id, err)
+	}
+	keepTimer(&input, &existing)
+	if err := tx.Save(&input).Error; err != nil {
+		return Todo{}, nil, err
+	}
+	return input, nil, audit.Record(tx, audit.ActionUpdate, input.ID, userID, existing, input)
+}
+
+// checkCreatedAt rejects an imported creation time that is in the future
+// or before the Unix epoch.
+func checkCreatedAt(createdAt *time.Time) error {
+	if createdAt == nil {
+		return nil
+	}
+	if createdAt.Before(time.Unix(0, 0)) || createdAt.After(time.Now()) {
+		return errors.New("created_at must be between 1970 and now")
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func doSync(t *testing.T, router *gin.Engine, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	jsonData, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/todos/sync", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func decodeSync(t *testing.T, w *httptest.ResponseRecorder) syncResult {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var result syncResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return result
+}
+
+func setupSyncHandler(t *testing.T) (*TodoHandler, *gin.Engine) {
+	t.Helper()
+	handler, router := setupTestHandler(t)
+	router.POST("/todos/sync", handler.Sync)
+	return handler, router
+}
+
+// TestSync_CreatesAndUpdates: new ids are created and current versions are replaced
+func TestSync_CreatesAndUpdates(t *testing.T) {
+	handler, router := setupSyncHandler(t)
+	existing := Todo{UserID: testUserID, Title: "old title", Priority: PriorityLow}
+	handler.db.Create(&existing)
+
+	result := decodeSync(t, doSync(t, router, []map[string]any{
+		{"id": existing.ID, "updated_at": existing.UpdatedAt, "text": "new title", "completed": true},
+		{"id": 42, "text": "made offline"},
+	}))
+
+	if len(result.Conflicts) != 0 || len(result.Todos) != 2 {
+		t.Fatalf("expected 2 applied todos and no conflicts, got %+v", result)
+	}
+	var updated Todo
+	handler.db.First(&updated, existing.ID)
+	if updated.Title != "new title" || !updated.Completed || updated.CompletedAt == nil {
+		t.Errorf("expected the update to be applied, got %+v", updated)
+	}
+	var created Todo
+	if err := handler.db.First(&created, 42).Error; err != nil || created.UserID != testUserID {
+		t.Errorf("expected todo 42 to be created for the caller, got %+v (%v)", created, err)
+	}
+	if got := auditActions(t, handler); len(got) != 2 {
+		t.Errorf("expected 2 audit entries, got %v", got)
+	}
+}
+
+// TestSync_Conflicts: stale, deleted and foreign todos are reported and left as they are
+func TestSync_Conflicts(t *testing.T) {
+	handler, router := setupSyncHandler(t)
+	stale := Todo{UserID: testUserID, Title: "server edit"}
+	deleted := Todo{UserID: testUserID, Title: "deleted"}
+	theirs := Todo{UserID: testUserID + 1, Title: "someone else's"}
+	handler.db.Create(&stale)
+	handler.db.Create(&deleted)
+	handler.db.Create(&theirs)
+	handler.db.Delete(&deleted)
+	seen := stale.UpdatedAt.Add(-time.Minute)
+
+	result := decodeSync(t, doSync(t, router, []map[string]any{
+		{"id": stale.ID, "updated_at": seen, "text": "client edit"},
+		{"id": stale.ID + 100, "text": "fine"},
+		{"id": deleted.ID, "updated_at": deleted.UpdatedAt, "text": "revive"},
+		{"id": theirs.ID, "updated_at": theirs.UpdatedAt, "text": "steal"},
+	}))
+
+	want := map[uint]string{stale.ID: ConflictStale, deleted.ID: ConflictDeleted, theirs.ID: ConflictTaken}
+	if len(result.Conflicts) != len(want) {
+		t.Fatalf("expected %d conflicts, got %+v", len(want), result.Conflicts)
+	}
+	for _, conflict := range result.Conflicts {
+		if want[conflict.ID] != conflict.Reason {
+			t.Errorf("todo %d: expected %q, got %q", conflict.ID, want[conflict.ID], conflict.Reason)
+		}
+	}
+	if c := result.Conflicts[0]; c.Todo == nil || c.Todo.Title != "server edit" {
+		t.Errorf("expected the stale conflict to carry the server version, got %+v", c.Todo)
+	}
+	if c := result.Conflicts[2]; c.Todo != nil {
+		t.Errorf("expected no details of another user's todo, got %+v", c.Todo)
+	}
+	if len(result.Todos) != 1 || result.Todos[0].Title != "fine" {
+		t.Errorf("expected only the new todo to be applied, got %+v", result.Todos)
+	}
+
+	var current Todo
+	handler.db.First(&current, stale.ID)
+	if current.Title != "server edit" {
+		t.Errorf("expected the server version to win, got %q", current.Title)
+	}
+}
+
+// TestSync_QuotaRollsBack: a batch that would exceed the quota is rejected as a whole
+func TestSync_QuotaRollsBack(t *testing.T) {
+	handler, router := setupSyncHandler(t)
+	handler.cfg.MaxTodosPerUser = 1
+
+	w := doSync(t, router, []map[string]any{{"id": 1, "text": "a"}, {"id": 2, "text": "b"}})
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+	var count int64
+	handler.db.Model(&Todo{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected nothing to be saved, found %d todos", count)
+	}
+}
+
+func TestSync_InvalidRequests(t *testing.T) {
+	_, router := setupSyncHandler(t)
+	tooMany := make([]map[string]any, DefaultMaxBulkItems+1)
+	for i := range tooMany {
+		tooMany[i] = map[string]any{"id": i + 1}
+	}
+
+	tests := []struct {
+		name string
+		body any
+		want int
+	}{
+		{"not an array", map[string]any{"id": 1}, http.StatusBadRequest},
+		{"empty", []map[string]any{}, http.StatusUnprocessableEntity},
+		{"too many", tooMany, http.StatusBadRequest},
+		{"missing id", []map[string]any{{"text": "no id"}}, http.StatusUnprocessableEntity},
+		{"bad priority", []map[string]any{{"id": 1, "priority": "urgent"}}, http.StatusUnprocessableEntity},
+		{"duplicate id", []map[string]any{{"id": 1}, {"id": 1}}, http.StatusUnprocessableEntity},
+	}
+	for _, tc := range tests {
+		if w := doSync(t, router, tc.body); w.Code != tc.want {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.want, w.Code)
+		}
+	}
+}
+
+// TestSync_PreserveCreatedAt: admins importing with preserve_created_at keep the supplied creation times
+func TestSync_PreserveCreatedAt(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+	router := gin.New()
+	router.Use(asAdmin(testUserID))
+	router.POST("/todos/sync", handler.Sync)
+	doImport := func(query string, body any) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/todos/sync"+query, bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	created := time.Date(2019, 5, 1, 9, 30, 0, 0, time.FixedZone("", 2*60*60))
+	result := decodeSync(t, doImport("?preserve_created_at=true", []map[string]any{
+		{"id": 1, "text": "from the old system", "created_at": created},
+		{"id": 2, "text": "no creation time"},
+	}))
+	if len(result.Todos) != 2 {
+		t.Fatalf("expected 2 imported todos, got %+v", result)
+	}
+	var imported, fresh Todo
+	handler.db.First(&imported, 1)
+	handler.db.First(&fresh, 2)
+	if !imported.CreatedAt.Equal(created) {
+		t.Errorf("expected created_at %s, got %s", created, imported.CreatedAt)
+	}
+	if time.Since(fresh.CreatedAt) > time.Minute {
+		t.Errorf("expected a todo without created_at to be created now, got %s", fresh.CreatedAt)
+	}
+
+	decodeSync(t, doImport("", []map[string]any{{"id": 3, "text": "flag not set", "created_at": created}}))
+	var ignored Todo
+	handler.db.First(&ignored, 3)
+	if ignored.CreatedAt.Equal(created) {
+		t.Error("expected created_at to be ignored without preserve_created_at")
+	}
+
+	future := time.Now().Add(time.Hour)
+	for _, when := range []time.Time{future, time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)} {
+		w := doImport("?preserve_created_at=true", []map[string]any{{"id": 4, "text": "bad", "created_at": when}})
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("created_at %s: expected status %d, got %d", when, http.StatusUnprocessableEntity, w.Code)
+		}
+	}
+
+	_, userRouter := setupSyncHandler(t)
+	req := httptest.NewRequest(http.MethodPost, "/todos/sync?preserve_created_at=true", bytes.NewBufferString(`[{"id": 5}]`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	userRouter.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for a non-admin, got %d", http.StatusForbidden, w.Code)
+	}
+}
@@ -0,0 +1,184 @@
+package todo
+
+import (
+	"cmp"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Template is a user's blueprint for a todo they create again and again.
+// Its fields are copied into each todo made from it.
+type Template struct {
+	gorm.Model
+	UserID           uint           `json:"user_id" gorm:"index"`
+	Title            string         `json:"text"`
+	Priority         string         `json:"priority" binding:"omitempty,oneof=low medium high"`
+	EstimatedMinutes int            `json:"estimated_minutes" binding:"min=0"`
+	Metadata         datatypes.JSON `json:"metadata"`
+}
+
+var errTemplateNotFound = apperr.ErrNotFound.With("template not found")
+
+// fromTemplateRequest is the optional body of POST /todos/from-template/:id.
+type fromTemplateRequest struct {
+	DueDate *flexTime `json:"due_date"`
+}
+
+// CreateTemplate saves a template for the caller. Titles, priorities and
+// metadata are checked and cleaned as they are for todos.
+func (t *TodoHandler) CreateTemplate(c *gin.Context) {
+	_, userID, ok := t.owned(c)
+	if !ok {
+		return
+	}
+
+	var tmpl Template
+	if !t.bindJSON(c, &tmpl) {
+		return
+	}
+	tmpl.Title = t.cleanTitle(tmpl.Title)
+	if err := cmp.Or(t.checkTitle(tmpl.Title), cleanMetadata(&tmpl.Metadata)); err != nil {
+		invalid(c, err)
+		return
+	}
+	tmpl.Model = gorm.Model{}
+	tmpl.UserID = userID
+
+	err := t.retry(c, func() error {
+		tmpl.ID = 0
+		return t.conn(c).Create(&tmpl).Error
+	})
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	c.Header("Location", "/templates/"+strconv.FormatUint(uint64(tmpl.ID), 10))
+	t.respond(c, http.StatusCreated, tmpl)
+}
+
+// ListTemplates returns the caller's templates by id, paginated.
+func (t *TodoHandler) ListTemplates(c *gin.Context) {
+	q, _, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	p, ok := t.page(c)
+	if !ok {
+		return
+	}
+
+	templates := []Template{}
+	err := t.retry(c, func() error {
+		return q.Order("id").Limit(p.Limit).Offset(p.Offset()).Find(&templates).Error
+	})
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	t.respond(c, http.StatusOK, templates)
+}
+
+// GetTemplate returns one of the caller's templates.
+func (t *TodoHandler) GetTemplate(c *gin.Context) {
+	q, _, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var tmpl Template
+	err := t.retry(c, func() error {
+		return q.First(&tmpl, id).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apperr.Write(c, errTemplateNotFound)
+		return
+	}
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	t.respond(c, http.StatusOK, tmpl)
+}
+
+// DeleteTemplate deletes one of the caller's templates. Todos made from it
+// are kept.
+func (t *TodoHandler) DeleteTemplate(c *gin.Context) {
+	q, _, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var deleted int64
+	err := t.retry(c, func() error {
+		r := q.Delete(&Template{}, id)
+		deleted = r.RowsAffected
+		return r.Error
+	})
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	if deleted == 0 {
+		apperr.Write(c, errTemplateNotFound)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// NewTaskFromTemplate creates a todo from one of the caller's templates,
+// copying its title, priority, estimate and metadata. The body is optional
+// and may set the todo's due_date. The todo is created as by NewTask.
+func (t *TodoHandler) NewTaskFromTemplate(c *gin.Context) {
+	q, userID, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	if !selectZone(c) {
+		return
+	}
+
+	var req fromTemplateRequest
+	if !t.bindOptionalJSON(c, &req) {
+		return
+	}
+
+	var tmpl Template
+	err := t.retry(c, func() error {
+		return q.First(&tmpl, id).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apperr.Write(c, errTemplateNotFound)
+		return
+	}
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+
+	todo := Todo{
+		Title:            tmpl.Title,
+		DueDate:          req.DueDate.ptr(),
+		Priority:         tmpl.Priority,
+		EstimatedMinutes: tmpl.EstimatedMinutes,
+		Metadata:         tmpl.Metadata,
+	}
+	t.create(c, userID, todo)
+}
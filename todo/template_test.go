@@ -0,0 +1,145 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/audit"
+)
+
+func setupTemplates(t *testing.T) (*TodoHandler, *gin.Engine) {
+	t.Helper()
+	handler, router := setupTestHandler(t)
+	router.POST("/templates", handler.CreateTemplate)
+	router.GET("/templates", handler.ListTemplates)
+	router.GET("/templates/:id", handler.GetTemplate)
+	router.DELETE("/templates/:id", handler.DeleteTemplate)
+	router.POST("/todos/from-template/:id", handler.NewTaskFromTemplate)
+	return handler, router
+}
+
+// TestNewTaskFromTemplate_CopiesFields: the todo gets the template's fields and the requested due date
+func TestNewTaskFromTemplate_CopiesFields(t *testing.T) {
+	handler, router := setupTemplates(t)
+
+	w := doJSON(router, http.MethodPost, "/templates", `{"text": "  Weekly   report ", "priority": "high", "estimated_minutes": 45, "metadata": {"project": "ops"}}`)
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != "/templates/1" {
+		t.Fatalf("expected the template at /templates/1, got %d %q: %s", w.Code, w.Header().Get("Location"), w.Body.String())
+	}
+	w = doJSON(router, http.MethodGet, w.Header().Get("Location"), "")
+	var saved Template
+	if err := json.Unmarshal(w.Body.Bytes(), &saved); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected the Location to return the template, got %d: %s", w.Code, w.Body.String())
+	}
+	if saved.Title != "Weekly report" || saved.Priority != PriorityHigh {
+		t.Errorf("expected the saved template, got %+v", saved)
+	}
+
+	due := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	w = doJSON(router, http.MethodPost, "/todos/from-template/1", `{"due_date": "`+due.Format(time.RFC3339)+`"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var todo Todo
+	handler.db.First(&todo)
+	if todo.Title != "Weekly report" || todo.Priority != PriorityHigh || todo.EstimatedMinutes != 45 || todo.UserID != testUserID {
+		t.Errorf("expected the template's fields, got %+v", todo)
+	}
+	if string(todo.Metadata) != `{"project":"ops"}` {
+		t.Errorf("expected the template's metadata, got %s", todo.Metadata)
+	}
+	if todo.DueDate == nil || !todo.DueDate.Equal(due) {
+		t.Errorf("expected due date %v, got %v", due, todo.DueDate)
+	}
+	var entries int64
+	handler.db.Model(&audit.Log{}).Where("action = ?", audit.ActionCreate).Count(&entries)
+	if entries != 1 {
+		t.Errorf("expected the todo's creation to be audited, got %d entries", entries)
+	}
+
+	w = doJSON(router, http.MethodPost, "/todos/from-template/1", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected a todo without a body too, got %d: %s", w.Code, w.Body.String())
+	}
+	var second Todo
+	handler.db.Last(&second)
+	if second.DueDate != nil || second.Title != "Weekly report" {
+		t.Errorf("expected a second todo without a due date, got %+v", second)
+	}
+}
+
+// TestNewTaskFromTemplate_DefaultPriority: templates without a priority give todos the default one
+func TestNewTaskFromTemplate_DefaultPriority(t *testing.T) {
+	handler, router := setupTemplates(t)
+	handler.cfg.DefaultPriority = PriorityLow
+	handler.db.Create(&Template{UserID: testUserID, Title: "Plain"})
+
+	if w := doJSON(router, http.MethodPost, "/todos/from-template/1", ""); w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var todo Todo
+	handler.db.First(&todo)
+	if todo.Priority != PriorityLow {
+		t.Errorf("expected priority %q, got %q", PriorityLow, todo.Priority)
+	}
+}
+
+// TestTemplates_ScopedToUser: other users' templates can't be listed, read, used or deleted
+func TestTemplates_ScopedToUser(t *testing.T) {
+	handler, router := setupTemplates(t)
+	handler.db.Create(&Template{UserID: testUserID, Title: "Mine"})
+	handler.db.Create(&Template{UserID: testUserID + 1, Title: "Theirs"})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/templates", nil))
+	var templates []Template
+	if err := json.Unmarshal(w.Body.Bytes(), &templates); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(templates) != 1 || templates[0].Title != "Mine" {
+		t.Errorf("expected only the caller's template, got %+v", templates)
+	}
+
+	if w := doJSON(router, http.MethodPost, "/todos/from-template/2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d using another user's template, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := doJSON(router, http.MethodGet, "/templates/2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d getting another user's template, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := doJSON(router, http.MethodDelete, "/templates/2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d deleting another user's template, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := doJSON(router, http.MethodDelete, "/templates/1", ""); w.Code != http.StatusNoContent {
+		t.Errorf("expected status %d deleting the caller's template, got %d", http.StatusNoContent, w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/todos/from-template/1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d using a deleted template, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestNewTaskFromTemplate_Invalid: bad bodies and past due dates are rejected without creating a todo
+func TestNewTaskFromTemplate_Invalid(t *testing.T) {
+	handler, router := setupTemplates(t)
+	handler.cfg.RejectPastDue = true
+	handler.db.Create(&Template{UserID: testUserID, Title: "Mine"})
+
+	if w := doJSON(router, http.MethodPost, "/todos/from-template/1", `{"due_date": "soon"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for a malformed due date, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/todos/from-template/1", `{"due_date": "2000-01-01T00:00:00Z"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d for a past due date, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/templates", `{"text": "Bad", "priority": "urgent"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d for a bad template priority, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	var count int64
+	handler.db.Model(&Todo{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected no todos, got %d", count)
+	}
+}
@@ -0,0 +1,106 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Supported values for Config.TimeFormat.
+const (
+	TimeRFC3339    = "rfc3339"
+	TimeUnixMillis = "unix_millis"
+)
+
+// timeKeys are the response keys, as declared on the models, that hold
+// timestamps.
+var timeKeys = map[string]bool{
+	"CreatedAt":        true,
+	"UpdatedAt":        true,
+	"DeletedAt":        true,
+	"due_date":         true,
+	"completed_at":     true,
+	"timer_started_at": true,
+	"created_at":       true,
+	"updated_at":       true,
+	"last_activity":    true,
+}
+
+// unixMillis rewrites every timestamp in a decoded JSON value as
+// milliseconds since the Unix epoch. Client metadata is left as sent.
+func unixMillis(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, inner := range val {
+			if k == "metadata" {
+				continue
+			}
+			if s, ok := inner.(string); ok && timeKeys[k] {
+				if ts, err := time.Parse(time.RFC3339Nano, s); err == nil {
+					val[k] = json.Number(strconv.FormatInt(ts.UnixMilli(), 10))
+					continue
+				}
+			}
+			val[k] = unixMillis(inner)
+		}
+		return val
+	case []any:
+		for i, inner := range val {
+			val[i] = unixMillis(inner)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// flexTime is a time decoded from either an RFC 3339 string or a JSON
+// number of milliseconds since the Unix epoch, so clients served
+// TimeUnixMillis can send timestamps back as they got them.
+type flexTime struct {
+	time.Time
+}
+
+func (t *flexTime) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		return t.Time.UnmarshalJSON(data)
+	}
+	ms, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("time must be an RFC 3339 string or milliseconds since the Unix epoch, got %s", data)
+	}
+	t.Time = time.UnixMilli(ms).UTC()
+	return nil
+}
+
+// ptr returns the decoded time, or nil if none was sent.
+func (t *flexTime) ptr() *time.Time {
+	if t == nil {
+		return nil
+	}
+	return &t.Time
+}
+
+// parseTime reads a timestamp query parameter, given as RFC 3339 or as
+// milliseconds since the Unix epoch.
+func parseTime(raw string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.UnixMilli(ms).UTC(), nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
+
+// sameTime reports whether seen, a timestamp the client got from a
+// response, is current. Under TimeUnixMillis responses only carry whole
+// milliseconds, so the comparison is made at that precision.
+func (s *TodoService) sameTime(seen, current time.Time) bool {
+	if s.cfg.TimeFormat == TimeUnixMillis {
+		return seen.UnixMilli() == current.UnixMilli()
+	}
+	return seen.Equal(current)
+}
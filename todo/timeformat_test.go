@@ -0,0 +1,119 @@
+package todo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+func getWithTimeFormat(t *testing.T, format string) (map[string]any, time.Time) {
+	t.Helper()
+	handler, router := setupTestHandler(t)
+	handler.cfg.TimeFormat = format
+	router.GET("/todos/:id", handler.GetTask)
+	due := time.Date(2026, 3, 1, 9, 30, 0, 123456789, time.UTC)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Timed", DueDate: &due, Metadata: datatypes.JSON(`{"due_date":"2026-03-01T00:00:00Z"}`)})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos/1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response map[string]any
+	dec := json.NewDecoder(w.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return response, due
+}
+
+// TestTimeFormat_RFC3339: timestamps are RFC 3339 strings by default
+func TestTimeFormat_RFC3339(t *testing.T) {
+	for _, format := range []string{"", TimeRFC3339} {
+		response, due := getWithTimeFormat(t, format)
+		if response["due_date"] != due.Format(time.RFC3339Nano) {
+			t.Errorf("%q: expected due_date %q, got %#v", format, due.Format(time.RFC3339Nano), response["due_date"])
+		}
+		if s, ok := response["CreatedAt"].(string); !ok {
+			t.Errorf("%q: expected a string CreatedAt, got %#v", format, response["CreatedAt"])
+		} else if _, err := time.Parse(time.RFC3339, s); err != nil {
+			t.Errorf("%q: CreatedAt is not RFC 3339: %v", format, err)
+		}
+	}
+}
+
+// TestTimeFormat_UnixMillis: timestamps are epoch milliseconds, nulls and metadata are untouched
+func TestTimeFormat_UnixMillis(t *testing.T) {
+	response, due := getWithTimeFormat(t, TimeUnixMillis)
+
+	if n, ok := response["due_date"].(json.Number); !ok || n.String() != "1772357400123" {
+		t.Errorf("expected due_date %d, got %#v", due.UnixMilli(), response["due_date"])
+	}
+	for _, key := range []string{"CreatedAt", "UpdatedAt"} {
+		if _, ok := response[key].(json.Number); !ok {
+			t.Errorf("expected a numeric %s, got %#v", key, response[key])
+		}
+	}
+	if response["completed_at"] != nil || response["DeletedAt"] != nil {
+		t.Errorf("expected null times to stay null, got %#v and %#v", response["completed_at"], response["DeletedAt"])
+	}
+	metadata, _ := response["metadata"].(map[string]any)
+	if metadata["due_date"] != "2026-03-01T00:00:00Z" {
+		t.Errorf("expected metadata to be left as sent, got %#v", response["metadata"])
+	}
+}
+
+// TestTimeFormat_UnixMillisRoundTrip: timestamps served as epoch milliseconds are accepted back by sync and filters
+func TestTimeFormat_UnixMillisRoundTrip(t *testing.T) {
+	handler, router := setupSyncHandler(t)
+	handler.cfg.TimeFormat = TimeUnixMillis
+	router.GET("/todos", handler.ListTasks)
+	router.GET("/todos/:id", handler.GetTask)
+	updated := time.Date(2026, 3, 1, 9, 30, 0, 123456789, time.UTC)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Timed"})
+	handler.db.Model(&Todo{}).Where("id = 1").UpdateColumn("updated_at", updated)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos/1", nil))
+	var served map[string]any
+	dec := json.NewDecoder(w.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&served); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	seen, ok := served["UpdatedAt"].(json.Number)
+	if !ok {
+		t.Fatalf("expected a numeric UpdatedAt, got %#v", served["UpdatedAt"])
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos?modified_since="+seen.String(), nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected modified_since to accept epoch milliseconds, got %d: %s", w.Code, w.Body.String())
+	}
+
+	stale := json.Number(fmt.Sprint(updated.UnixMilli() - 1))
+	w = doSync(t, router, []map[string]any{{"id": 1, "updated_at": stale, "text": "Lost"}})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"reason":"stale"`) {
+		t.Fatalf("expected an older time to be stale, got %d: %s", w.Code, w.Body.String())
+	}
+
+	due := json.Number("1772357400123")
+	w = doSync(t, router, []map[string]any{{"id": 1, "updated_at": seen, "text": "Synced", "due_date": due}})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"conflicts":[]`) {
+		t.Fatalf("expected the served updated_at to be current, got %d: %s", w.Code, w.Body.String())
+	}
+	var todo Todo
+	handler.db.First(&todo, 1)
+	if todo.Title != "Synced" || todo.DueDate == nil || todo.DueDate.UnixMilli() != 1772357400123 {
+		t.Errorf("expected the sync to be applied, got %+v", todo)
+	}
+}
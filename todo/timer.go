@@ -0,0 +1,85 @@
+package todo
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+)
+
+var (
+	errTimerRunning = apperr.ErrConflict.With("timer is already running")
+	errTimerStopped = apperr.ErrConflict.With("timer is not running")
+)
+
+// StartTimer starts timing work on one of the caller's todos. Starting a
+// timer that is already running is 409.
+func (t *TodoHandler) StartTimer(c *gin.Context) {
+	t.updateTimer(c, func(todo *Todo, now time.Time) error {
+		if todo.TimerStartedAt != nil {
+			return errTimerRunning
+		}
+		todo.TimerStartedAt = &now
+		return nil
+	})
+}
+
+// StopTimer stops the running timer of one of the caller's todos and adds
+// the time since it started to ActualMinutes. Stopping a timer that isn't
+// running is 409.
+func (t *TodoHandler) StopTimer(c *gin.Context) {
+	t.updateTimer(c, func(todo *Todo, now time.Time) error {
+		if todo.TimerStartedAt == nil {
+			return errTimerStopped
+		}
+		todo.ActualMinutes += elapsedMinutes(*todo.TimerStartedAt, now)
+		todo.TimerStartedAt = nil
+		return nil
+	})
+}
+
+// updateTimer applies change to the caller's todo through UpdateTimer and
+// writes the todo.
+func (t *TodoHandler) updateTimer(c *gin.Context, change func(todo *Todo, now time.Time) error) {
+	_, userID, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	todo, err := t.UpdateTimer(c.Request.Context(), userID, id, change)
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	t.respond(c, http.StatusOK, todo)
+}
+
+// UpdateTimer applies change to the timer of todo id of userID, given the
+// current time. An error from change is returned and nothing is saved.
+func (s *TodoService) UpdateTimer(ctx context.Context, userID, id uint, change func(todo *Todo, now time.Time) error) (Todo, error) {
+	return s.repo.Update(ctx, userID, id, func(todo *Todo) error {
+		return change(todo, time.Now())
+	})
+}
+
+// elapsedMinutes is the time from start to stop to the nearest minute.
+// A clock that went backwards counts as no time.
+func elapsedMinutes(start, stop time.Time) int {
+	return int(max(stop.Sub(start), 0).Round(time.Minute) / time.Minute)
+}
+
+// keepTimer carries the tracked time and running timer of previous over to
+// todo, which was decoded from a request. Only the timer endpoints change
+// them; a new todo starts with none.
+func keepTimer(todo *Todo, previous *Todo) {
+	todo.ActualMinutes, todo.TimerStartedAt = 0, nil
+	if previous != nil {
+		todo.ActualMinutes, todo.TimerStartedAt = previous.ActualMinutes, previous.TimerStartedAt
+	}
+}
@@ -0,0 +1,119 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestElapsedMinutes: elapsed time is rounded to the nearest minute and never negative
+func TestElapsedMinutes(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		elapsed time.Duration
+		want    int
+	}{
+		{0, 0},
+		{29 * time.Second, 0},
+		{30 * time.Second, 1},
+		{90*time.Minute + 10*time.Second, 90},
+		{25 * time.Hour, 1500},
+		{-time.Hour, 0},
+	} {
+		if got := elapsedMinutes(start, start.Add(tc.elapsed)); got != tc.want {
+			t.Errorf("%v: expected %d minutes, got %d", tc.elapsed, tc.want, got)
+		}
+	}
+}
+
+func setupTimer(t *testing.T) (*TodoHandler, *gin.Engine, func(path string) (Todo, int)) {
+	t.Helper()
+	handler, router := setupTestHandler(t)
+	router.POST("/todos/:id/timer/start", handler.StartTimer)
+	router.POST("/todos/:id/timer/stop", handler.StopTimer)
+	do := func(path string) (Todo, int) {
+		w := doJSON(router, http.MethodPost, path, "")
+		var todo Todo
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &todo); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+		}
+		return todo, w.Code
+	}
+	return handler, router, do
+}
+
+// TestTimer_StartStop: stopping adds the time since start to ActualMinutes, across several sessions
+func TestTimer_StartStop(t *testing.T) {
+	handler, _, do := setupTimer(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Write report", EstimatedMinutes: 120, ActualMinutes: 15})
+
+	started, code := do("/todos/1/timer/start")
+	if code != http.StatusOK || started.TimerStartedAt == nil {
+		t.Fatalf("expected a running timer, got status %d and %v", code, started.TimerStartedAt)
+	}
+
+	// Pretend the timer was started 45 minutes ago.
+	handler.db.Model(&Todo{}).Where("id = ?", 1).Update("timer_started_at", time.Now().Add(-45*time.Minute))
+	stopped, code := do("/todos/1/timer/stop")
+	if code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if stopped.ActualMinutes != 60 || stopped.TimerStartedAt != nil {
+		t.Errorf("expected 60 minutes and a stopped timer, got %d and %v", stopped.ActualMinutes, stopped.TimerStartedAt)
+	}
+	if stopped.EstimatedMinutes != 120 {
+		t.Errorf("expected the estimate to be kept, got %d", stopped.EstimatedMinutes)
+	}
+
+	var stored Todo
+	handler.db.First(&stored, 1)
+	if stored.ActualMinutes != 60 || stored.TimerStartedAt != nil {
+		t.Errorf("expected the tracked time to be stored, got %d and %v", stored.ActualMinutes, stored.TimerStartedAt)
+	}
+}
+
+// TestTimer_Conflicts: starting a running timer or stopping a stopped one is 409
+func TestTimer_Conflicts(t *testing.T) {
+	handler, _, do := setupTimer(t)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Mine"})
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "Not yours"})
+
+	if _, code := do("/todos/1/timer/stop"); code != http.StatusConflict {
+		t.Errorf("stop while stopped: expected status %d, got %d", http.StatusConflict, code)
+	}
+	do("/todos/1/timer/start")
+	if _, code := do("/todos/1/timer/start"); code != http.StatusConflict {
+		t.Errorf("start while running: expected status %d, got %d", http.StatusConflict, code)
+	}
+	if _, code := do("/todos/2/timer/start"); code != http.StatusNotFound {
+		t.Errorf("another user's todo: expected status %d, got %d", http.StatusNotFound, code)
+	}
+}
+
+// TestTimer_KeptOnReplace: PUT sets the estimate but can't change tracked time
+func TestTimer_KeptOnReplace(t *testing.T) {
+	handler, router, do := setupTimer(t)
+	router.PUT("/todos/:id", handler.PutTask)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Mine", ActualMinutes: 30})
+	do("/todos/1/timer/start")
+
+	w := doJSON(router, http.MethodPut, "/todos/1", `{"text": "Renamed", "estimated_minutes": 90, "actual_minutes": 0, "timer_started_at": null}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var stored Todo
+	handler.db.First(&stored, 1)
+	if stored.EstimatedMinutes != 90 || stored.ActualMinutes != 30 || stored.TimerStartedAt == nil {
+		t.Errorf("expected estimate 90 with 30 tracked minutes and a running timer, got %d, %d and %v",
+			stored.EstimatedMinutes, stored.ActualMinutes, stored.TimerStartedAt)
+	}
+
+	if w := doJSON(router, http.MethodPut, "/todos/1", `{"text": "Bad", "estimated_minutes": -5}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("negative estimate: expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
@@ -0,0 +1,83 @@
+package todo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+)
+
+const zoneKey = "todo.zone"
+
+// parseLocation accepts an IANA time zone name such as "Asia/Bangkok" or a
+// fixed UTC offset such as "+07:00" or "Z".
+func parseLocation(name string) (*time.Location, error) {
+	if offset, err := time.Parse("Z07:00", name); err == nil {
+		_, secs := offset.Zone()
+		return time.FixedZone(name, secs), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" || name == "Local" {
+		return nil, fmt.Errorf("tz must be an IANA time zone such as Asia/Bangkok or an offset such as +07:00, got %q", name)
+	}
+	return loc, nil
+}
+
+// selectZone parses ?tz= and stores it for respond and the views that
+// depend on the current day. It writes a 400 and returns false if the zone
+// is unknown.
+func selectZone(c *gin.Context) bool {
+	raw, ok := c.GetQuery("tz")
+	if !ok {
+		return true
+	}
+	loc, err := parseLocation(raw)
+	if err != nil {
+		apperr.Write(c, apperr.ErrBadRequest.Wrap(err))
+		return false
+	}
+	c.Set(zoneKey, loc)
+	return true
+}
+
+// selectedZone returns the zone stored by selectZone, or nil.
+func selectedZone(c *gin.Context) *time.Location {
+	loc, _ := c.Value(zoneKey).(*time.Location)
+	return loc
+}
+
+// location returns the zone a request's days are counted in: ?tz=, then
+// the configured Location, then time.Local.
+func (t *TodoHandler) location(c *gin.Context) *time.Location {
+	if loc := selectedZone(c); loc != nil {
+		return loc
+	}
+	if t.cfg.Location != nil {
+		return t.cfg.Location
+	}
+	return time.Local
+}
+
+func utc(ts *time.Time) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	u := ts.UTC()
+	return &u
+}
+
+func inZone(ts *time.Time, loc *time.Location) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	l := ts.In(loc)
+	return &l
+}
+
+// localize converts the due and completion times of todo to loc for
+// display. The instants are unchanged.
+func localize(todo *Todo, loc *time.Location) {
+	todo.DueDate = inZone(todo.DueDate, loc)
+	todo.CompletedAt = inZone(todo.CompletedAt, loc)
+}
@@ -0,0 +1,148 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int // seconds east of UTC at 2026-01-15
+		ok     bool
+	}{
+		{"Asia/Bangkok", 7 * 3600, true},
+		{"UTC", 0, true},
+		{"+07:00", 7 * 3600, true},
+		{"-05:30", -(5*3600 + 30*60), true},
+		{"Z", 0, true},
+		{"", 0, false},
+		{"Local", 0, false},
+		{"Mars/Olympus_Mons", 0, false},
+		{"+25:00", 0, false},
+	}
+	for _, tc := range tests {
+		loc, err := parseLocation(tc.name)
+		if (err == nil) != tc.ok {
+			t.Errorf("%q: expected ok=%v, got error %v", tc.name, tc.ok, err)
+			continue
+		}
+		if !tc.ok {
+			continue
+		}
+		if _, got := time.Date(2026, 1, 15, 12, 0, 0, 0, loc).Zone(); got != tc.offset {
+			t.Errorf("%q: expected offset %d, got %d", tc.name, tc.offset, got)
+		}
+	}
+}
+
+// TestEndOfDay_DST: days that gain or lose an hour still end at local midnight
+func TestEndOfDay_DST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		length time.Duration
+	}{
+		{"spring forward", time.Date(2026, 3, 8, 12, 0, 0, 0, newYork), 23 * time.Hour},
+		{"fall back", time.Date(2026, 11, 1, 12, 0, 0, 0, newYork), 25 * time.Hour},
+		{"ordinary day", time.Date(2026, 6, 1, 12, 0, 0, 0, newYork), 24 * time.Hour},
+	}
+	for _, tc := range tests {
+		end := endOfDay(tc.now, newYork)
+		start := time.Date(tc.now.Year(), tc.now.Month(), tc.now.Day(), 0, 0, 0, 0, newYork)
+		if end.Hour() != 0 || end.Day() != tc.now.Day()+1 {
+			t.Errorf("%s: expected next local midnight, got %v", tc.name, end)
+		}
+		if got := end.Sub(start); got != tc.length {
+			t.Errorf("%s: expected a %v day, got %v", tc.name, tc.length, got)
+		}
+	}
+}
+
+// TestDueDate_RoundTrip: due dates are stored in UTC and shown in the ?tz= zone on request
+func TestDueDate_RoundTrip(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+	router.GET("/todos/:id", handler.GetTask)
+
+	body, _ := json.Marshal(map[string]any{"text": "call mum", "due_date": "2026-03-08T09:00:00+07:00"})
+	req := httptest.NewRequest(http.MethodPost, "/todos?tz=Asia/Bangkok", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if due := decodeDueDate(t, w); due != "2026-03-08T09:00:00+07:00" {
+		t.Errorf("expected the create response in Bangkok time, got %s", due)
+	}
+
+	var stored string
+	handler.db.Raw("SELECT CAST(due_date AS TEXT) FROM todos WHERE id = 1").Scan(&stored)
+	if want := "2026-03-08 02:00:00+00:00"; stored != want {
+		t.Errorf("expected %q in the database, got %q", want, stored)
+	}
+
+	if due := decodeDueDate(t, doList(t, router, "/1")); due != "2026-03-08T02:00:00Z" {
+		t.Errorf("expected UTC without ?tz=, got %s", due)
+	}
+	if due := decodeDueDate(t, doList(t, router, "/1?tz=-05:00")); due != "2026-03-07T21:00:00-05:00" {
+		t.Errorf("expected the -05:00 offset, got %s", due)
+	}
+}
+
+func decodeDueDate(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp struct {
+		DueDate string `json:"due_date"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return resp.DueDate
+}
+
+// TestListToday_TZParam: ?tz= decides which day is today, overriding the configured zone
+func TestListToday_TZParam(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	handler.cfg.Location = time.UTC
+	router.GET("/todos/today", handler.ListToday)
+
+	// Whichever of the two zones finishes its day first, a todo due just
+	// after that is "today" only in the other one.
+	early, late := "+14:00", "-12:00"
+	earlyLoc, _ := parseLocation(early)
+	lateLoc, _ := parseLocation(late)
+	if endOfDay(time.Now(), earlyLoc).After(endOfDay(time.Now(), lateLoc)) {
+		early, late = late, early
+		earlyLoc = lateLoc
+	}
+	due := endOfDay(time.Now(), earlyLoc).Add(time.Minute)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "tomorrow in " + early, DueDate: &due})
+
+	if todos := decodeTodos(t, doList(t, router, "/today?tz="+url.QueryEscape(early))); len(todos) != 0 {
+		t.Errorf("expected nothing due today in %s, got %+v", early, todos)
+	}
+	if todos := decodeTodos(t, doList(t, router, "/today?tz="+url.QueryEscape(late))); len(todos) != 1 {
+		t.Errorf("expected the todo to be due today in %s, got %+v", late, todos)
+	}
+}
+
+func TestTZParam_Invalid(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+
+	if w := doList(t, router, "?tz=Nowhere/Special"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
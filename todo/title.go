@@ -0,0 +1,92 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+)
+
+// DefaultMaxTitleLen is the longest title accepted when Config.MaxTitleLen
+// is zero.
+const DefaultMaxTitleLen = 500
+
+const truncateKey = "todo.truncate"
+
+func (s *TodoService) maxTitleLen() int {
+	if s.cfg.MaxTitleLen > 0 {
+		return s.cfg.MaxTitleLen
+	}
+	return DefaultMaxTitleLen
+}
+
+// errEmptyTitle rejects a title that is empty or only whitespace.
+var errEmptyTitle = errors.New("text must not be empty")
+
+// checkTitle rejects a blank title, or one longer than the configured
+// maximum, counted in characters.
+func (s *TodoService) checkTitle(title string) error {
+	if strings.TrimSpace(title) == "" {
+		return errEmptyTitle
+	}
+	if max := s.maxTitleLen(); utf8.RuneCountInString(title) > max {
+		return fmt.Errorf("text must be at most %d characters", max)
+	}
+	return nil
+}
+
+// selectTruncate parses ?truncate=N and stores it for respond. It writes a
+// 400 and returns false unless N is a positive integer.
+func selectTruncate(c *gin.Context) bool {
+	raw, ok := c.GetQuery("truncate")
+	if !ok {
+		return true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		apperr.Write(c, apperr.ErrBadRequest.With("truncate must be a positive integer"))
+		return false
+	}
+	c.Set(truncateKey, n)
+	return true
+}
+
+// selectedTruncate returns the length stored by selectTruncate, or 0.
+func selectedTruncate(c *gin.Context) int {
+	n, _ := c.Value(truncateKey).(int)
+	return n
+}
+
+// truncate shortens a title longer than n characters to n, ending in an
+// ellipsis, and flags it. Only the response is affected.
+func truncate(todo *Todo, n int) {
+	runes := []rune(todo.Title)
+	if len(runes) <= n {
+		return
+	}
+	todo.Title = string(runes[:n-1]) + "…"
+	todo.Truncated = true
+}
+
+// forDisplay applies adjust to a copy of each todo in a Todo or []Todo.
+// Other data is returned as is.
+func forDisplay(data any, adjust func(*Todo)) any {
+	switch v := data.(type) {
+	case Todo:
+		adjust(&v)
+		return v
+	case []Todo:
+		out := make([]Todo, len(v))
+		for i, todo := range v {
+			adjust(&todo)
+			out[i] = todo
+		}
+		return out
+	default:
+		return data
+	}
+}
@@ -0,0 +1,71 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestNewTask_TitleTooLong: titles over the limit are rejected, counted in characters
+func TestNewTask_TitleTooLong(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+
+	if w := postTodo(router, strings.Repeat("é", DefaultMaxTitleLen)); w.Code != http.StatusCreated {
+		t.Errorf("expected a title at the limit to be accepted, got %d", w.Code)
+	}
+	if w := postTodo(router, strings.Repeat("a", DefaultMaxTitleLen+1)); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+func TestPutTask_ConfiguredMaxTitleLen(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	handler.cfg.MaxTitleLen = 10
+	router.PUT("/todos/:id", handler.PutTask)
+
+	w := doPut(t, router, "/todos/1", map[string]any{"text": "eleven char"})
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+// TestTruncate: ?truncate= shortens long titles in the response only
+func TestTruncate(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "short"})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "a rather long title"})
+
+	w := doList(t, router, "?truncate=8")
+
+	var todos []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &todos); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if todos[0]["text"] != "short" || todos[0]["truncated"] != nil {
+		t.Errorf("expected the short title untouched, got %v", todos[0])
+	}
+	if todos[1]["text"] != "a rathe…" || todos[1]["truncated"] != true {
+		t.Errorf("expected a truncated title, got %v", todos[1])
+	}
+
+	var stored Todo
+	handler.db.First(&stored, 2)
+	if stored.Title != "a rather long title" {
+		t.Errorf("expected the stored title intact, got %q", stored.Title)
+	}
+}
+
+func TestTruncate_Invalid(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos", handler.ListTasks)
+
+	for _, query := range []string{"?truncate=0", "?truncate=abc"} {
+		if w := doList(t, router, query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package todo
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+)
+
+// endOfDay returns midnight at the end of the day containing now, in loc.
+func endOfDay(now time.Time, loc *time.Location) time.Time {
+	now = now.In(loc)
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+}
+
+// ListToday returns the caller's incomplete todos that are due today or
+// already overdue, soonest due first. "Today" is the current day in the
+// ?tz= zone, or else the configured one.
+func (t *TodoHandler) ListToday(c *gin.Context) {
+	q, _, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	p, ok := t.page(c)
+	if !ok {
+		return
+	}
+	if !selectFields(c) || !selectZone(c) || !selectTruncate(c) {
+		return
+	}
+
+	// Due dates are stored in UTC, and SQLite compares them as text.
+	end := endOfDay(time.Now(), t.location(c)).UTC()
+
+	todos := []Todo{}
+	err := t.retry(c, func() error {
+		return q.Where("completed = ? AND due_date IS NOT NULL AND due_date < ?", false, end).
+			Order("due_date").Order("id").
+			Limit(p.Limit).Offset(p.Offset()).Find(&todos).Error
+	})
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	t.respond(c, http.StatusOK, todos)
+}
@@ -0,0 +1,55 @@
+package todo
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestEndOfDay: the day ends at local midnight, not UTC midnight
+func TestEndOfDay(t *testing.T) {
+	bangkok := time.FixedZone("UTC+7", 7*60*60)
+	// 20:00 UTC on the 10th is already 03:00 on the 11th in Bangkok.
+	now := time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC)
+
+	got := endOfDay(now, bangkok)
+
+	want := time.Date(2026, 3, 12, 0, 0, 0, 0, bangkok)
+	if !got.Equal(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// TestListToday: only the caller's incomplete todos due today or earlier, soonest first
+func TestListToday(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	loc := time.FixedZone("UTC+14", 14*60*60)
+	handler.cfg.Location = loc
+	router.GET("/todos/today", handler.ListToday)
+
+	end := endOfDay(time.Now(), loc)
+	at := func(d time.Duration) *time.Time {
+		due := end.Add(d).UTC()
+		return &due
+	}
+	handler.db.Create(&Todo{UserID: testUserID, Title: "late tonight", DueDate: at(-time.Minute)})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "tomorrow", DueDate: at(time.Minute)})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "overdue", DueDate: at(-48 * time.Hour)})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "done", DueDate: at(-time.Hour), Completed: true})
+	handler.db.Create(&Todo{UserID: testUserID, Title: "no deadline"})
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "someone else's", DueDate: at(-time.Hour)})
+
+	w := doList(t, router, "/today")
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var titles []string
+	for _, todo := range decodeTodos(t, w) {
+		titles = append(titles, todo.Title)
+	}
+	if !slices.Equal(titles, []string{"overdue", "late tonight"}) {
+		t.Errorf("expected [overdue late tonight], got %v", titles)
+	}
+}
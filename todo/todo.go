@@ -1,44 +1,344 @@
 package todo
 
 import (
+	"cmp"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/audit"
+	"github.com/pradist/todoapi/auth"
+	"github.com/pradist/todoapi/dbretry"
+	"github.com/pradist/todoapi/pagination"
+	"github.com/pradist/todoapi/response"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
 )
 
 type Todo struct {
-	Title string `json:"text"`
+	Title       string     `json:"text"`
+	DueDate     *time.Time `json:"due_date"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Priority    string     `json:"priority" binding:"omitempty,oneof=low medium high"`
+	UserID      uint       `json:"user_id" gorm:"index"`
+	// Status refines Completed: a completed todo is done or verified. It is
+	// derived from Completed on write and advanced by AdvanceStatus.
+	Status string `json:"status" gorm:"not null;default:open"`
+	// Metadata is a JSON object of the client's own, stored as given.
+	Metadata datatypes.JSON `json:"metadata"`
+	// EstimatedMinutes is the client's estimate of the work. ActualMinutes
+	// adds up the time tracked by the timer, which is running while
+	// TimerStartedAt is set.
+	EstimatedMinutes int        `json:"estimated_minutes" binding:"min=0"`
+	ActualMinutes    int        `json:"actual_minutes"`
+	TimerStartedAt   *time.Time `json:"timer_started_at"`
+	// DeleteReason is the optional reason given when the todo was deleted.
+	// Requests can't set it, only DELETE /todos/:id.
+	DeleteReason string `json:"delete_reason,omitempty"`
+	// Truncated marks a response whose text was shortened by ?truncate=.
+	Truncated bool `json:"truncated,omitempty" gorm:"-"`
+	// Deleted marks a deleted todo in a ?modified_since= response.
+	Deleted bool `json:"deleted,omitempty" gorm:"-"`
 	gorm.Model
 }
 
-func (Todo) TableName() string {
-	return "todos"
+// TableName is "todos" under GORM's default naming, with the naming
+// strategy's prefix and pluralisation applied.
+func (Todo) TableName(namer schema.Namer) string {
+	return namer.TableName("Todo")
+}
+
+// BeforeSave stores timestamps in UTC. SQLite compares them as text, so
+// mixed offsets would sort and filter wrongly.
+func (t *Todo) BeforeSave(*gorm.DB) error {
+	t.DueDate = utc(t.DueDate)
+	t.CompletedAt = utc(t.CompletedAt)
+	return nil
+}
+
+// Config holds the tunable behaviour of TodoHandler. The zero value is the
+// default configuration.
+type Config struct {
+	// JSONCase rewrites response keys to SnakeCase or CamelCase. Empty
+	// leaves them as declared on the model.
+	JSONCase string
+	// PreserveWhitespace stores titles exactly as submitted instead of
+	// trimming them and collapsing internal runs of whitespace.
+	PreserveWhitespace bool
+	// MaxTitleLen is the longest title accepted, in characters. Zero means
+	// DefaultMaxTitleLen.
+	MaxTitleLen int
+	// DefaultPriority is given to todos created without a priority. Empty
+	// means PriorityMedium.
+	DefaultPriority string
+	// MaxBulkItems is the most todos or ids one bulk request may carry.
+	// Zero means DefaultMaxBulkItems.
+	MaxBulkItems int
+	// MaxTodosPerUser caps how many active todos one user may hold. Zero
+	// means unlimited.
+	MaxTodosPerUser int
+	// PageLimits bounds the page sizes of list endpoints.
+	PageLimits pagination.Limits
+	// Retry bounds how transient database errors are retried. The zero
+	// value uses dbretry.DefaultPolicy.
+	Retry dbretry.Policy
+	// IDsAsStrings writes ids as JSON strings, which JavaScript clients
+	// can hold without losing precision. Like ResponseEnvelope, it applies
+	// to the other handlers too, through Response.
+	IDsAsStrings bool
+	// TimeFormat writes timestamps as TimeUnixMillis numbers instead of
+	// RFC 3339 strings. Empty means TimeRFC3339.
+	TimeFormat string
+	// ResponseEnvelope wraps successful JSON responses as
+	// {"data": ..., "meta": ...}.
+	ResponseEnvelope bool
+	// StrictJSON rejects request bodies with fields the endpoint doesn't
+	// know, such as "title" sent instead of "text".
+	StrictJSON bool
+	// RejectPastDue rejects due dates in the past on create and update.
+	RejectPastDue bool
+	// RequireIfMatch rejects a PUT that replaces a todo without an
+	// If-Match header, so clients can't overwrite changes they never saw.
+	RequireIfMatch bool
+	// DeleteNotFound answers a DELETE of an id the caller never had with
+	// 404 instead of 204. Todos already deleted are still a 204.
+	DeleteNotFound bool
+	// Audit writes audit entries in batches after their transaction
+	// commits. Nil writes each entry in its transaction.
+	Audit *audit.Batcher
+	// Location is the time zone that decides where a day starts and ends.
+	// Nil means time.Local.
+	Location *time.Location
+}
+
+// Response returns the shaping of successful JSON responses cfg asks for,
+// for handlers outside this package.
+func (cfg Config) Response() response.Options {
+	return response.Options{Envelope: cfg.ResponseEnvelope, IDsAsStrings: cfg.IDsAsStrings}
 }
 
+// TodoHandler translates HTTP requests into calls on its TodoService and
+// the results back into responses. Endpoints the service doesn't cover, as
+// listed on TodoService, use the database directly.
 type TodoHandler struct {
+	*TodoService
 	db *gorm.DB
+
+	schemaOnce sync.Once
+	schema     graphql.Schema
+	schemaErr  error
+}
+
+func NewTodoHandler(db *gorm.DB, cfg Config) *TodoHandler {
+	service := NewTodoService(NewGormTodoRepository(db, cfg), cfg)
+	return &TodoHandler{TodoService: service, db: db}
+}
+
+// conn returns the handler's database bound to the request context, so
+// queries are cancelled with the request and attributed to it.
+func (t *TodoHandler) conn(c *gin.Context) *gorm.DB {
+	return t.db.WithContext(c.Request.Context())
+}
+
+// retry runs fn again while it fails for a transient reason, until the
+// request is cancelled. fn must be safe to repeat.
+func (t *TodoHandler) retry(c *gin.Context, fn func() error) error {
+	return dbretry.Do(c.Request.Context(), t.cfg.Retry, fn)
+}
+
+// transaction runs fn in a transaction bound to the request, as
+// runTransaction does.
+func (t *TodoHandler) transaction(c *gin.Context, fn func(tx *gorm.DB) error) error {
+	return runTransaction(c.Request.Context(), t.db, t.cfg, fn)
+}
+
+// owned returns a query limited to the caller's todos. The query is a new
+// session, so it can be reused across retries. It writes a 401 and returns
+// false if the request carries no user identity.
+func (t *TodoHandler) owned(c *gin.Context) (*gorm.DB, uint, bool) {
+	userID, ok := auth.UserID(c)
+	if !ok {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return nil, 0, false
+	}
+	return t.conn(c).Where("user_id = ?", userID).Session(&gorm.Session{}), userID, true
+}
+
+// clean tidies the title and fills in the default priority of a todo
+// decoded from a request. Any delete reason is dropped: only deleting a
+// todo sets one.
+func (s *TodoService) clean(todo *Todo) {
+	todo.Title = s.cleanTitle(todo.Title)
+	todo.DeleteReason = ""
+	if todo.Priority == "" {
+		todo.Priority = cmp.Or(s.cfg.DefaultPriority, PriorityMedium)
+	}
+}
+
+func (s *TodoService) cleanTitle(title string) string {
+	if s.cfg.PreserveWhitespace {
+		return title
+	}
+	return normalizeWhitespace(title)
 }
 
-func NewTodoHandler(db *gorm.DB) *TodoHandler {
-	return &TodoHandler{db: db}
+// normalizeWhitespace trims s and collapses every internal run of
+// whitespace into a single space.
+func normalizeWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// stampCompletion sets CompletedAt and Status from the Completed flag. A
+// todo that was already complete keeps its original completion time and
+// status. Reopening a verified todo is errReopenVerified: as with
+// AdvanceStatus, it must go back to done first.
+func stampCompletion(todo *Todo, previous *Todo) error {
+	switch {
+	case !todo.Completed:
+		if previous != nil && previous.Status == StatusVerified {
+			return errReopenVerified
+		}
+		todo.CompletedAt = nil
+		todo.Status = StatusOpen
+	case previous != nil && previous.Completed:
+		todo.CompletedAt = previous.CompletedAt
+		todo.Status = previous.Status
+	default:
+		now := time.Now()
+		todo.CompletedAt = &now
+		todo.Status = StatusDone
+	}
+	return nil
 }
 
+var errQuotaExceeded = apperr.ErrQuotaExceeded.With("todo limit reached for this user")
+
 func (t *TodoHandler) NewTask(c *gin.Context) {
+	_, userID, ok := t.owned(c)
+	if !ok {
+		return
+	}
+
+	if !selectZone(c) {
+		return
+	}
 	var todo Todo
-	if err := c.ShouldBindJSON(&todo); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !t.bindJSON(c, &todo) {
+		return
+	}
+	t.create(c, userID, todo)
+}
+
+// create saves todo as a new todo of userID through the service and
+// answers 201 with it.
+func (t *TodoHandler) create(c *gin.Context, userID uint, todo Todo) {
+	todo, err := t.Create(c.Request.Context(), userID, todo)
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	t.respondCreated(c, todo)
+}
+
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, strconv.IntSize)
+	if err != nil || id == 0 {
+		apperr.Write(c, apperr.ErrBadRequest.With("invalid id"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (t *TodoHandler) GetTask(c *gin.Context) {
+	_, userID, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	deleted, ok := includeDeleted(c)
+	if !ok {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	if !selectFields(c) || !selectZone(c) || !selectTruncate(c) {
+		return
+	}
+
+	todo, err := t.Get(c.Request.Context(), userID, id, deleted)
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	setETag(c, todo)
+	if noneMatch(c.GetHeader("If-None-Match"), etag(c, todo)) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	t.respond(c, http.StatusOK, todo)
+}
+
+var errTodoNotFound = apperr.ErrNotFound.With("todo not found")
+
+var (
+	errIDTaken = apperr.ErrConflict.With("id is already taken")
+	errExists  = apperr.ErrPreconditionFailed.With("a todo with this id already exists")
+)
+
+// PutTask creates or replaces the todo at /todos/:id. The body is validated
+// the same way as NewTask. Replaying the same request always leaves the
+// resource in the same state, so clients may safely retry it. Ids held by
+// another user's todo or by a deleted todo cannot be claimed. With
+// If-None-Match: * it only creates, answering 412 if the id is in use. With
+// If-Match it only replaces, answering 412 unless the todo's current ETag
+// is listed; Config.RequireIfMatch makes If-Match mandatory for replacing.
+func (t *TodoHandler) PutTask(c *gin.Context) {
+	_, userID, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if !selectZone(c) {
+		return
+	}
+	var input Todo
+	if !t.bindJSON(c, &input) {
+		return
+	}
+	cond := PutConditions{CreateOnly: c.GetHeader("If-None-Match") == "*"}
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		cond.Match = func(current Todo) bool { return matches(ifMatch, current) }
+	}
+
+	todo, created, err := t.Put(c.Request.Context(), userID, id, input, cond)
+	if err != nil {
+		apperr.Write(c, err)
 		return
 	}
 
-	r := t.db.Create(&todo)
-	if err := r.Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+	setETag(c, todo)
+	if created {
+		c.Header("Location", "/todos/"+strconv.FormatUint(uint64(id), 10))
+		t.respond(c, http.StatusCreated, todo)
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{
-		"ID": todo.Model.ID,
-	})
+	t.respond(c, http.StatusOK, todo)
 }
@@ -2,12 +2,20 @@ package todo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/mattn/go-sqlite3"
+	"github.com/pradist/todoapi/audit"
+	"github.com/pradist/todoapi/auth"
+	"github.com/pradist/todoapi/settings"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -18,7 +26,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("failed to connect to test database: %v", err)
 	}
 
-	err = db.AutoMigrate(&Todo{})
+	err = db.AutoMigrate(&Todo{}, &Template{}, &audit.Log{}, &settings.Settings{})
 	if err != nil {
 		t.Fatalf("failed to migrate test database: %v", err)
 	}
@@ -26,13 +34,25 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	return db
 }
 
+const testUserID = 1
+
+// asUser stands in for auth.Protect, marking every request as made by userID.
+func asUser(userID uint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth.SetClaims(c, &auth.Claims{StandardClaims: jwt.StandardClaims{
+			Subject: strconv.FormatUint(uint64(userID), 10),
+		}})
+	}
+}
+
 func setupTestHandler(t *testing.T) (*TodoHandler, *gin.Engine) {
 	gin.SetMode(gin.TestMode)
 
 	db := setupTestDB(t)
-	handler := NewTodoHandler(db)
+	handler := NewTodoHandler(db, Config{})
 
 	router := gin.New()
+	router.Use(asUser(testUserID))
 
 	return handler, router
 }
@@ -67,23 +87,44 @@ func TestNewTask_Success(t *testing.T) {
 	}
 }
 
+// TestNewTask_EmptyTitle: an empty or blank title is rejected and nothing is saved
 func TestNewTask_EmptyTitle(t *testing.T) {
 	handler, router := setupTestHandler(t)
 	router.POST("/todos", handler.NewTask)
 
-	todo := map[string]any{
-		"text": "",
-	}
+	for _, title := range []string{"", "   "} {
+		todo := map[string]any{
+			"text": title,
+		}
 
-	jsonData, _ := json.Marshal(todo)
-	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBuffer(jsonData))
-	req.Header.Set("Content-Type", "application/json")
+		jsonData, _ := json.Marshal(todo)
+		req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
 
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
 
-	if w.Code != http.StatusCreated {
-		t.Errorf("expected status %d, got %d", http.StatusCreated, w.Code)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%q: expected status %d, got %d", title, http.StatusUnprocessableEntity, w.Code)
+		}
+	}
+	var count int64
+	handler.db.Model(&Todo{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected no todos, got %d", count)
+	}
+}
+
+// TestNewTask_NonEmptyTitle: any title with a visible character is still created
+func TestNewTask_NonEmptyTitle(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	handler.cfg.PreserveWhitespace = true
+	router.POST("/todos", handler.NewTask)
+
+	for _, title := range []string{"x", "  padded  "} {
+		if w := postTodo(router, title); w.Code != http.StatusCreated {
+			t.Errorf("%q: expected status %d, got %d", title, http.StatusCreated, w.Code)
+		}
 	}
 }
 
@@ -222,7 +263,7 @@ func TestNewTask_DatabaseError(t *testing.T) {
 	}
 
 	// Migrate normally first
-	err = db.AutoMigrate(&Todo{})
+	err = db.AutoMigrate(&Todo{}, &Template{}, &audit.Log{}, &settings.Settings{})
 	if err != nil {
 		t.Fatalf("failed to migrate test database: %v", err)
 	}
@@ -231,8 +272,9 @@ func TestNewTask_DatabaseError(t *testing.T) {
 	sqlDB, _ := db.DB()
 	sqlDB.Close()
 
-	handler := NewTodoHandler(db)
+	handler := NewTodoHandler(db, Config{})
 	router := gin.New()
+	router.Use(asUser(testUserID))
 	router.POST("/todos", handler.NewTask)
 
 	todo := map[string]any{
@@ -260,3 +302,665 @@ func TestNewTask_DatabaseError(t *testing.T) {
 		t.Error("expected response to contain error field")
 	}
 }
+
+func doPut(t *testing.T, router *gin.Engine, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	jsonData, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPut, path, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetTask_Success(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos/:id", handler.GetTask)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Existing todo"})
+
+	req := httptest.NewRequest(http.MethodGet, "/todos/1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response map[string]any
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response["text"] != "Existing todo" {
+		t.Errorf("expected text 'Existing todo', got %v", response["text"])
+	}
+}
+
+func TestGetTask_NotFound(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos/:id", handler.GetTask)
+
+	req := httptest.NewRequest(http.MethodGet, "/todos/42", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestGetTask_InvalidID(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos/:id", handler.GetTask)
+
+	for _, id := range []string{"abc", "0", "-1"} {
+		req := httptest.NewRequest(http.MethodGet, "/todos/"+id, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("id %q: expected status %d, got %d", id, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestPutTask_CreatesWhenMissing: PUT to an unused id creates the todo with that id
+func TestPutTask_CreatesWhenMissing(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.PUT("/todos/:id", handler.PutTask)
+
+	w := doPut(t, router, "/todos/7", map[string]any{"text": "Client generated"})
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "/todos/7" {
+		t.Errorf("expected Location '/todos/7', got %q", loc)
+	}
+
+	var savedTodo Todo
+	if err := handler.db.First(&savedTodo, 7).Error; err != nil {
+		t.Fatalf("todo was not saved with the requested id: %v", err)
+	}
+	if savedTodo.Title != "Client generated" {
+		t.Errorf("expected title 'Client generated', got '%s'", savedTodo.Title)
+	}
+}
+
+// TestPutTask_UpdatesWhenExisting: PUT to an existing id replaces it and returns 200
+func TestPutTask_UpdatesWhenExisting(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.PUT("/todos/:id", handler.PutTask)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Old title"})
+
+	w := doPut(t, router, "/todos/1", map[string]any{"text": "New title"})
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "" {
+		t.Errorf("expected no Location header on update, got %q", loc)
+	}
+
+	var savedTodo Todo
+	handler.db.First(&savedTodo, 1)
+	if savedTodo.Title != "New title" {
+		t.Errorf("expected title 'New title', got '%s'", savedTodo.Title)
+	}
+}
+
+// TestPutTask_Idempotent: repeating the same PUT leaves a single todo in the same state
+func TestPutTask_Idempotent(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.PUT("/todos/:id", handler.PutTask)
+
+	first := doPut(t, router, "/todos/3", map[string]any{"text": "Same"})
+	second := doPut(t, router, "/todos/3", map[string]any{"text": "Same"})
+
+	if first.Code != http.StatusCreated || second.Code != http.StatusOK {
+		t.Fatalf("expected 201 then 200, got %d then %d", first.Code, second.Code)
+	}
+
+	var count int64
+	handler.db.Model(&Todo{}).Count(&count)
+	if count != 1 {
+		t.Errorf("expected 1 todo, got %d", count)
+	}
+}
+
+// TestPutTask_DeletedIDConflict: an id held by a soft-deleted todo cannot be reused
+func TestPutTask_DeletedIDConflict(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.PUT("/todos/:id", handler.PutTask)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Gone"})
+	handler.db.Delete(&Todo{}, 1)
+
+	w := doPut(t, router, "/todos/1", map[string]any{"text": "Reborn"})
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+// TestPutTask_InvalidPriority: a well-formed body that breaks a rule is 422, not 400
+func TestPutTask_InvalidPriority(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.PUT("/todos/:id", handler.PutTask)
+
+	w := doPut(t, router, "/todos/1", map[string]any{"text": "urgent", "priority": "critical"})
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+// TestNewTask_WrongFieldType: a value of the wrong JSON type can't be decoded and is 400
+func TestNewTask_WrongFieldType(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+
+	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBufferString(`{"text": "x", "completed": "yes"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func doPutCreateOnly(router *gin.Engine, path, title string) *httptest.ResponseRecorder {
+	jsonData, _ := json.Marshal(map[string]any{"text": title})
+	req := httptest.NewRequest(http.MethodPut, path, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-None-Match", "*")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestPutTask_IfNoneMatchCreates: If-None-Match: * creates a todo at an unused id
+func TestPutTask_IfNoneMatchCreates(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.PUT("/todos/:id", handler.PutTask)
+
+	w := doPutCreateOnly(router, "/todos/7", "Once only")
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var saved Todo
+	if err := handler.db.First(&saved, 7).Error; err != nil || saved.Title != "Once only" {
+		t.Errorf("expected todo 7 to be created, got %+v (%v)", saved, err)
+	}
+}
+
+// TestPutTask_IfNoneMatchExisting: If-None-Match: * never replaces, even the caller's own todo
+func TestPutTask_IfNoneMatchExisting(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.PUT("/todos/:id", handler.PutTask)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Original"})
+
+	w := doPutCreateOnly(router, "/todos/1", "Duplicate")
+
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected status %d, got %d", http.StatusPreconditionFailed, w.Code)
+	}
+	var saved Todo
+	handler.db.First(&saved, 1)
+	if saved.Title != "Original" {
+		t.Errorf("expected the todo to be unchanged, got %q", saved.Title)
+	}
+	if actions := auditActions(t, handler); len(actions) != 0 {
+		t.Errorf("expected no audit entries, got %v", actions)
+	}
+}
+
+func TestPutTask_InvalidJSON(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.PUT("/todos/:id", handler.PutTask)
+
+	req := httptest.NewRequest(http.MethodPut, "/todos/1", bytes.NewBufferString(`{"text": invalid json}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestNormalizeWhitespace(t *testing.T) {
+	tests := map[string]string{
+		"  buy milk  ":             "buy milk",
+		"buy   milk":               "buy milk",
+		"buy\t\nmilk":              "buy milk",
+		"already clean":            "already clean",
+		"   ":                      "",
+		" a  b   c ":               "a b c",
+		"\u00a0non-breaking\u00a0": "non-breaking",
+	}
+	for in, want := range tests {
+		if got := normalizeWhitespace(in); got != want {
+			t.Errorf("normalizeWhitespace(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestNewTask_NormalizesWhitespace: titles are trimmed and collapsed before saving
+func TestNewTask_NormalizesWhitespace(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+
+	jsonData, _ := json.Marshal(map[string]any{"text": "  Buy    some   milk "})
+	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var savedTodo Todo
+	handler.db.First(&savedTodo)
+	if savedTodo.Title != "Buy some milk" {
+		t.Errorf("expected title 'Buy some milk', got %q", savedTodo.Title)
+	}
+}
+
+// TestPutTask_NormalizesWhitespace: the update path cleans titles too
+func TestPutTask_NormalizesWhitespace(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.PUT("/todos/:id", handler.PutTask)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Old"})
+
+	doPut(t, router, "/todos/1", map[string]any{"text": " New \t title "})
+
+	var savedTodo Todo
+	handler.db.First(&savedTodo, 1)
+	if savedTodo.Title != "New title" {
+		t.Errorf("expected title 'New title', got %q", savedTodo.Title)
+	}
+}
+
+// TestNewTask_PreserveWhitespace: opting out stores the title exactly as sent
+func TestNewTask_PreserveWhitespace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	handler := NewTodoHandler(db, Config{PreserveWhitespace: true})
+	router := gin.New()
+	router.Use(asUser(testUserID))
+	router.POST("/todos", handler.NewTask)
+
+	jsonData, _ := json.Marshal(map[string]any{"text": "  exact   text "})
+	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var savedTodo Todo
+	db.First(&savedTodo)
+	if savedTodo.Title != "  exact   text " {
+		t.Errorf("expected title to be preserved, got %q", savedTodo.Title)
+	}
+}
+
+// TestNewTask_WithoutIdentity: requests that did not pass through auth are rejected
+func TestNewTask_WithoutIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewTodoHandler(setupTestDB(t), Config{})
+	router := gin.New()
+	router.POST("/todos", handler.NewTask)
+
+	jsonData, _ := json.Marshal(map[string]any{"text": "anonymous"})
+	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+// TestNewTask_AssignsOwnerAndDefaults: the caller owns the todo, and priority defaults to medium
+func TestNewTask_AssignsOwnerAndDefaults(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+
+	jsonData, _ := json.Marshal(map[string]any{"text": "mine", "user_id": 99})
+	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var savedTodo Todo
+	handler.db.First(&savedTodo)
+	if savedTodo.UserID != testUserID {
+		t.Errorf("expected owner %d, got %d", testUserID, savedTodo.UserID)
+	}
+	if savedTodo.Priority != PriorityMedium {
+		t.Errorf("expected priority %q, got %q", PriorityMedium, savedTodo.Priority)
+	}
+}
+
+// TestNewTask_ConfiguredDefaultPriority: todos created without a priority get the configured default
+func TestNewTask_ConfiguredDefaultPriority(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	handler.cfg.DefaultPriority = PriorityHigh
+	router.POST("/todos", handler.NewTask)
+
+	w := doJSON(router, http.MethodPost, "/todos", `{"text": "no priority"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	doJSON(router, http.MethodPost, "/todos", `{"text": "explicit", "priority": "low"}`)
+
+	var saved []Todo
+	handler.db.Order("id").Find(&saved)
+	if len(saved) != 2 || saved[0].Priority != PriorityHigh || saved[1].Priority != PriorityLow {
+		t.Errorf("expected priorities high and low, got %+v", saved)
+	}
+}
+
+func TestNewTask_InvalidPriority(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+
+	jsonData, _ := json.Marshal(map[string]any{"text": "urgent", "priority": "critical"})
+	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+// TestNewTask_CompletedStampsTime: creating a completed todo records when it was completed
+func TestNewTask_CompletedStampsTime(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+
+	jsonData, _ := json.Marshal(map[string]any{"text": "done already", "completed": true})
+	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var savedTodo Todo
+	handler.db.First(&savedTodo)
+	if !savedTodo.Completed || savedTodo.CompletedAt == nil {
+		t.Errorf("expected completed todo with completed_at, got %+v", savedTodo)
+	}
+}
+
+// TestGetTask_OtherUsersTodo: another user's todo is indistinguishable from a missing one
+func TestGetTask_OtherUsersTodo(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.GET("/todos/:id", handler.GetTask)
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "Not yours"})
+
+	req := httptest.NewRequest(http.MethodGet, "/todos/1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestPutTask_OtherUsersIDConflict: PUT cannot take over an id owned by another user
+func TestPutTask_OtherUsersIDConflict(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.PUT("/todos/:id", handler.PutTask)
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "Not yours"})
+
+	w := doPut(t, router, "/todos/1", map[string]any{"text": "Mine now"})
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	var savedTodo Todo
+	handler.db.First(&savedTodo, 1)
+	if savedTodo.Title != "Not yours" {
+		t.Errorf("expected other user's todo to be untouched, got %q", savedTodo.Title)
+	}
+}
+
+// TestPutTask_KeepsCompletionTime: re-sending a completed todo does not move completed_at
+func TestPutTask_KeepsCompletionTime(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.PUT("/todos/:id", handler.PutTask)
+	completedAt := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Done", Completed: true, CompletedAt: &completedAt})
+
+	doPut(t, router, "/todos/1", map[string]any{"text": "Done", "completed": true})
+
+	var savedTodo Todo
+	handler.db.First(&savedTodo, 1)
+	if savedTodo.CompletedAt == nil || !savedTodo.CompletedAt.Equal(completedAt) {
+		t.Errorf("expected completed_at %v to be kept, got %v", completedAt, savedTodo.CompletedAt)
+	}
+}
+
+func auditActions(t *testing.T, handler *TodoHandler) []string {
+	t.Helper()
+	var entries []audit.Log
+	handler.db.Order("id").Find(&entries)
+	actions := make([]string, len(entries))
+	for i, e := range entries {
+		actions[i] = e.Action
+	}
+	return actions
+}
+
+// TestNewTask_RecordsAudit: creating a todo writes a create audit entry
+func TestNewTask_RecordsAudit(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+
+	jsonData, _ := json.Marshal(map[string]any{"text": "audited"})
+	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry audit.Log
+	if err := handler.db.First(&entry).Error; err != nil {
+		t.Fatalf("expected an audit entry: %v", err)
+	}
+	if entry.Action != audit.ActionCreate || entry.TodoID != 1 || entry.UserID != testUserID {
+		t.Errorf("unexpected audit entry %+v", entry)
+	}
+}
+
+// TestNewTask_AuditFailureRollsBack: if the audit entry can't be written, the todo isn't either
+func TestNewTask_AuditFailureRollsBack(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+	handler.db.Migrator().DropTable(&audit.Log{})
+
+	jsonData, _ := json.Marshal(map[string]any{"text": "not saved"})
+	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	var count int64
+	handler.db.Model(&Todo{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected the todo to be rolled back, found %d", count)
+	}
+}
+
+// failCreates makes the next n inserts fail with err, as if the database
+// had returned it.
+func failCreates(t *testing.T, db *gorm.DB, n int, err error) {
+	t.Helper()
+	hook := func(tx *gorm.DB) {
+		if n > 0 {
+			n--
+			tx.AddError(err)
+		}
+	}
+	if err := db.Callback().Create().Before("gorm:create").Register("test:fail_creates", hook); err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+}
+
+// TestNewTask_RetriesTransientError: a busy database is retried and the todo is created exactly once
+func TestNewTask_RetriesTransientError(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+	failCreates(t, handler.db, 2, sqlite3.Error{Code: sqlite3.ErrBusy})
+
+	w := postTodo(router, "eventually saved")
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var count int64
+	handler.db.Model(&Todo{}).Count(&count)
+	if count != 1 {
+		t.Errorf("expected 1 todo, got %d", count)
+	}
+	if got := auditActions(t, handler); len(got) != 1 {
+		t.Errorf("expected 1 audit entry, got %v", got)
+	}
+}
+
+// TestNewTask_NonTransientErrorNotRetried: other database errors fail the request on the first attempt
+func TestNewTask_NonTransientErrorNotRetried(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.POST("/todos", handler.NewTask)
+	failCreates(t, handler.db, 1, sqlite3.Error{Code: sqlite3.ErrConstraint})
+
+	if w := postTodo(router, "not saved"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	// The injected failure was consumed by a single attempt.
+	if w := postTodo(router, "saved"); w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+// TestPutTask_RecordsAudit: PUT records a create, then an update with only the changed fields
+func TestPutTask_RecordsAudit(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.PUT("/todos/:id", handler.PutTask)
+
+	doPut(t, router, "/todos/1", map[string]any{"text": "v1"})
+	doPut(t, router, "/todos/1", map[string]any{"text": "v2"})
+	doPut(t, router, "/todos/1", map[string]any{"text": "v2"})
+
+	actions := auditActions(t, handler)
+	if len(actions) != 2 || actions[0] != audit.ActionCreate || actions[1] != audit.ActionUpdate {
+		t.Fatalf("expected [create update], got %v", actions)
+	}
+
+	var update audit.Log
+	handler.db.Last(&update)
+	var changes map[string]audit.Change
+	json.Unmarshal(update.Changes, &changes)
+	if len(changes) != 1 || changes["text"].Old != "v1" || changes["text"].New != "v2" {
+		t.Errorf("expected only text v1 -> v2, got %v", changes)
+	}
+}
+
+// TestPutTask_FailedMutationNoAudit: a rejected PUT leaves no audit entry behind
+func TestPutTask_FailedMutationNoAudit(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	router.PUT("/todos/:id", handler.PutTask)
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "Not yours"})
+
+	w := doPut(t, router, "/todos/1", map[string]any{"text": "Mine now"})
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	if actions := auditActions(t, handler); len(actions) != 0 {
+		t.Errorf("expected no audit entries, got %v", actions)
+	}
+}
+
+func postTodo(router *gin.Engine, title string) *httptest.ResponseRecorder {
+	jsonData, _ := json.Marshal(map[string]any{"text": title})
+	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestNewTask_QuotaExceeded: creating beyond MaxTodosPerUser returns 403 quota_exceeded
+func TestNewTask_QuotaExceeded(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	handler.cfg.MaxTodosPerUser = 2
+	router.POST("/todos", handler.NewTask)
+	router.PUT("/todos/:id", handler.PutTask)
+
+	for i := range 2 {
+		if w := postTodo(router, "ok"); w.Code != http.StatusCreated {
+			t.Fatalf("todo %d: expected status %d, got %d", i+1, http.StatusCreated, w.Code)
+		}
+	}
+
+	w := postTodo(router, "one too many")
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+	var response map[string]any
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response["code"] != "quota_exceeded" {
+		t.Errorf("expected code quota_exceeded, got %v", response["code"])
+	}
+
+	if w := doPut(t, router, "/todos/99", map[string]any{"text": "via put"}); w.Code != http.StatusForbidden {
+		t.Errorf("PUT create: expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := doPut(t, router, "/todos/1", map[string]any{"text": "replace"}); w.Code != http.StatusOK {
+		t.Errorf("PUT replace: expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+// TestNewTask_QuotaIgnoresDeletedAndOtherUsers: only the caller's active todos count
+func TestNewTask_QuotaIgnoresDeletedAndOtherUsers(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	handler.cfg.MaxTodosPerUser = 1
+	router.POST("/todos", handler.NewTask)
+
+	deleted := Todo{UserID: testUserID, Title: "deleted"}
+	handler.db.Create(&deleted)
+	handler.db.Delete(&deleted)
+	handler.db.Create(&Todo{UserID: testUserID + 1, Title: "someone else's"})
+
+	if w := postTodo(router, "mine"); w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+// TestTransaction_AuditBatched: batched audit entries are written after commit, and never for failed changes
+func TestTransaction_AuditBatched(t *testing.T) {
+	handler, router := setupTestHandler(t)
+	sqlDB, _ := handler.db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	batcher := audit.NewBatcher(handler.db, 100, time.Hour)
+	handler.cfg.Audit = batcher
+	handler.cfg.MaxTodosPerUser = 1
+	router.POST("/todos", handler.NewTask)
+
+	if w := doJSON(router, http.MethodPost, "/todos", `{"text": "Kept"}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/todos", `{"text": "Over quota"}`); w.Code != http.StatusForbidden {
+		t.Fatalf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+	if err := batcher.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var entries []audit.Log
+	handler.db.Find(&entries)
+	if len(entries) != 1 || entries[0].Action != audit.ActionCreate || entries[0].TodoID != 1 {
+		t.Errorf("expected only the committed creation, got %+v", entries)
+	}
+}
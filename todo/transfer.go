@@ -0,0 +1,81 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/audit"
+	"github.com/pradist/todoapi/auth"
+	"gorm.io/gorm"
+)
+
+type transferRequest struct {
+	ToUserID flexID `json:"to_user_id" binding:"required"`
+}
+
+var (
+	errNotOwner   = apperr.ErrForbidden.With("only the owner or an admin may transfer this todo")
+	errNoSuchUser = errors.New("target user does not exist")
+)
+
+// Transfer hands a todo to another user. The caller must own the todo or be
+// an admin, and the new owner must exist and have room under their quota.
+// The change is audited as a transfer by the caller.
+func (t *TodoHandler) Transfer(c *gin.Context) {
+	_, userID, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var req transferRequest
+	if !t.bindJSON(c, &req) {
+		return
+	}
+	to := uint(req.ToUserID)
+	isAdmin := auth.HasRole(c, auth.RoleAdmin)
+
+	var todo Todo
+	err := t.transaction(c, func(tx *gorm.DB) error {
+		todo = Todo{}
+		if err := tx.First(&todo, id).Error; err != nil {
+			return err
+		}
+		if todo.UserID != userID && !isAdmin {
+			return errNotOwner
+		}
+		if todo.UserID == to {
+			return nil
+		}
+		if err := tx.First(&auth.User{}, to).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNoSuchUser
+			}
+			return err
+		}
+		if err := checkQuota(tx, to, t.cfg.MaxTodosPerUser); err != nil {
+			return err
+		}
+		before := todo
+		todo.UserID = to
+		if err := tx.Model(&todo).Update("user_id", todo.UserID).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, audit.ActionTransfer, id, userID, before, todo)
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		apperr.Write(c, errTodoNotFound)
+	case errors.Is(err, errNoSuchUser):
+		invalid(c, fmt.Errorf("user %d: %w", to, err))
+	case err != nil:
+		apperr.Write(c, err)
+	default:
+		t.respond(c, http.StatusOK, todo)
+	}
+}
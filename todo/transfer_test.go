@@ -0,0 +1,97 @@
+package todo
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/audit"
+	"github.com/pradist/todoapi/auth"
+)
+
+const otherUserID = testUserID + 1
+
+// setupTransferHandler serves Transfer as user with the given middleware,
+// with users testUserID and otherUserID in the database.
+func setupTransferHandler(t *testing.T, as gin.HandlerFunc) (*TodoHandler, *gin.Engine) {
+	t.Helper()
+	handler, _ := setupTestHandler(t)
+	if err := handler.db.AutoMigrate(&auth.User{}); err != nil {
+		t.Fatalf("failed to migrate users: %v", err)
+	}
+	handler.db.Create(&[]auth.User{{Username: "alice"}, {Username: "bob"}})
+	router := gin.New()
+	router.Use(as)
+	router.POST("/todos/:id/transfer", handler.Transfer)
+	return handler, router
+}
+
+func doTransfer(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestTransfer_Owner: the owner hands a todo to another user and the move is audited
+func TestTransfer_Owner(t *testing.T) {
+	handler, router := setupTransferHandler(t, asUser(testUserID))
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Delegate me"})
+
+	w := doTransfer(router, "/todos/1/transfer", `{"to_user_id": 2}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var saved Todo
+	handler.db.First(&saved, 1)
+	if saved.UserID != otherUserID {
+		t.Errorf("expected the todo to belong to user %d, got %d", otherUserID, saved.UserID)
+	}
+	var entry audit.Log
+	handler.db.First(&entry)
+	if entry.Action != audit.ActionTransfer || entry.UserID != testUserID {
+		t.Errorf("expected a transfer by user %d, got %+v", testUserID, entry)
+	}
+}
+
+// TestTransfer_Admin: admins may transfer todos they don't own
+func TestTransfer_Admin(t *testing.T) {
+	handler, router := setupTransferHandler(t, asAdmin(99))
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Reassign me"})
+
+	if w := doTransfer(router, "/todos/1/transfer", `{"to_user_id": 2}`); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestTransfer_Errors(t *testing.T) {
+	handler, router := setupTransferHandler(t, asUser(testUserID))
+	handler.cfg.MaxTodosPerUser = 1
+	handler.db.Create(&Todo{UserID: testUserID, Title: "Mine"})
+	handler.db.Create(&Todo{UserID: otherUserID, Title: "Bob's"})
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"not owner", "/todos/2/transfer", `{"to_user_id": 1}`, http.StatusForbidden},
+		{"missing todo", "/todos/99/transfer", `{"to_user_id": 2}`, http.StatusNotFound},
+		{"unknown user", "/todos/1/transfer", `{"to_user_id": 42}`, http.StatusUnprocessableEntity},
+		{"missing user id", "/todos/1/transfer", `{}`, http.StatusUnprocessableEntity},
+		{"target over quota", "/todos/1/transfer", `{"to_user_id": 2}`, http.StatusForbidden},
+	}
+	for _, tc := range tests {
+		if w := doTransfer(router, tc.path, tc.body); w.Code != tc.want {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.want, w.Code)
+		}
+	}
+	if actions := auditActions(t, handler); len(actions) != 0 {
+		t.Errorf("expected no audit entries, got %v", actions)
+	}
+}
@@ -0,0 +1,163 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/apperr"
+	"gorm.io/gorm"
+)
+
+// Values of ?period= on GET /todos/trends.
+const (
+	PeriodDay  = "day"
+	PeriodWeek = "week"
+)
+
+// maxTrendBuckets bounds the range of one trends request.
+const maxTrendBuckets = 366
+
+// defaultTrendBuckets is the range shown when ?from= is omitted.
+var defaultTrendBuckets = map[string]int{PeriodDay: 30, PeriodWeek: 12}
+
+type trendBucket struct {
+	// Start is the first day of the bucket, in the request's zone.
+	Start     string `json:"start"`
+	Created   int64  `json:"created"`
+	Completed int64  `json:"completed"`
+}
+
+type trendsResponse struct {
+	Period  string        `json:"period"`
+	Zone    string        `json:"tz"`
+	Buckets []trendBucket `json:"buckets"`
+}
+
+// Trends counts the caller's todos created and completed per day or week,
+// for charts. Buckets start at midnight in the ?tz= zone, or else the
+// configured one; weeks start on Monday. ?from= and ?to= (YYYY-MM-DD) pick
+// the range, inclusive, and default to the last 30 days or 12 weeks up to
+// today. Deleted todos still count, as they were once created or done.
+func (t *TodoHandler) Trends(c *gin.Context) {
+	q, _, ok := t.owned(c)
+	if !ok {
+		return
+	}
+	if !selectZone(c) {
+		return
+	}
+	loc := t.location(c)
+	bounds, err := trendBounds(c, loc, time.Now())
+	if err != nil {
+		apperr.Write(c, apperr.ErrBadRequest.Wrap(err))
+		return
+	}
+
+	created := make([]int64, len(bounds)-1)
+	completed := make([]int64, len(bounds)-1)
+	err = t.retry(c, func() error {
+		if err := countByBucket(q, "created_at", bounds, created); err != nil {
+			return err
+		}
+		return countByBucket(q, "completed_at", bounds, completed)
+	})
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+
+	resp := trendsResponse{
+		Period:  c.DefaultQuery("period", PeriodDay),
+		Zone:    loc.String(),
+		Buckets: make([]trendBucket, len(created)),
+	}
+	for i := range resp.Buckets {
+		resp.Buckets[i] = trendBucket{Start: bounds[i].Format(time.DateOnly), Created: created[i], Completed: completed[i]}
+	}
+	t.respond(c, http.StatusOK, resp)
+}
+
+// trendBounds returns the local midnights that delimit the buckets of a
+// trends request: bucket i covers [bounds[i], bounds[i+1]). Stepping by
+// calendar days keeps days 23 or 25 hours long across DST changes.
+func trendBounds(c *gin.Context, loc *time.Location, now time.Time) ([]time.Time, error) {
+	period := c.DefaultQuery("period", PeriodDay)
+	days := map[string]int{PeriodDay: 1, PeriodWeek: 7}[period]
+	if days == 0 {
+		return nil, fmt.Errorf("period must be %s or %s, got %q", PeriodDay, PeriodWeek, period)
+	}
+	// bucketStart is the start of the bucket containing midnight d.
+	bucketStart := func(d time.Time) time.Time {
+		if period == PeriodWeek {
+			return d.AddDate(0, 0, -(int(d.Weekday())+6)%7)
+		}
+		return d
+	}
+
+	to := endOfDay(now, loc).AddDate(0, 0, -1)
+	if v, ok := c.GetQuery("to"); ok {
+		var err error
+		if to, err = time.ParseInLocation(time.DateOnly, v, loc); err != nil {
+			return nil, fmt.Errorf("to must be a date such as 2026-01-31, got %q", v)
+		}
+	}
+	from := bucketStart(to).AddDate(0, 0, -days*(defaultTrendBuckets[period]-1))
+	if v, ok := c.GetQuery("from"); ok {
+		var err error
+		if from, err = time.ParseInLocation(time.DateOnly, v, loc); err != nil {
+			return nil, fmt.Errorf("from must be a date such as 2026-01-01, got %q", v)
+		}
+	}
+	if from.After(to) {
+		return nil, errors.New("from must not be after to")
+	}
+
+	bounds := []time.Time{bucketStart(from)}
+	for !bounds[len(bounds)-1].After(to) {
+		if len(bounds) > maxTrendBuckets {
+			return nil, fmt.Errorf("range must span at most %d %ss", maxTrendBuckets, period)
+		}
+		bounds = append(bounds, bounds[len(bounds)-1].AddDate(0, 0, days))
+	}
+	return bounds, nil
+}
+
+// countByBucket adds up the todos of q whose column falls in each bucket
+// delimited by bounds, into counts. It runs one grouped query: a CASE maps
+// each timestamp to its bucket, so the zone's DST changes, which plain date
+// functions don't know about, are handled by the bounds.
+func countByBucket(q *gorm.DB, column string, bounds []time.Time, counts []int64) error {
+	var expr strings.Builder
+	expr.WriteString("CASE")
+	args := make([]any, 0, len(bounds))
+	for i, bound := range bounds[1 : len(bounds)-1] {
+		fmt.Fprintf(&expr, " WHEN %s < ? THEN %d", column, i)
+		// SQLite compares timestamps as text, so bounds go in UTC like
+		// the stored times.
+		args = append(args, bound.UTC())
+	}
+	fmt.Fprintf(&expr, " ELSE %d END", len(bounds)-2)
+
+	var rows []struct {
+		Bucket int
+		N      int64
+	}
+	err := q.Model(&Todo{}).Unscoped().
+		Select(expr.String()+" AS bucket, COUNT(*) AS n", args...).
+		Where(column+" >= ? AND "+column+" < ?", bounds[0].UTC(), bounds[len(bounds)-1].UTC()).
+		Group("bucket").Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	for i := range counts {
+		counts[i] = 0
+	}
+	for _, row := range rows {
+		counts[row.Bucket] = row.N
+	}
+	return nil
+}
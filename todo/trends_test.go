@@ -0,0 +1,155 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func doTrends(t *testing.T, router *gin.Engine, query string) (trendsResponse, int) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos/trends"+query, nil))
+	var resp trendsResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+	}
+	return resp, w.Code
+}
+
+func trendCounts(resp trendsResponse) (starts []string, created, completed []int64) {
+	for _, b := range resp.Buckets {
+		starts = append(starts, b.Start)
+		created = append(created, b.Created)
+		completed = append(completed, b.Completed)
+	}
+	return starts, created, completed
+}
+
+func setupTrends(t *testing.T) (*TodoHandler, *gin.Engine) {
+	t.Helper()
+	handler, router := setupTestHandler(t)
+	router.GET("/todos/trends", handler.Trends)
+
+	utcAt := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	create := func(userID uint, created, completed string) *Todo {
+		todo := &Todo{UserID: userID, Title: "trend"}
+		todo.CreatedAt = utcAt(created)
+		if completed != "" {
+			done := utcAt(completed)
+			todo.Completed, todo.CompletedAt = true, &done
+		}
+		handler.db.Create(todo)
+		return todo
+	}
+	// In Bangkok (+07:00) the first two are created on 1 March.
+	create(testUserID, "2026-02-28T18:00:00Z", "")
+	create(testUserID, "2026-03-01T16:59:00Z", "2026-03-02T01:00:00Z")
+	handler.db.Delete(create(testUserID, "2026-03-02T05:00:00Z", "2026-03-02T06:00:00Z"))
+	create(testUserID, "2026-03-02T17:00:00Z", "")
+	create(testUserID, "2026-03-09T17:00:00Z", "2026-03-10T03:00:00Z")
+	create(testUserID+1, "2026-03-02T05:00:00Z", "2026-03-02T06:00:00Z")
+	return handler, router
+}
+
+// TestTrends_Days: created and completed todos are counted per local day, deleted ones included
+func TestTrends_Days(t *testing.T) {
+	_, router := setupTrends(t)
+
+	resp, code := doTrends(t, router, "?from=2026-03-01&to=2026-03-03&tz=%2B07:00")
+
+	if code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	starts, created, completed := trendCounts(resp)
+	if !slices.Equal(starts, []string{"2026-03-01", "2026-03-02", "2026-03-03"}) {
+		t.Errorf("unexpected buckets %v", starts)
+	}
+	if !slices.Equal(created, []int64{2, 1, 1}) || !slices.Equal(completed, []int64{0, 2, 0}) {
+		t.Errorf("expected created [2 1 1] and completed [0 2 0], got %v and %v", created, completed)
+	}
+	if resp.Period != PeriodDay || resp.Zone != "+07:00" {
+		t.Errorf("unexpected period %q or zone %q", resp.Period, resp.Zone)
+	}
+}
+
+// TestTrends_Weeks: weeks start on Monday and cover the whole range
+func TestTrends_Weeks(t *testing.T) {
+	_, router := setupTrends(t)
+
+	resp, code := doTrends(t, router, "?period=week&from=2026-03-04&to=2026-03-10&tz=%2B07:00")
+
+	if code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	starts, created, completed := trendCounts(resp)
+	if !slices.Equal(starts, []string{"2026-03-02", "2026-03-09"}) {
+		t.Errorf("unexpected buckets %v", starts)
+	}
+	if !slices.Equal(created, []int64{2, 1}) || !slices.Equal(completed, []int64{2, 1}) {
+		t.Errorf("expected created [2 1] and completed [2 1], got %v and %v", created, completed)
+	}
+}
+
+// TestTrends_Defaults: without a range, the last 30 days up to today are returned
+func TestTrends_Defaults(t *testing.T) {
+	_, router := setupTrends(t)
+
+	resp, code := doTrends(t, router, "?tz=UTC")
+
+	if code != http.StatusOK || len(resp.Buckets) != 30 {
+		t.Fatalf("expected 30 buckets, got %d (status %d)", len(resp.Buckets), code)
+	}
+	if got := resp.Buckets[29].Start; got != time.Now().UTC().Format(time.DateOnly) {
+		t.Errorf("expected the last bucket to be today, got %s", got)
+	}
+}
+
+// TestTrends_Invalid: unknown periods, bad dates and oversized ranges are 400
+func TestTrends_Invalid(t *testing.T) {
+	_, router := setupTrends(t)
+
+	for _, query := range []string{
+		"?period=month",
+		"?from=yesterday",
+		"?to=2026-02-30",
+		"?from=2026-03-02&to=2026-03-01",
+		"?from=2020-01-01&to=2026-01-01",
+		"?tz=Mars/Olympus",
+	} {
+		if _, code := doTrends(t, router, query); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, code)
+		}
+	}
+}
+
+// TestTrendBounds_DST: days follow the calendar across a daylight saving change
+func TestTrendBounds_DST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/?from=2026-03-07&to=2026-03-08", nil)
+
+	bounds, err := trendBounds(c, loc, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(bounds) != 3 || bounds[1].Sub(bounds[0]) != 24*time.Hour || bounds[2].Sub(bounds[1]) != 23*time.Hour {
+		t.Errorf("expected a 24h and a 23h day, got %v", bounds)
+	}
+}
@@ -0,0 +1,91 @@
+package todo
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mattn/go-sqlite3"
+	"github.com/pradist/todoapi/apperr"
+	"github.com/pradist/todoapi/auth"
+)
+
+type userStats struct {
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	Todos     int64  `json:"todos"`
+	Completed int64  `json:"completed"`
+	Deleted   int64  `json:"deleted"`
+	// LastActivity is when one of the user's todos was last created,
+	// changed or deleted; nil if they never had one.
+	LastActivity *time.Time `json:"last_activity"`
+}
+
+// UserStats lists every user with counts of their todos and when they
+// last touched one, by user id and paginated, from one grouped query. It
+// helps operators spot heavy users and abandoned accounts. The route must
+// be restricted to admins.
+func (t *TodoHandler) UserStats(c *gin.Context) {
+	p, ok := t.page(c)
+	if !ok {
+		return
+	}
+
+	namer := t.db.NamingStrategy
+	users, todos := namer.TableName("User"), namer.TableName("Todo")
+	var rows []struct {
+		UserID                    uint
+		Username                  string
+		Todos, Completed, Deleted int64
+		LastActivity              sql.NullString
+	}
+	err := t.retry(c, func() error {
+		rows = nil
+		return t.conn(c).Model(&auth.User{}).
+			Select(fmt.Sprintf(`%[1]s.id AS user_id, %[1]s.username,
+				COUNT(CASE WHEN %[2]s.id IS NOT NULL AND %[2]s.deleted_at IS NULL THEN 1 END) AS todos,
+				COUNT(CASE WHEN %[2]s.deleted_at IS NULL AND %[2]s.completed THEN 1 END) AS completed,
+				COUNT(%[2]s.deleted_at) AS deleted,
+				MAX(COALESCE(%[2]s.deleted_at, %[2]s.updated_at)) AS last_activity`, users, todos)).
+			Joins(fmt.Sprintf("LEFT JOIN %[2]s ON %[2]s.user_id = %[1]s.id", users, todos)).
+			Group(users + ".id").Order(users + ".id").
+			Limit(p.Limit).Offset(p.Offset()).Scan(&rows).Error
+	})
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+
+	stats := make([]userStats, len(rows))
+	for i, row := range rows {
+		stats[i] = userStats{
+			UserID:    row.UserID,
+			Username:  row.Username,
+			Todos:     row.Todos,
+			Completed: row.Completed,
+			Deleted:   row.Deleted,
+		}
+		if row.LastActivity.Valid {
+			at, err := parseSQLiteTime(row.LastActivity.String)
+			if err != nil {
+				apperr.Write(c, err)
+				return
+			}
+			stats[i].LastActivity = &at
+		}
+	}
+	t.respond(c, http.StatusOK, stats)
+}
+
+// parseSQLiteTime parses a time SQLite returned as text, as it does for
+// aggregates such as MAX, which lose the column's declared type.
+func parseSQLiteTime(s string) (time.Time, error) {
+	for _, layout := range sqlite3.SQLiteTimestampFormats {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised time %q", s)
+}
@@ -0,0 +1,82 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pradist/todoapi/auth"
+)
+
+func setupUserStats(t *testing.T) *gin.Engine {
+	t.Helper()
+	handler, router := setupTestHandler(t)
+	router.GET("/admin/users/stats", handler.UserStats)
+	if err := handler.db.AutoMigrate(&auth.User{}); err != nil {
+		t.Fatalf("failed to migrate users: %v", err)
+	}
+
+	for _, name := range []string{"alice", "bob", "carol"} {
+		handler.db.Create(&auth.User{Username: name, Password: "x"})
+	}
+	handler.db.Create(&Todo{UserID: 1, Title: "open"})
+	handler.db.Create(&Todo{UserID: 1, Title: "done", Completed: true})
+	gone := &Todo{UserID: 1, Title: "gone", Completed: true}
+	handler.db.Create(gone)
+	handler.db.Delete(gone)
+	handler.db.Create(&Todo{UserID: 2, Title: "bob's"})
+	return router
+}
+
+func doUserStats(t *testing.T, router *gin.Engine, query string) []userStats {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/users/stats"+query, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var stats []userStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return stats
+}
+
+// TestUserStats_Counts: every user is listed with live, completed and deleted todos counted apart
+func TestUserStats_Counts(t *testing.T) {
+	router := setupUserStats(t)
+	start := time.Now().Add(-time.Minute)
+
+	stats := doUserStats(t, router, "")
+
+	if len(stats) != 3 {
+		t.Fatalf("expected 3 users, got %d", len(stats))
+	}
+	alice, bob, carol := stats[0], stats[1], stats[2]
+	if alice.Username != "alice" || alice.Todos != 2 || alice.Completed != 1 || alice.Deleted != 1 {
+		t.Errorf("unexpected stats for alice: %+v", alice)
+	}
+	if bob.Todos != 1 || bob.Completed != 0 || bob.Deleted != 0 {
+		t.Errorf("unexpected stats for bob: %+v", bob)
+	}
+	if alice.LastActivity == nil || alice.LastActivity.Before(start) {
+		t.Errorf("expected a recent last activity for alice, got %v", alice.LastActivity)
+	}
+	if carol.Todos != 0 || carol.LastActivity != nil {
+		t.Errorf("expected no todos and no activity for carol, got %+v", carol)
+	}
+}
+
+// TestUserStats_Paginated: users are paged by id
+func TestUserStats_Paginated(t *testing.T) {
+	router := setupUserStats(t)
+
+	stats := doUserStats(t, router, "?page=2&limit=2")
+
+	if len(stats) != 1 || stats[0].Username != "carol" {
+		t.Errorf("expected only carol on page 2, got %+v", stats)
+	}
+}
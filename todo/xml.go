@@ -0,0 +1,78 @@
+package todo
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+const xmlMediaType = "application/xml; charset=utf-8"
+
+// todoXML is the XML form of a Todo. gorm.Model can't carry xml tags, so
+// its fields are copied out here.
+type todoXML struct {
+	XMLName      xml.Name   `xml:"todo"`
+	ID           uint       `xml:"id,attr"`
+	Title        string     `xml:"text"`
+	Truncated    bool       `xml:"truncated,omitempty"`
+	DueDate      *time.Time `xml:"due_date,omitempty"`
+	Completed    bool       `xml:"completed"`
+	CompletedAt  *time.Time `xml:"completed_at,omitempty"`
+	Status       string     `xml:"status"`
+	Priority     string     `xml:"priority"`
+	UserID       uint       `xml:"user_id"`
+	Estimated    int        `xml:"estimated_minutes"`
+	Actual       int        `xml:"actual_minutes"`
+	TimerStarted *time.Time `xml:"timer_started_at,omitempty"`
+	DeleteReason string     `xml:"delete_reason,omitempty"`
+	Deleted      bool       `xml:"deleted,omitempty"`
+	CreatedAt    time.Time  `xml:"created_at"`
+	UpdatedAt    time.Time  `xml:"updated_at"`
+	DeletedAt    *time.Time `xml:"deleted_at,omitempty"`
+}
+
+type todosXML struct {
+	XMLName xml.Name  `xml:"todos"`
+	Todos   []todoXML `xml:"todo"`
+}
+
+func newTodoXML(t Todo) todoXML {
+	x := todoXML{
+		ID:           t.ID,
+		Title:        t.Title,
+		Truncated:    t.Truncated,
+		DueDate:      t.DueDate,
+		Completed:    t.Completed,
+		CompletedAt:  t.CompletedAt,
+		Status:       t.Status,
+		Priority:     t.Priority,
+		UserID:       t.UserID,
+		Estimated:    t.EstimatedMinutes,
+		Actual:       t.ActualMinutes,
+		TimerStarted: t.TimerStartedAt,
+		DeleteReason: t.DeleteReason,
+		Deleted:      t.Deleted,
+		CreatedAt:    t.CreatedAt,
+		UpdatedAt:    t.UpdatedAt,
+	}
+	if t.DeletedAt.Valid {
+		x.DeletedAt = &t.DeletedAt.Time
+	}
+	return x
+}
+
+// toXML returns the XML document for a Todo or []Todo. Other data has no
+// XML form and reports false.
+func toXML(data any) (any, bool) {
+	switch v := data.(type) {
+	case Todo:
+		return newTodoXML(v), true
+	case []Todo:
+		doc := todosXML{Todos: make([]todoXML, len(v))}
+		for i, t := range v {
+			doc.Todos[i] = newTodoXML(t)
+		}
+		return doc, true
+	default:
+		return nil, false
+	}
+}
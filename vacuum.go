@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// vacuumBusyRetry is how soon a vacuum put off by a busy database is tried
+// again.
+const vacuumBusyRetry = time.Minute
+
+// vacuumLoop vacuums the database every interval until ctx is done. A run
+// that finds the database busy is put off by vacuumBusyRetry, so VACUUM,
+// which holds the database while it rewrites the file, doesn't stall
+// requests.
+func vacuumLoop(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		if !idle(db) {
+			timer.Reset(vacuumBusyRetry)
+			continue
+		}
+		vacuum(ctx, db)
+		timer.Reset(interval)
+	}
+}
+
+// idle reports whether no connection of db is in use.
+func idle(db *gorm.DB) bool {
+	sqlDB, err := db.DB()
+	return err == nil && sqlDB.Stats().InUse == 0
+}
+
+// vacuum folds the write-ahead log back into the database file, then
+// rebuilds the file to return the free pages left by deletes and purges.
+// Failures are reported; the next run tries again.
+func vacuum(ctx context.Context, db *gorm.DB) {
+	conn := db.WithContext(ctx)
+	err := conn.Exec("PRAGMA wal_checkpoint(TRUNCATE)").Error
+	if err == nil {
+		err = conn.Exec("VACUUM").Error
+	}
+	if err != nil && ctx.Err() == nil {
+		fmt.Printf("sqlite vacuum failed: %s\n", err)
+	}
+}
+
+// startVacuum registers a shutdown hook for the SQLite vacuum job and
+// returns the function that starts it, to be called once the database is
+// ready. Without an interval, or on a database other than SQLite, the job
+// never runs.
+func startVacuum(db *gorm.DB, interval time.Duration, lc *lifecycle) func() {
+	if interval <= 0 || db.Dialector.Name() != "sqlite" {
+		return func() {}
+	}
+	return lc.job("sqlite vacuum", func(ctx context.Context) {
+		vacuumLoop(ctx, db, interval)
+	})
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pradist/todoapi/todo"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// postgresDialector is SQLite under another name, standing in for a
+// PostgreSQL database.
+type postgresDialector struct {
+	*sqlite.Dialector
+}
+
+func (postgresDialector) Name() string { return "postgres" }
+
+// TestVacuum_ReclaimsFreePages: vacuuming after a purge leaves no free pages behind
+func TestVacuum_ReclaimsFreePages(t *testing.T) {
+	db := setupTestDB(t)
+	todos := make([]todo.Todo, 200)
+	for i := range todos {
+		todos[i].Title = "padding padding padding padding padding padding"
+	}
+	db.Create(&todos)
+	db.Unscoped().Where("1 = 1").Delete(&todo.Todo{})
+
+	vacuum(context.Background(), db)
+
+	var free int
+	db.Raw("PRAGMA freelist_count").Scan(&free)
+	if free != 0 {
+		t.Errorf("expected no free pages after vacuum, got %d", free)
+	}
+}
+
+// TestStartVacuum_StopsOnShutdown: the shutdown hook waits for the vacuum job to stop
+func TestStartVacuum_StopsOnShutdown(t *testing.T) {
+	var lc lifecycle
+	start := startVacuum(setupTestDB(t), time.Hour, &lc)
+	start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := lc.shutdown(ctx); err != nil {
+		t.Fatalf("expected the vacuum job to stop, got %v", err)
+	}
+}
+
+// TestStartVacuum_Skipped: without an interval, or on PostgreSQL, nothing is registered
+func TestStartVacuum_Skipped(t *testing.T) {
+	postgres, err := gorm.Open(postgresDialector{sqlite.Open(":memory:").(*sqlite.Dialector)}, &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+
+	for name, db := range map[string]*gorm.DB{"no interval": setupTestDB(t), "postgres": postgres} {
+		interval := time.Hour
+		if name == "no interval" {
+			interval = 0
+		}
+		var lc lifecycle
+		startVacuum(db, interval, &lc)()
+		if len(lc.hooks) != 0 {
+			t.Errorf("%s: expected no shutdown hook, got %d", name, len(lc.hooks))
+		}
+	}
+}